@@ -13,7 +13,7 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, stopWorkers context.CancelFunc, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -31,6 +31,7 @@ func gracefulShutdown(apiServer *http.Server, done chan bool) {
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Error().Err(err).Msg("Server forced to shutdown with error")
 	}
+	stopWorkers()
 
 	log.Info().Msg("Server exiting")
 
@@ -42,13 +43,14 @@ func main() {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	server := server.NewServer(log.Logger)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	server := server.NewServer(workerCtx, log.Logger)
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, stopWorkers, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -146,6 +146,26 @@ func (s *service) createTables() error {
 		(*models.Reaction)(nil),
 		(*models.Meeting)(nil),
 		(*models.MeetingMember)(nil),
+		(*models.Session)(nil),
+		(*models.PasswordResetToken)(nil),
+		(*models.LoginLink)(nil),
+		(*models.RecoveryCode)(nil),
+		(*models.WebAuthnCredential)(nil),
+		(*models.WebAuthnChallenge)(nil),
+		(*models.WorkspaceOIDCConfig)(nil),
+		(*models.UserIdentity)(nil),
+		(*models.SSOLoginState)(nil),
+		(*models.WorkspaceSAMLConfig)(nil),
+		(*models.SSOLoginCode)(nil),
+		(*models.APIToken)(nil),
+		(*models.LoginAttempt)(nil),
+		(*models.SecurityEvent)(nil),
+		(*models.DataExport)(nil),
+		(*models.UserAvatar)(nil),
+		(*models.WorkspaceInvitation)(nil),
+		(*models.WorkspaceJoinRequest)(nil),
+		(*models.WorkspaceRole)(nil),
+		(*models.WorkspaceAuditEntry)(nil),
 	}
 
 	for _, model := range modelsToCreate {
@@ -158,5 +178,5 @@ func (s *service) createTables() error {
 		s.log.Info().Str("model", fmt.Sprintf("%T", model)).Msg("Table created")
 	}
 
-	return nil
+	return s.migrate(ctx)
 }
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// addedColumns brings tables made by an earlier version up to date. createTables only creates
+// missing tables, so every column added to an existing table since must be listed here as well.
+var addedColumns = []string{
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS title VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS pronouns VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS phone VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS status_text VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS status_emoji VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS status_expires_at TIMESTAMPTZ`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS dnd_until TIMESTAMPTZ`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS dnd_schedule JSONB`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_updated_at TIMESTAMPTZ`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMPTZ`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_used_step BIGINT NOT NULL DEFAULT 0`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ`,
+	`ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ`,
+
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS require_verified_email BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS is_open BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS allow_join_requests BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS is_discoverable BOOLEAN NOT NULL DEFAULT FALSE`,
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS plan VARCHAR NOT NULL DEFAULT 'free'`,
+
+	`ALTER TABLE workspace_members ADD COLUMN IF NOT EXISTS custom_role_id BIGINT`,
+	`ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_name VARCHAR`,
+	`ALTER TABLE sso_login_states ADD COLUMN IF NOT EXISTS link_user_id BIGINT`,
+}
+
+// workspaceSlugs adds the slug column to workspaces made before there were slugs. Each gets one
+// made from its name, like new workspaces do; the workspace ID is appended when the name gives
+// no usable slug or another workspace got it first. The constraints follow once every row has one.
+var workspaceSlugs = []string{
+	`ALTER TABLE workspaces ADD COLUMN IF NOT EXISTS slug VARCHAR`,
+	`WITH base AS (
+		SELECT id, rtrim(left(trim(BOTH '-' FROM regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), 36), '-') AS slug
+		FROM workspaces
+		WHERE slug IS NULL
+	), ranked AS (
+		SELECT id, slug, row_number() OVER (PARTITION BY slug ORDER BY id) AS n
+		FROM base
+	)
+	UPDATE workspaces AS w
+	SET slug = CASE
+		WHEN length(r.slug) < 3 OR r.slug !~ '[a-z]' THEN 'workspace-' || w.id
+		WHEN r.n = 1 AND NOT EXISTS (SELECT 1 FROM workspaces AS o WHERE o.slug = r.slug) THEN r.slug
+		ELSE r.slug || '-' || w.id
+	END
+	FROM ranked AS r
+	WHERE w.id = r.id`,
+	`ALTER TABLE workspaces ALTER COLUMN slug SET NOT NULL`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS workspaces_slug_key ON workspaces (slug)`,
+}
+
+// migrate runs after createTables. Every step is safe to repeat, so it runs on each start.
+func (s *service) migrate(ctx context.Context) error {
+	steps := append(append([]string{}, addedColumns...), workspaceSlugs...)
+	for _, step := range steps {
+		if _, err := s.db.ExecContext(ctx, step); err != nil {
+			return fmt.Errorf("failed to migrate schema: %w", err)
+		}
+	}
+	s.log.Info().Int("steps", len(steps)).Msg("Schema migrated")
+	return nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type AccountHandler struct {
+	accountService services.AccountService
+	log            zerolog.Logger
+}
+
+func NewAccountHandler(as services.AccountService, logger zerolog.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: as,
+		log:            logger,
+	}
+}
+
+func (h *AccountHandler) Deactivate(c *gin.Context) {
+	h.log.Info().Msg("Handling DeactivateAccount request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in DeactivateAccount")
+		return
+	}
+
+	if err := h.accountService.Deactivate(c.Request.Context(), userID); err != nil {
+		h.writeError(c, err, "Failed to deactivate account")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Account deactivated successfully")
+	if c.GetHeader("Authorization") == "" {
+		utils.ClearSessionCookies(c)
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *AccountHandler) Reactivate(c *gin.Context) {
+	h.log.Info().Msg("Handling ReactivateAccount request")
+	var form models.ReactivateAccountModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ReactivateAccount")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.Reactivate(c.Request.Context(), form, c.ClientIP()); err != nil {
+		h.writeError(c, err, "Failed to reactivate account")
+		return
+	}
+
+	h.log.Info().Msg("Account reactivated successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *AccountHandler) RequestExport(c *gin.Context) {
+	h.log.Info().Msg("Handling RequestDataExport request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RequestDataExport")
+		return
+	}
+
+	export, err := h.accountService.RequestExport(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to request data export")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, export)
+}
+
+func (h *AccountHandler) ListExports(c *gin.Context) {
+	h.log.Info().Msg("Handling ListDataExports request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ListDataExports")
+		return
+	}
+
+	exports, err := h.accountService.ListExports(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to list data exports")
+		return
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+func (h *AccountHandler) DownloadExport(c *gin.Context) {
+	h.log.Info().Msg("Handling DownloadDataExport request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in DownloadDataExport")
+		return
+	}
+	exportID, err := strconv.Atoi(c.Param("exportID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	export, path, err := h.accountService.GetExportFile(c.Request.Context(), userID, exportID)
+	if err != nil {
+		h.writeError(c, err, "Failed to download data export")
+		return
+	}
+
+	c.FileAttachment(path, fmt.Sprintf("axis-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02")))
+}
+
+// Erase removes the caller's account for good. It backs DELETE /api/users.
+func (h *AccountHandler) Erase(c *gin.Context) {
+	h.log.Info().Msg("Handling EraseAccount request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in EraseAccount")
+		return
+	}
+
+	var form models.EraseAccountModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for EraseAccount")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.accountService.Erase(c.Request.Context(), userID, form, c.ClientIP()); err != nil {
+		h.writeError(c, err, "Failed to erase account")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Account erased successfully")
+	if c.GetHeader("Authorization") == "" {
+		utils.ClearSessionCookies(c)
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *AccountHandler) writeError(c *gin.Context, err error, message string) {
+	switch e := err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.UnauthorizedError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case *services.TooManyRequestsError:
+		h.log.Warn().Err(err).Msg(message)
+		c.Header("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
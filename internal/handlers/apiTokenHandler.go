@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type APITokenHandler struct {
+	apiTokenService services.APITokenService
+	log             zerolog.Logger
+}
+
+func NewAPITokenHandler(ats services.APITokenService, logger zerolog.Logger) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: ats,
+		log:             logger,
+	}
+}
+
+func (h *APITokenHandler) CreateToken(c *gin.Context) {
+	h.log.Info().Msg("Handling CreateToken request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in CreateToken")
+		return
+	}
+
+	var form models.CreateAPITokenModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for CreateToken")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.apiTokenService.CreateToken(c.Request.Context(), userID, form)
+	if err != nil {
+		switch err.(type) {
+		case *services.ValidationError:
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Invalid API token request")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case *services.ForbiddenError:
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("API token requested for a foreign workspace")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to create API token via service")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		}
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("token_id", token.ID).Msg("API token created successfully")
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *APITokenHandler) ListTokens(c *gin.Context) {
+	h.log.Info().Msg("Handling ListTokens request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ListTokens")
+		return
+	}
+
+	tokens, err := h.apiTokenService.ListTokens(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to list API tokens via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *APITokenHandler) RevokeToken(c *gin.Context) {
+	h.log.Info().Msg("Handling RevokeToken request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RevokeToken")
+		return
+	}
+
+	idStr := c.Param("tokenID")
+	tokenID, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("tokenID_param", idStr).Msg("Invalid token ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	err = h.apiTokenService.RevokeToken(c.Request.Context(), userID, tokenID)
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Int("token_id", tokenID).Msg("API token not found for revocation")
+			c.JSON(http.StatusNotFound, gin.H{"error": "API token not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Int("token_id", tokenID).Msg("Failed to revoke API token via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("token_id", tokenID).Msg("API token revoked successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *APITokenHandler) ListScopes(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIScopes)
+}
@@ -33,6 +33,15 @@ func (h *AttachmentHandler) CreateAttachment(c *gin.Context) {
 
 	createdAttachment, err := h.attachmentService.CreateAttachment(c.Request.Context(), &attachment)
 	if err != nil {
+		if quotaErr, ok := err.(*services.QuotaExceededError); ok {
+			h.log.Warn().Err(err).Int("message_id", attachment.MessageID).Msg("Workspace storage limit reached")
+			writeQuotaError(c, quotaErr)
+			return
+		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Msg("Failed to create attachment via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment"})
 		return
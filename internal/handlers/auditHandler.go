@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+	log          zerolog.Logger
+}
+
+func NewAuditHandler(as services.AuditService, logger zerolog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: as,
+		log:          logger,
+	}
+}
+
+// GetAuditLog lists the changes made to a workspace's members and ownership, newest first.
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetAuditLog")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+		return
+	}
+
+	entries, err := h.auditService.GetAuditLog(c.Request.Context(), userID, workspaceID, limit, offset)
+	if err != nil {
+		switch err.(type) {
+		case *services.NotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case *services.ForbiddenError:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get audit log")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get audit log"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type AvatarHandler struct {
+	avatarService services.AvatarService
+	log           zerolog.Logger
+}
+
+func NewAvatarHandler(as services.AvatarService, logger zerolog.Logger) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: as,
+		log:           logger,
+	}
+}
+
+// UploadAvatar takes the image in the "avatar" field of a multipart form.
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	h.log.Info().Msg("Handling UploadAvatar request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in UploadAvatar")
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, services.MaxAvatarUploadSize+64<<10)
+	file, _, err := c.Request.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar is too large"})
+			return
+		}
+		h.log.Warn().Err(err).Msg("Missing avatar file in UploadAvatar")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An image is required in the avatar form field"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxAvatarUploadSize+1))
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to read avatar upload")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read avatar"})
+		return
+	}
+
+	user, err := h.avatarService.SetAvatar(c.Request.Context(), userID, data)
+	if err != nil {
+		h.writeError(c, err, "Failed to update avatar")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Avatar uploaded successfully")
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AvatarHandler) RemoveAvatar(c *gin.Context) {
+	h.log.Info().Msg("Handling RemoveAvatar request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RemoveAvatar")
+		return
+	}
+
+	user, err := h.avatarService.RemoveAvatar(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to remove avatar")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Avatar removed successfully")
+	c.JSON(http.StatusOK, user)
+}
+
+// GetAvatar serves a user's avatar. The optional size query parameter picks the closest
+// standard size; the default is the largest.
+func (h *AvatarHandler) GetAvatar(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	size := utils.AvatarSizes[len(utils.AvatarSizes)-1]
+	if v := c.Query("size"); v != "" {
+		if size, err = strconv.Atoi(v); err != nil || size < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size"})
+			return
+		}
+	}
+
+	avatar, err := h.avatarService.GetAvatar(c.Request.Context(), userID, size)
+	if err != nil {
+		h.writeError(c, err, "Failed to get avatar")
+		return
+	}
+
+	// Avatar URLs carry a version that changes on upload, so versioned requests can be cached.
+	if c.Query("v") != "" {
+		c.Header("Cache-Control", "public, max-age=604800")
+	} else {
+		c.Header("Cache-Control", "public, max-age=300")
+	}
+	c.Header("Content-Type", avatar.ContentType)
+	http.ServeContent(c.Writer, c.Request, "", avatar.ModTime, bytes.NewReader(avatar.Data))
+}
+
+func (h *AvatarHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
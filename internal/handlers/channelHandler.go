@@ -41,6 +41,16 @@ func (h *ChannelHandler) CreateChannel(c *gin.Context) {
 
 	createdChannel, err := h.channelService.CreateChannel(c.Request.Context(), &channel)
 	if err != nil {
+		if _, ok := err.(*services.ForbiddenError); ok {
+			h.log.Warn().Err(err).Int("creator_id", int(userID)).Int("workspace_id", channel.WorkspaceID).Msg("User forbidden from creating channel")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("workspace_id", channel.WorkspaceID).Msg("Workspace not found for channel creation")
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Int("creator_id", int(userID)).Str("channel_name", channel.Name).Msg("Failed to create channel via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create channel"})
 		return
@@ -154,17 +164,16 @@ func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Info().Int("channel_id", id).Msg("Channel not found for update")
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Int("user_id", int(userID)).Int("channel_id", id).Msg("Failed to update channel via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update channel"})
 		return
 	}
 
-	if updatedChannel == nil {
-		h.log.Info().Int("channel_id", id).Msg("Channel not found for update")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
-		return
-	}
-
 	h.log.Info().Int("channel_id", updatedChannel.ID).Int("user_id", int(userID)).Msg("Channel updated successfully")
 	c.JSON(http.StatusOK, updatedChannel)
 }
@@ -193,6 +202,11 @@ func (h *ChannelHandler) DeleteChannel(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Info().Int("channel_id", id).Msg("Channel not found for deletion")
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Int("user_id", int(userID)).Int("channel_id", id).Msg("Failed to delete channel via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete channel"})
 		return
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"axis/internal/services"
+	"axis/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
@@ -23,6 +24,11 @@ func NewChannelMemberHandler(cms services.ChannelMemberService, logger zerolog.L
 
 func (h *ChannelMemberHandler) AddMemberToChannel(c *gin.Context) {
 	h.log.Info().Msg("Handling AddMemberToChannel request")
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in AddMemberToChannel")
+		return
+	}
 	channelIDStr := c.Param("channelID")
 	h.log.Debug().Str("channelID_param", channelIDStr).Msg("Parsing channel ID")
 	channelID, err := strconv.Atoi(channelIDStr)
@@ -41,10 +47,9 @@ func (h *ChannelMemberHandler) AddMemberToChannel(c *gin.Context) {
 		return
 	}
 
-	channelMember, err := h.channelMemberService.AddMemberToChannel(c.Request.Context(), channelID, reqBody.UserID)
+	channelMember, err := h.channelMemberService.AddMemberToChannel(c.Request.Context(), actorID, channelID, reqBody.UserID)
 	if err != nil {
-		h.log.Error().Err(err).Int("channel_id", channelID).Int("user_id", reqBody.UserID).Msg("Failed to add member to channel via service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member to channel"})
+		h.writeError(c, err, "Failed to add member to channel")
 		return
 	}
 
@@ -54,6 +59,11 @@ func (h *ChannelMemberHandler) AddMemberToChannel(c *gin.Context) {
 
 func (h *ChannelMemberHandler) RemoveMemberFromChannel(c *gin.Context) {
 	h.log.Info().Msg("Handling RemoveMemberFromChannel request")
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RemoveMemberFromChannel")
+		return
+	}
 	channelIDStr := c.Param("channelID")
 	h.log.Debug().Str("channelID_param", channelIDStr).Msg("Parsing channel ID for removal")
 	channelID, err := strconv.Atoi(channelIDStr)
@@ -72,10 +82,9 @@ func (h *ChannelMemberHandler) RemoveMemberFromChannel(c *gin.Context) {
 		return
 	}
 
-	err = h.channelMemberService.RemoveMemberFromChannel(c.Request.Context(), channelID, userID)
+	err = h.channelMemberService.RemoveMemberFromChannel(c.Request.Context(), actorID, channelID, userID)
 	if err != nil {
-		h.log.Error().Err(err).Int("channel_id", channelID).Int("user_id", userID).Msg("Failed to remove member from channel via service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member from channel"})
+		h.writeError(c, err, "Failed to remove member from channel")
 		return
 	}
 
@@ -104,3 +113,20 @@ func (h *ChannelMemberHandler) GetChannelMembers(c *gin.Context) {
 	h.log.Info().Int("channel_id", channelID).Int("members_count", len(members)).Msg("Channel members retrieved successfully")
 	c.JSON(http.StatusOK, members)
 }
+
+func (h *ChannelMemberHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ForbiddenError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
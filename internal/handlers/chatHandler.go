@@ -26,14 +26,18 @@ var upgrader = websocket.Upgrader{
 }
 
 type ChatHandler struct {
-	chatService services.MeetingChatService
-	log         zerolog.Logger
+	chatService     services.MeetingChatService
+	userService     services.UserService
+	presenceService services.PresenceService
+	log             zerolog.Logger
 }
 
-func NewChatHandler(cs services.MeetingChatService, logger zerolog.Logger) *ChatHandler {
+func NewChatHandler(cs services.MeetingChatService, us services.UserService, ps services.PresenceService, logger zerolog.Logger) *ChatHandler {
 	return &ChatHandler{
-		chatService: cs,
-		log:         logger,
+		chatService:     cs,
+		userService:     us,
+		presenceService: ps,
+		log:             logger,
 	}
 }
 
@@ -73,6 +77,8 @@ func (h *ChatHandler) ServeMeetingChatWs(c *gin.Context) {
 
 	h.chatService.RegisterClient(meetingID, client)
 	defer h.chatService.UnregisterClient(meetingID, client)
+	h.presenceService.Connect(client)
+	defer h.presenceService.Disconnect(client)
 
 	// Send join notification to all clients
 	joinData := models.WSRoomData{
@@ -80,7 +86,7 @@ func (h *ChatHandler) ServeMeetingChatWs(c *gin.Context) {
 		UserID:    userID,
 		Action:    "join",
 		Timestamp: time.Now(),
-		User:      &models.WSUserData{ID: userID, Name: "", Username: ""}, // TODO: Get user details
+		User:      h.wsUser(c.Request.Context(), userID),
 	}
 	h.broadcastWSMessage(meetingID, "room", joinData)
 
@@ -116,7 +122,13 @@ func (h *ChatHandler) readPump(ctx context.Context, client *utils.Client, meetin
 			continue
 		}
 
+		if wsMessage.Type != "heartbeat" {
+			h.presenceService.Heartbeat(client, false)
+		}
+
 		switch wsMessage.Type {
+		case "heartbeat":
+			h.handleWSHeartbeat(client, wsMessage)
 		case "message":
 			h.handleWSMessage(ctx, client, meetingID, wsMessage)
 		case "reaction":
@@ -131,6 +143,22 @@ func (h *ChatHandler) readPump(ctx context.Context, client *utils.Client, meetin
 	}
 }
 
+func (h *ChatHandler) handleWSHeartbeat(client *utils.Client, wsMessage models.WSMessage) {
+	dataBytes, err := json.Marshal(wsMessage.Data)
+	if err != nil {
+		h.sendError(client, "INVALID_HEARTBEAT_DATA", "Invalid heartbeat data", err.Error())
+		return
+	}
+
+	var heartbeat models.WSHeartbeatData
+	if err := json.Unmarshal(dataBytes, &heartbeat); err != nil {
+		h.sendError(client, "INVALID_HEARTBEAT_DATA", "Invalid heartbeat data", err.Error())
+		return
+	}
+
+	h.presenceService.Heartbeat(client, heartbeat.Idle)
+}
+
 func (h *ChatHandler) handleWSMessage(ctx context.Context, client *utils.Client, meetingID int, wsMessage models.WSMessage) {
 	// Parse message data
 	dataBytes, err := json.Marshal(wsMessage.Data)
@@ -172,6 +200,10 @@ func (h *ChatHandler) handleWSMessage(ctx context.Context, client *utils.Client,
 
 	savedMessage, err := h.chatService.SendMessage(ctx, meetingID, client.ID, msgData.ReplyTo, msgData.Content, messageType, attachments)
 	if err != nil {
+		if _, ok := err.(*services.QuotaExceededError); ok {
+			h.sendError(client, "QUOTA_EXCEEDED", "Failed to send message", err.Error())
+			return
+		}
 		h.sendError(client, "SEND_FAILED", "Failed to send message", err.Error())
 		return
 	}
@@ -186,7 +218,7 @@ func (h *ChatHandler) handleWSMessage(ctx context.Context, client *utils.Client,
 		Type:      string(msgData.Type),
 		ReplyTo:   msgData.ReplyTo,
 		Files:     msgData.Files,
-		User:      &models.WSUserData{ID: client.ID, Name: "", Username: ""}, // TODO: Get user details
+		User:      h.wsUser(ctx, client.ID),
 	}
 
 	// Broadcast simplified message
@@ -208,9 +240,9 @@ func (h *ChatHandler) handleWSReaction(ctx context.Context, client *utils.Client
 
 	var errReaction error
 	if reactionData.Action == "add" {
-		_, errReaction = h.chatService.AddReaction(ctx, reactionData.MessageID, client.ID, reactionData.Emoji)
+		_, errReaction = h.chatService.AddReaction(ctx, meetingID, reactionData.MessageID, client.ID, reactionData.Emoji)
 	} else if reactionData.Action == "remove" {
-		_, errReaction = h.chatService.RemoveReaction(ctx, reactionData.MessageID, client.ID, reactionData.Emoji)
+		_, errReaction = h.chatService.RemoveReaction(ctx, meetingID, reactionData.MessageID, client.ID, reactionData.Emoji)
 	} else {
 		h.sendError(client, "INVALID_REACTION_ACTION", "Invalid reaction action", fmt.Sprintf("Action: %s", reactionData.Action))
 		return
@@ -228,7 +260,7 @@ func (h *ChatHandler) handleWSReaction(ctx context.Context, client *utils.Client
 		Emoji:     reactionData.Emoji,
 		Action:    reactionData.Action,
 		Timestamp: time.Now(),
-		User:      &models.WSUserData{ID: client.ID, Name: "", Username: ""}, // TODO: Get user details
+		User:      h.wsUser(ctx, client.ID),
 	}
 
 	h.broadcastWSMessage(meetingID, "reaction", responseData)
@@ -255,7 +287,7 @@ func (h *ChatHandler) handleWSTyping(ctx context.Context, client *utils.Client,
 
 	// Set user ID and broadcast
 	typingData.UserID = client.ID
-	typingData.User = &models.WSUserData{ID: client.ID, Name: "", Username: ""} // TODO: Get user details
+	typingData.User = h.wsUser(ctx, client.ID)
 
 	h.broadcastWSMessage(meetingID, "typing", typingData)
 }
@@ -294,7 +326,14 @@ func (h *ChatHandler) handleWSHistory(ctx context.Context, client *utils.Client,
 
 	// Convert to WS format
 	var wsMessages []models.WSMessageData
+	senders := make(map[int]*models.WSUserData)
 	for _, msg := range messages {
+		sender, ok := senders[msg.SenderID]
+		if !ok {
+			sender = h.wsUser(ctx, msg.SenderID)
+			senders[msg.SenderID] = sender
+		}
+
 		var files []models.WSAttachmentData
 		for _, att := range msg.Attachments {
 			files = append(files, models.WSAttachmentData{
@@ -315,7 +354,7 @@ func (h *ChatHandler) handleWSHistory(ctx context.Context, client *utils.Client,
 			Type:      "text", // TODO: Convert from MessageType
 			ReplyTo:   msg.ParentMessageID,
 			Files:     files,
-			User:      &models.WSUserData{ID: msg.SenderID, Name: "", Username: ""}, // TODO: Get user details
+			User:      sender,
 		})
 	}
 
@@ -331,6 +370,21 @@ func (h *ChatHandler) handleWSHistory(ctx context.Context, client *utils.Client,
 	h.sendWSMessage(client, "history", responseData)
 }
 
+// wsUser describes a user in WebSocket payloads. If the user cannot be loaded only the ID is sent.
+func (h *ChatHandler) wsUser(ctx context.Context, userID int) *models.WSUserData {
+	user, err := h.userService.GetUserByID(ctx, userID)
+	if err != nil || user == nil {
+		h.log.Warn().Err(err).Int("user_id", userID).Msg("Failed to load user details for WebSocket payload")
+		return &models.WSUserData{ID: userID}
+	}
+	return &models.WSUserData{
+		ID:       user.ID,
+		Name:     user.Name,
+		Username: user.Username,
+		Avatar:   user.AvatarURL(),
+	}
+}
+
 func (h *ChatHandler) writePump(client *utils.Client, meetingID int) {
 	ticker := time.NewTicker(50 * time.Second)
 	defer func() {
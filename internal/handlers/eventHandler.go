@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+)
+
+// EventHandler serves the account-level event stream, which carries updates about other users
+// rather than about a single meeting.
+type EventHandler struct {
+	eventService    services.EventService
+	presenceService services.PresenceService
+	log             zerolog.Logger
+}
+
+func NewEventHandler(es services.EventService, ps services.PresenceService, logger zerolog.Logger) *EventHandler {
+	return &EventHandler{
+		eventService:    es,
+		presenceService: ps,
+		log:             logger,
+	}
+}
+
+func (h *EventHandler) ServeEventsWs(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context for events WebSocket")
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to upgrade connection to WebSocket")
+		return
+	}
+
+	client := &utils.Client{ID: userID, Conn: conn, Message: make(chan []byte, 256)}
+	h.eventService.Register(client)
+	h.presenceService.Connect(client)
+
+	go h.writePump(client)
+	h.readPump(client)
+}
+
+// readPump keeps the connection alive and takes heartbeats; clients send nothing else.
+func (h *EventHandler) readPump(client *utils.Client) {
+	defer func() {
+		h.presenceService.Disconnect(client)
+		h.eventService.Unregister(client)
+		client.Conn.Close()
+	}()
+
+	client.Conn.SetReadLimit(512)
+	client.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	client.Conn.SetPongHandler(func(string) error { client.Conn.SetReadDeadline(time.Now().Add(60 * time.Second)); return nil })
+
+	for {
+		_, messageBytes, err := client.Conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.log.Error().Err(err).Int("user_id", client.ID).Msg("Unexpected close error on events WebSocket")
+			}
+			return
+		}
+
+		var heartbeat struct {
+			Type string                 `json:"type"`
+			Data models.WSHeartbeatData `json:"data"`
+		}
+		if err := json.Unmarshal(messageBytes, &heartbeat); err != nil || heartbeat.Type != "heartbeat" {
+			h.log.Debug().Int("user_id", client.ID).Msg("Ignoring unexpected message on events WebSocket")
+			continue
+		}
+		h.presenceService.Heartbeat(client, heartbeat.Data.Idle)
+	}
+}
+
+func (h *EventHandler) writePump(client *utils.Client) {
+	ticker := time.NewTicker(50 * time.Second)
+	defer func() {
+		ticker.Stop()
+		client.Conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-client.Message:
+			client.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if !ok {
+				client.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := client.Conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				h.log.Error().Err(err).Int("user_id", client.ID).Msg("Failed to write event to WebSocket")
+				return
+			}
+		case <-ticker.C:
+			client.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := client.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				h.log.Error().Err(err).Int("user_id", client.ID).Msg("Failed to send ping message")
+				return
+			}
+		}
+	}
+}
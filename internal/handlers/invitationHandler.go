@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type InvitationHandler struct {
+	invitationService services.InvitationService
+	log               zerolog.Logger
+}
+
+func NewInvitationHandler(is services.InvitationService, logger zerolog.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: is,
+		log:               logger,
+	}
+}
+
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	h.log.Info().Msg("Handling CreateInvitation request")
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in CreateInvitation")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var form models.CreateInvitationModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for CreateInvitation")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(c.Request.Context(), adminID, workspaceID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to create invitation")
+		return
+	}
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ListInvitations")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	invitations, err := h.invitationService.ListInvitations(c.Request.Context(), adminID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to list invitations")
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	h.log.Info().Msg("Handling RevokeInvitation request")
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RevokeInvitation")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+	invitationID, err := strconv.Atoi(c.Param("invitationID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(c.Request.Context(), adminID, workspaceID, invitationID); err != nil {
+		h.writeError(c, err, "Failed to revoke invitation")
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// PreviewInvitation tells the holder of an invitation link which workspace it is for, so the
+// app can show it before they sign in or accept.
+func (h *InvitationHandler) PreviewInvitation(c *gin.Context) {
+	var form models.AcceptInvitationModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.invitationService.PreviewInvitation(c.Request.Context(), form.Token)
+	if err != nil {
+		h.writeError(c, err, "Failed to preview invitation")
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *InvitationHandler) AcceptInvitation(c *gin.Context) {
+	h.log.Info().Msg("Handling AcceptInvitation request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in AcceptInvitation")
+		return
+	}
+
+	var form models.AcceptInvitationModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for AcceptInvitation")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.invitationService.AcceptInvitation(c.Request.Context(), userID, form.Token)
+	if err != nil {
+		h.writeError(c, err, "Failed to accept invitation")
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+func (h *InvitationHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ForbiddenError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case *services.QuotaExceededError:
+		h.log.Warn().Err(err).Msg(message)
+		writeQuotaError(c, err.(*services.QuotaExceededError))
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -64,6 +64,16 @@ func (h *MeetingHandler) CreateMeeting(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if _, ok := err.(*services.ForbiddenError); ok {
+			h.log.Warn().Err(err).Int("creator_id", int(userID)).Int("channel_id", req.ChannelID).Msg("User forbidden from creating meeting")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if quotaErr, ok := err.(*services.QuotaExceededError); ok {
+			h.log.Warn().Err(err).Int("channel_id", req.ChannelID).Msg("Workspace meeting limit reached")
+			writeQuotaError(c, quotaErr)
+			return
+		}
 		h.log.Error().Err(err).Int("creator_id", int(userID)).Str("meeting_name", req.Name).Msg("Failed to create meeting via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create meeting"})
 		return
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type PresenceHandler struct {
+	presenceService services.PresenceService
+	log             zerolog.Logger
+}
+
+func NewPresenceHandler(ps services.PresenceService, logger zerolog.Logger) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: ps,
+		log:             logger,
+	}
+}
+
+// GetPresence answers GET /api/presence?user_ids=1,2,3.
+func (h *PresenceHandler) GetPresence(c *gin.Context) {
+	viewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetPresence")
+		return
+	}
+
+	var userIDs []int
+	for _, value := range strings.Split(c.Query("user_ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		userID, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user_ids must be a comma separated list of user IDs"})
+			return
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	presences, err := h.presenceService.GetPresence(c.Request.Context(), viewerID, userIDs)
+	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", viewerID).Msg("Failed to get presence")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get presence"})
+		return
+	}
+	c.JSON(http.StatusOK, presences)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type QuotaHandler struct {
+	quotaService services.QuotaService
+	log          zerolog.Logger
+}
+
+func NewQuotaHandler(qs services.QuotaService, logger zerolog.Logger) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: qs,
+		log:          logger,
+	}
+}
+
+// GetUsage shows a workspace's admins how much of each quota of their plan is used.
+func (h *QuotaHandler) GetUsage(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetUsage")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	usage, err := h.quotaService.GetUsage(c.Request.Context(), adminID, workspaceID)
+	if err != nil {
+		switch err.(type) {
+		case *services.NotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case *services.ForbiddenError:
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get workspace usage")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get workspace usage"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// writeQuotaError tells the client which limit of the workspace's plan the request ran into.
+func writeQuotaError(c *gin.Context, err *services.QuotaExceededError) {
+	c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "quota": err.Quota, "limit": err.Limit})
+}
@@ -6,6 +6,7 @@ import (
 
 	"axis/internal/models"
 	"axis/internal/services"
+	"axis/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
@@ -33,31 +34,36 @@ func (h *ReactionHandler) AddReaction(c *gin.Context) {
 		return
 	}
 
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context for AddReaction")
+		return
+	}
+
 	var reqBody struct {
-		UserID int    `json:"user_id"`
-		Emoji  string `json:"emoji"`
+		Emoji string `json:"emoji"`
 	}
 	if err := c.ShouldBindJSON(&reqBody); err != nil {
 		h.log.Error().Err(err).Msg("Failed to bind JSON for AddReaction")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	h.log.Debug().Int("message_id", messageID).Int("user_id", reqBody.UserID).Str("emoji", reqBody.Emoji).Msg("AddReaction request body")
+	h.log.Debug().Int("message_id", messageID).Int("user_id", userID).Str("emoji", reqBody.Emoji).Msg("AddReaction request body")
 
 	reaction := &models.Reaction{
 		MessageID: messageID,
-		UserID:    reqBody.UserID,
+		UserID:    userID,
 		Emoji:     reqBody.Emoji,
 	}
 
 	addedReaction, err := h.reactionService.AddReaction(c.Request.Context(), reaction)
 	if err != nil {
-		h.log.Error().Err(err).Int("message_id", messageID).Int("user_id", reqBody.UserID).Str("emoji", reqBody.Emoji).Msg("Failed to add reaction via service")
+		h.log.Error().Err(err).Int("message_id", messageID).Int("user_id", userID).Str("emoji", reqBody.Emoji).Msg("Failed to add reaction via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add reaction"})
 		return
 	}
 
-	h.log.Info().Int("reaction_id", addedReaction.ID).Int("message_id", messageID).Int("user_id", reqBody.UserID).Msg("Reaction added successfully")
+	h.log.Info().Int("reaction_id", addedReaction.ID).Int("message_id", messageID).Int("user_id", userID).Msg("Reaction added successfully")
 	c.JSON(http.StatusCreated, addedReaction)
 }
 
@@ -79,11 +85,26 @@ func (h *ReactionHandler) RemoveReaction(c *gin.Context) {
 		return
 	}
 
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context for RemoveReaction")
+		return
+	}
+
 	emoji := c.Param("emoji")
 	h.log.Debug().Int("message_id", messageID).Int("user_id", userID).Str("emoji", emoji).Msg("Attempting to remove reaction")
 
-	err = h.reactionService.RemoveReaction(c.Request.Context(), messageID, userID, emoji)
+	err = h.reactionService.RemoveReaction(c.Request.Context(), actorID, messageID, userID, emoji)
 	if err != nil {
+		switch err.(type) {
+		case *services.ForbiddenError:
+			h.log.Warn().Err(err).Int("message_id", messageID).Int("user_id", userID).Int("actor_id", actorID).Msg("Not allowed to remove reaction")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case *services.NotFoundError:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Int("message_id", messageID).Int("user_id", userID).Str("emoji", emoji).Msg("Failed to remove reaction via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
 		return
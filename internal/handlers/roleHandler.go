@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type RoleHandler struct {
+	roleService services.RoleService
+	log         zerolog.Logger
+}
+
+func NewRoleHandler(rs services.RoleService, logger zerolog.Logger) *RoleHandler {
+	return &RoleHandler{
+		roleService: rs,
+		log:         logger,
+	}
+}
+
+func (h *RoleHandler) GetPermissionMatrix(c *gin.Context) {
+	c.JSON(http.StatusOK, h.roleService.PermissionMatrix())
+}
+
+func (h *RoleHandler) GetRoles(c *gin.Context) {
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.GetRoles(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get roles")
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	h.log.Info().Msg("Handling CreateRole request")
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	var form models.RoleModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for CreateRole")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), userID, workspaceID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to create role")
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	h.log.Info().Msg("Handling UpdateRole request")
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+	roleID, err := strconv.Atoi(c.Param("roleID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	var form models.RoleModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for UpdateRole")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), userID, workspaceID, roleID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to update role")
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	h.log.Info().Msg("Handling DeleteRole request")
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+	roleID, err := strconv.Atoi(c.Param("roleID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role ID"})
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), userID, workspaceID, roleID); err != nil {
+		h.writeError(c, err, "Failed to delete role")
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	h.log.Info().Msg("Handling AssignRole request")
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var form models.AssignRoleModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for AssignRole")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.roleService.AssignRole(c.Request.Context(), userID, workspaceID, memberID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to assign role")
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+// PromoteMember makes a member an admin.
+func (h *RoleHandler) PromoteMember(c *gin.Context) {
+	h.log.Info().Msg("Handling PromoteMember request")
+	h.setBuiltInRole(c, models.Admin, "Failed to promote member")
+}
+
+// DemoteMember makes an admin, or a member with a custom role, a plain member.
+func (h *RoleHandler) DemoteMember(c *gin.Context) {
+	h.log.Info().Msg("Handling DemoteMember request")
+	h.setBuiltInRole(c, models.Member, "Failed to demote member")
+}
+
+func (h *RoleHandler) setBuiltInRole(c *gin.Context, role models.UserRole, message string) {
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	member, err := h.roleService.AssignRole(c.Request.Context(), userID, workspaceID, memberID, models.AssignRoleModel{Role: &role})
+	if err != nil {
+		h.writeError(c, err, message)
+		return
+	}
+	c.JSON(http.StatusOK, member)
+}
+
+func (h *RoleHandler) GetPermissions(c *gin.Context) {
+	userID, workspaceID, ok := h.workspaceParams(c)
+	if !ok {
+		return
+	}
+
+	permissions, err := h.roleService.GetPermissions(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get permissions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"permissions": permissions})
+}
+
+func (h *RoleHandler) workspaceParams(c *gin.Context) (userID, workspaceID int, ok bool) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context for role request")
+		return 0, 0, false
+	}
+	workspaceID, err = strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return 0, 0, false
+	}
+	return userID, workspaceID, true
+}
+
+func (h *RoleHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ForbiddenError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type SSOHandler struct {
+	ssoService     services.SSOService
+	sessionService services.SessionService
+	log            zerolog.Logger
+}
+
+func NewSSOHandler(ss services.SSOService, sessionService services.SessionService, logger zerolog.Logger) *SSOHandler {
+	return &SSOHandler{
+		ssoService:     ss,
+		sessionService: sessionService,
+		log:            logger,
+	}
+}
+
+func (h *SSOHandler) GetOIDCConfig(c *gin.Context) {
+	h.log.Info().Msg("Handling GetOIDCConfig request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetOIDCConfig")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.ssoService.GetOIDCConfig(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get OIDC configuration")
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+func (h *SSOHandler) ConfigureOIDC(c *gin.Context) {
+	h.log.Info().Msg("Handling ConfigureOIDC request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ConfigureOIDC")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	var form models.OIDCConfigModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ConfigureOIDC")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := h.ssoService.ConfigureOIDC(c.Request.Context(), userID, workspaceID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to save OIDC configuration")
+		return
+	}
+
+	h.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("OIDC configuration saved")
+	c.JSON(http.StatusOK, config)
+}
+
+func (h *SSOHandler) DeleteOIDCConfig(c *gin.Context) {
+	h.log.Info().Msg("Handling DeleteOIDCConfig request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in DeleteOIDCConfig")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ssoService.DeleteOIDCConfig(c.Request.Context(), userID, workspaceID); err != nil {
+		h.writeError(c, err, "Failed to delete OIDC configuration")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *SSOHandler) StartOIDCLogin(c *gin.Context) {
+	h.log.Info().Msg("Handling StartOIDCLogin request")
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	authURL, err := h.ssoService.StartOIDCLogin(c.Request.Context(), workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to start OIDC login")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// StartOIDCLink starts an OIDC login that links the identity to the signed-in user's account.
+// The login then completes through OIDCCallback as usual.
+func (h *SSOHandler) StartOIDCLink(c *gin.Context) {
+	h.log.Info().Msg("Handling StartOIDCLink request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in StartOIDCLink")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	authURL, err := h.ssoService.StartOIDCLink(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to start OIDC link")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+func (h *SSOHandler) OIDCCallback(c *gin.Context) {
+	h.log.Info().Msg("Handling OIDCCallback request")
+	var form models.OIDCCallbackModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for OIDCCallback")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Code == "" || form.State == "" {
+		h.log.Warn().Msg("Code and state are required for OIDCCallback")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code and state are required"})
+		return
+	}
+
+	user, err := h.ssoService.CompleteOIDCLogin(c.Request.Context(), form)
+	if err != nil {
+		h.writeError(c, err, "Failed to complete OIDC login")
+		return
+	}
+
+	h.respondWithSession(c, user, form.DeviceName)
+}
+
+func (h *SSOHandler) GetSAMLConfig(c *gin.Context) {
+	h.log.Info().Msg("Handling GetSAMLConfig request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetSAMLConfig")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	config, err := h.ssoService.GetSAMLConfig(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get SAML configuration")
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+func (h *SSOHandler) ConfigureSAML(c *gin.Context) {
+	h.log.Info().Msg("Handling ConfigureSAML request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ConfigureSAML")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	var form models.SAMLConfigModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ConfigureSAML")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := h.ssoService.ConfigureSAML(c.Request.Context(), userID, workspaceID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to save SAML configuration")
+		return
+	}
+
+	h.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("SAML configuration saved")
+	c.JSON(http.StatusOK, config)
+}
+
+func (h *SSOHandler) DeleteSAMLConfig(c *gin.Context) {
+	h.log.Info().Msg("Handling DeleteSAMLConfig request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in DeleteSAMLConfig")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.ssoService.DeleteSAMLConfig(c.Request.Context(), userID, workspaceID); err != nil {
+		h.writeError(c, err, "Failed to delete SAML configuration")
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *SSOHandler) SAMLMetadata(c *gin.Context) {
+	h.log.Info().Msg("Handling SAMLMetadata request")
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	metadata, err := h.ssoService.SAMLMetadata(c.Request.Context(), workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to build SAML metadata")
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+func (h *SSOHandler) StartSAMLLogin(c *gin.Context) {
+	h.log.Info().Msg("Handling StartSAMLLogin request")
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	redirectURL, err := h.ssoService.StartSAMLLogin(c.Request.Context(), workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to start SAML login")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": redirectURL})
+}
+
+// StartSAMLLink starts a SAML login that links the identity to the signed-in user's account.
+func (h *SSOHandler) StartSAMLLink(c *gin.Context) {
+	h.log.Info().Msg("Handling StartSAMLLink request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in StartSAMLLink")
+		return
+	}
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	redirectURL, err := h.ssoService.StartSAMLLink(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to start SAML link")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_url": redirectURL})
+}
+
+// SAMLACS is the assertion consumer service the IdP posts the user's browser to. The browser is
+// sent on to the web client with a one-time code, which the client trades for tokens through
+// ExchangeCode so that tokens never appear in a URL.
+func (h *SSOHandler) SAMLACS(c *gin.Context) {
+	h.log.Info().Msg("Handling SAMLACS request")
+	workspaceID, ok := h.workspaceIDParam(c)
+	if !ok {
+		return
+	}
+
+	samlResponse := c.PostForm("SAMLResponse")
+	if samlResponse == "" {
+		h.log.Warn().Msg("SAMLResponse is required for SAMLACS")
+		c.Redirect(http.StatusSeeOther, utils.AppURL("/sso/complete", url.Values{"error": {"invalid_response"}}))
+		return
+	}
+
+	code, err := h.ssoService.CompleteSAMLLogin(c.Request.Context(), workspaceID, samlResponse)
+	if err != nil {
+		h.log.Warn().Err(err).Int("workspace_id", workspaceID).Msg("Failed to complete SAML login")
+		c.Redirect(http.StatusSeeOther, utils.AppURL("/sso/complete", url.Values{"error": {err.Error()}}))
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, utils.AppURL("/sso/complete", url.Values{"code": {code}}))
+}
+
+func (h *SSOHandler) ExchangeCode(c *gin.Context) {
+	h.log.Info().Msg("Handling ExchangeCode request")
+	var form models.SSOCodeExchangeModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ExchangeCode")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Code == "" {
+		h.log.Warn().Msg("Code is required for ExchangeCode")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+		return
+	}
+
+	user, err := h.ssoService.ExchangeLoginCode(c.Request.Context(), form.Code)
+	if err != nil {
+		h.writeError(c, err, "Failed to exchange SSO login code")
+		return
+	}
+
+	h.respondWithSession(c, user, form.DeviceName)
+}
+
+// respondWithSession starts a session for a user authenticated by an identity provider and answers
+// the same way UserHandler.Login does.
+func (h *SSOHandler) respondWithSession(c *gin.Context, user *models.User, deviceName string) {
+	startSession(c, h.log, h.sessionService, user, deviceName, "sso")
+}
+
+func (h *SSOHandler) workspaceIDParam(c *gin.Context) (int, bool) {
+	idStr := c.Param("workspaceID")
+	workspaceID, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("workspaceID_param", idStr).Msg("Invalid workspace ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return 0, false
+	}
+	return workspaceID, true
+}
+
+func (h *SSOHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.UnauthorizedError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case *services.ForbiddenError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case *services.QuotaExceededError:
+		h.log.Warn().Err(err).Msg(message)
+		writeQuotaError(c, err.(*services.QuotaExceededError))
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type StatusHandler struct {
+	statusService services.StatusService
+	log           zerolog.Logger
+}
+
+func NewStatusHandler(ss services.StatusService, logger zerolog.Logger) *StatusHandler {
+	return &StatusHandler{
+		statusService: ss,
+		log:           logger,
+	}
+}
+
+func (h *StatusHandler) GetStatus(c *gin.Context) {
+	viewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetStatus")
+		return
+	}
+	userID, err := strconv.Atoi(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	info, err := h.statusService.GetStatus(c.Request.Context(), viewerID, userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get status")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) SetStatus(c *gin.Context) {
+	h.log.Info().Msg("Handling SetStatus request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in SetStatus")
+		return
+	}
+
+	var form models.SetStatusModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for SetStatus")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := h.statusService.SetStatus(c.Request.Context(), userID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to set status")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) ClearStatus(c *gin.Context) {
+	h.log.Info().Msg("Handling ClearStatus request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ClearStatus")
+		return
+	}
+
+	info, err := h.statusService.ClearStatus(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to clear status")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) SetDoNotDisturb(c *gin.Context) {
+	h.log.Info().Msg("Handling SetDoNotDisturb request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in SetDoNotDisturb")
+		return
+	}
+
+	var form models.SetDoNotDisturbModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for SetDoNotDisturb")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := h.statusService.SetDoNotDisturb(c.Request.Context(), userID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to turn on Do Not Disturb")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) ClearDoNotDisturb(c *gin.Context) {
+	h.log.Info().Msg("Handling ClearDoNotDisturb request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ClearDoNotDisturb")
+		return
+	}
+
+	info, err := h.statusService.ClearDoNotDisturb(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to turn off Do Not Disturb")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) SetDoNotDisturbSchedule(c *gin.Context) {
+	h.log.Info().Msg("Handling SetDoNotDisturbSchedule request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in SetDoNotDisturbSchedule")
+		return
+	}
+
+	var schedule models.DNDSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for SetDoNotDisturbSchedule")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	info, err := h.statusService.SetDoNotDisturbSchedule(c.Request.Context(), userID, schedule)
+	if err != nil {
+		h.writeError(c, err, "Failed to set Do Not Disturb schedule")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) ClearDoNotDisturbSchedule(c *gin.Context) {
+	h.log.Info().Msg("Handling ClearDoNotDisturbSchedule request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ClearDoNotDisturbSchedule")
+		return
+	}
+
+	info, err := h.statusService.ClearDoNotDisturbSchedule(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, "Failed to clear Do Not Disturb schedule")
+		return
+	}
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *StatusHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+	sessionService   services.SessionService
+	log              zerolog.Logger
+}
+
+func NewTwoFactorHandler(tfs services.TwoFactorService, ss services.SessionService, logger zerolog.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: tfs,
+		sessionService:   ss,
+		log:              logger,
+	}
+}
+
+func (h *TwoFactorHandler) BeginSetup(c *gin.Context) {
+	h.log.Info().Msg("Handling BeginTwoFactorSetup request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in BeginSetup")
+		return
+	}
+
+	setup, err := h.twoFactorService.BeginSetup(c.Request.Context(), userID)
+	if err != nil {
+		h.writeError(c, err, userID, "Failed to start two-factor setup")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Two-factor setup started")
+	c.JSON(http.StatusOK, setup)
+}
+
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	h.log.Info().Msg("Handling EnableTwoFactor request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in Enable")
+		return
+	}
+
+	var form models.TwoFactorCodeModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for EnableTwoFactor")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(c.Request.Context(), userID, form.Code, c.ClientIP())
+	if err != nil {
+		h.writeError(c, err, userID, "Failed to enable two-factor authentication")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Two-factor authentication enabled")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	h.log.Info().Msg("Handling DisableTwoFactor request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in Disable")
+		return
+	}
+
+	var form models.DisableTwoFactorModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for DisableTwoFactor")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.twoFactorService.Disable(c.Request.Context(), userID, form, c.ClientIP()); err != nil {
+		h.writeError(c, err, userID, "Failed to disable two-factor authentication")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Two-factor authentication disabled")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	h.log.Info().Msg("Handling RegenerateRecoveryCodes request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RegenerateRecoveryCodes")
+		return
+	}
+
+	var form models.RegenerateRecoveryCodesModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for RegenerateRecoveryCodes")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(c.Request.Context(), userID, form, c.ClientIP())
+	if err != nil {
+		h.writeError(c, err, userID, "Failed to regenerate recovery codes")
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Recovery codes regenerated")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *TwoFactorHandler) CompleteLogin(c *gin.Context) {
+	h.log.Info().Msg("Handling TwoFactorLogin request")
+	var form models.TwoFactorLoginModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for TwoFactorLogin")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.twoFactorService.CompleteLogin(c.Request.Context(), form, c.ClientIP())
+	if err != nil {
+		if tooMany, ok := err.(*services.TooManyRequestsError); ok {
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Two-factor login throttled after failed attempts")
+			c.Header("Retry-After", strconv.Itoa(int(tooMany.RetryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.UnauthorizedError); ok {
+			h.log.Warn().Err(err).Msg("Two-factor login rejected")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to complete two-factor login via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete login"})
+		return
+	}
+
+	startSession(c, h.log, h.sessionService, user, form.DeviceName, "two_factor")
+}
+
+func (h *TwoFactorHandler) writeError(c *gin.Context, err error, userID int, message string) {
+	switch e := err.(type) {
+	case *services.TooManyRequestsError:
+		h.log.Warn().Err(err).Int("user_id", userID).Msg(message)
+		c.Header("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Int("user_id", userID).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.UnauthorizedError:
+		h.log.Warn().Err(err).Int("user_id", userID).Msg(message)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Int("user_id", userID).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Int("user_id", userID).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	default:
+		h.log.Error().Err(err).Int("user_id", userID).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
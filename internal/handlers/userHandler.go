@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
 	"axis/internal/models"
 	"axis/internal/services"
@@ -11,14 +14,16 @@ import (
 )
 
 type UserHandler struct {
-	userService services.UserService
-	log         zerolog.Logger
+	userService    services.UserService
+	sessionService services.SessionService
+	log            zerolog.Logger
 }
 
-func NewUserHandler(us services.UserService, logger zerolog.Logger) *UserHandler {
+func NewUserHandler(us services.UserService, ss services.SessionService, logger zerolog.Logger) *UserHandler {
 	return &UserHandler{
-		userService: us,
-		log:         logger,
+		userService:    us,
+		sessionService: ss,
+		log:            logger,
 	}
 }
 
@@ -34,6 +39,11 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	user, err := h.userService.Register(c.Request.Context(), form)
 	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Msg("Registration rejected by password policy")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Str("email", form.Email).Msg("Failed to register user via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user"})
 		return
@@ -43,6 +53,71 @@ func (h *UserHandler) Register(c *gin.Context) {
 	c.JSON(http.StatusCreated, user)
 }
 
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	h.log.Info().Msg("Handling VerifyEmail request")
+	var form models.VerifyEmailModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for VerifyEmail")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userService.VerifyEmail(c.Request.Context(), form.Token)
+	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Msg("Email verification token rejected")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Msg("User not found for email verification")
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to verify email via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	h.log.Info().Int("user_id", user.ID).Msg("Email verified successfully")
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *UserHandler) ResendVerificationEmail(c *gin.Context) {
+	h.log.Info().Msg("Handling ResendVerificationEmail request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ResendVerificationEmail")
+		return
+	}
+
+	err = h.userService.ResendVerificationEmail(c.Request.Context(), userID)
+	if err != nil {
+		if tooMany, ok := err.(*services.TooManyRequestsError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Verification email resend throttled")
+			c.Header("Retry-After", strconv.Itoa(int(tooMany.RetryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ConflictError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Email already verified")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("User not found for verification resend")
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to resend verification email via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Msg("Verification email resent successfully")
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
 func (h *UserHandler) Login(c *gin.Context) {
 	h.log.Info().Msg("Handling Login request")
 	var creds models.LoginModel
@@ -51,29 +126,194 @@ func (h *UserHandler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	h.log.Debug().Str("email", creds.Email).Msg("Login request body (email)")
 
-	user, err := h.userService.Login(c.Request.Context(), creds)
+	user, challenge, err := h.userService.Login(c.Request.Context(), creds, c.ClientIP())
+	if err != nil {
+		switch e := err.(type) {
+		case *services.TooManyRequestsError:
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Login throttled after failed attempts")
+			c.Header("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case *services.UnauthorizedError:
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Login failed: invalid credentials or user not found")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		case *services.ForbiddenError:
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Login refused")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			h.log.Error().Err(err).Msg("Failed to log in via service")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		}
+		return
+	}
+	h.respondWithLogin(c, user, challenge, creds.DeviceName)
+}
+
+// respondWithLogin finishes a first-factor login: it hands out a 2FA challenge when the service
+// asked for one and starts a session otherwise.
+func (h *UserHandler) respondWithLogin(c *gin.Context, user *models.User, challenge *models.TwoFactorChallenge, deviceName string) {
+	if challenge != nil {
+		h.log.Info().Int("user_id", user.ID).Msg("Login requires a second factor")
+		c.JSON(http.StatusOK, gin.H{
+			"two_factor_required": true,
+			"challenge_token":     challenge.ChallengeToken,
+			"expires_at":          challenge.ExpiresAt,
+		})
+		return
+	}
+
+	startSession(c, h.log, h.sessionService, user, deviceName, "password")
+}
+
+func (h *UserHandler) RequestLoginLink(c *gin.Context) {
+	h.log.Info().Msg("Handling RequestLoginLink request")
+	var form models.LoginLinkRequestModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for RequestLoginLink")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Email == "" {
+		h.log.Warn().Msg("Email is required for RequestLoginLink")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	// A throttled request is answered like any other, so that it does not reveal the account.
+	if err := h.userService.SendLoginLink(c.Request.Context(), form.Email); err != nil {
+		if _, ok := err.(*services.TooManyRequestsError); ok {
+			c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a sign-in link has been sent"})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to send login link via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send login link"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a sign-in link has been sent"})
+}
+
+func (h *UserHandler) LoginWithLink(c *gin.Context) {
+	h.log.Info().Msg("Handling LoginWithLink request")
+	var form models.LoginLinkModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for LoginWithLink")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Token == "" {
+		h.log.Warn().Msg("Token is required for LoginWithLink")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Token is required"})
+		return
+	}
+
+	user, challenge, err := h.userService.LoginWithLink(c.Request.Context(), form.Token)
 	if err != nil {
-		h.log.Warn().Err(err).Str("email", creds.Email).Msg("Login failed: invalid credentials or user not found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		if _, ok := err.(*services.UnauthorizedError); ok {
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Login link rejected")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ForbiddenError); ok {
+			h.log.Warn().Str("ip_address", c.ClientIP()).Msg("Login link refused")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to log in with link via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	h.respondWithLogin(c, user, challenge, form.DeviceName)
+}
+
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	h.log.Info().Msg("Handling RefreshToken request")
+	var form models.RefreshTokenModel
+	// Cookie sessions send no body; the refresh token comes from the cookie.
+	if err := c.ShouldBindJSON(&form); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for RefreshToken")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	cookieSession := false
+	if form.RefreshToken == "" {
+		form.RefreshToken = utils.SessionCookieRefreshToken(c)
+		cookieSession = form.RefreshToken != ""
+	}
+	if form.RefreshToken == "" {
+		h.log.Warn().Msg("Refresh token is required for RefreshToken")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		return
+	}
+	if cookieSession && !utils.ValidCSRF(c) {
+		h.log.Warn().Msg("Missing or invalid CSRF token for cookie session refresh")
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token"})
 		return
 	}
 
-	token, err := utils.GenerateAccessToken(user.ID)
+	tokens, err := h.sessionService.RefreshSession(c.Request.Context(), form.RefreshToken, sessionMetadataFromRequest(c, ""))
 	if err != nil {
-		h.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to generate token for login")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		if _, ok := err.(*services.UnauthorizedError); ok {
+			h.log.Warn().Err(err).Msg("Refresh token rejected")
+			if cookieSession {
+				utils.ClearSessionCookies(c)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to refresh session via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
 		return
 	}
 
-	h.log.Info().Int("user_id", user.ID).Str("email", user.Email).Msg("User logged in successfully")
-	c.JSON(http.StatusOK, gin.H{
-		"user":  user,
-		"token": token,
-	})
+	h.log.Info().Msg("Token refreshed successfully")
+	if !cookieSession {
+		c.JSON(http.StatusOK, tokens)
+		return
+	}
+	csrfToken, err := utils.SetSessionCookies(c, tokens.AccessToken, tokens.RefreshToken, services.RefreshTokenTTL)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to set session cookies")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"csrf_token": csrfToken})
 }
 
+func (h *UserHandler) Logout(c *gin.Context) {
+	h.log.Info().Msg("Handling Logout request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in Logout")
+		return
+	}
+	sessionID, err := utils.GetSessionIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get session ID from context in Logout")
+		return
+	}
+
+	err = h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Int("session_id", sessionID).Msg("Session not found for logout")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Int("session_id", sessionID).Msg("Failed to revoke session via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("session_id", sessionID).Msg("User logged out successfully")
+	if c.GetHeader("Authorization") == "" {
+		utils.ClearSessionCookies(c)
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetUserByID returns the caller's own account, with everything they may change about it.
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	h.log.Info().Msg("Handling GetUserByID request")
 	userID, err := utils.GetUserIDFromContext(c)
@@ -134,7 +374,7 @@ func (h *UserHandler) GetUserByEmail(c *gin.Context) {
 	}
 
 	h.log.Info().Str("email", email).Msg("User retrieved successfully by email")
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, user.PublicProfile())
 }
 
 func (h *UserHandler) GetUserByUsername(c *gin.Context) {
@@ -166,7 +406,7 @@ func (h *UserHandler) GetUserByUsername(c *gin.Context) {
 	}
 
 	h.log.Info().Str("username", username).Msg("User retrieved successfully by username")
-	c.JSON(http.StatusOK, user)
+	c.JSON(http.StatusOK, user.PublicProfile())
 }
 
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -188,6 +428,11 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	user, err := h.userService.UpdateUser(c.Request.Context(), userID, updateUser)
 	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Invalid profile update")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if _, ok := err.(*services.NotFoundError); ok {
 			h.log.Warn().Err(err).Int("user_id", userID).Msg("User not found for update")
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -208,27 +453,239 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-func (h *UserHandler) DeleteUser(c *gin.Context) {
-	h.log.Info().Msg("Handling DeleteUser request")
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	h.log.Info().Msg("Handling ListSessions request")
 	userID, err := utils.GetUserIDFromContext(c)
 	if err != nil {
-		h.log.Error().Err(err).Msg("Failed to get user ID from context in DeleteUser")
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ListSessions")
+		return
+	}
+	sessionID, err := utils.GetSessionIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get session ID from context in ListSessions")
 		return
 	}
-	h.log.Debug().Int("user_id_from_context", userID).Msg("Deleting user")
 
-	err = h.userService.DeleteUser(c.Request.Context(), userID)
+	sessions, err := h.sessionService.ListSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to list sessions via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("sessions_count", len(sessions)).Msg("Sessions retrieved successfully")
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	h.log.Info().Msg("Handling RevokeSession request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RevokeSession")
+		return
+	}
+
+	idStr := c.Param("sessionID")
+	h.log.Debug().Str("sessionID_param", idStr).Msg("Parsing session ID for revocation")
+	sessionID, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("sessionID_param", idStr).Msg("Invalid session ID format for revocation")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	err = h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID)
 	if err != nil {
 		if _, ok := err.(*services.NotFoundError); ok {
-			h.log.Warn().Err(err).Int("user_id", userID).Msg("User not found for deletion")
+			h.log.Warn().Err(err).Int("user_id", userID).Int("session_id", sessionID).Msg("Session not found for revocation")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Int("session_id", sessionID).Msg("Failed to revoke session via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("session_id", sessionID).Msg("Session revoked successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	h.log.Info().Msg("Handling RevokeOtherSessions request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RevokeOtherSessions")
+		return
+	}
+	sessionID, err := utils.GetSessionIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get session ID from context in RevokeOtherSessions")
+		return
+	}
+
+	err = h.sessionService.RevokeOtherSessions(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		h.log.Error().Err(err).Int("user_id", userID).Int("session_id", sessionID).Msg("Failed to revoke other sessions via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("session_id", sessionID).Msg("Other sessions revoked successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// startSession creates a session for a user who has just proven who they are and answers with it.
+// method names the way they signed in, for the log.
+func startSession(c *gin.Context, log zerolog.Logger, sessionService services.SessionService, user *models.User, deviceName, method string) {
+	tokens, err := sessionService.CreateSession(c.Request.Context(), user, sessionMetadataFromRequest(c, deviceName))
+	if err != nil {
+		if _, ok := err.(*services.ForbiddenError); ok {
+			log.Warn().Int("user_id", user.ID).Str("method", method).Msg("Login refused")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error().Err(err).Int("user_id", user.ID).Str("method", method).Msg("Failed to create session for login")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	log.Info().Int("user_id", user.ID).Str("method", method).Msg("User logged in successfully")
+	writeSessionResponse(c, log, user, tokens)
+}
+
+// writeSessionResponse answers a successful login. When the client asked for a cookie session the
+// tokens go into HttpOnly cookies instead of the body, out of reach of scripts, and the body carries
+// the CSRF token the client has to send back.
+func writeSessionResponse(c *gin.Context, log zerolog.Logger, user *models.User, tokens *models.TokenPair) {
+	if !utils.WantsCookieSession(c) {
+		c.JSON(http.StatusOK, gin.H{
+			"user":          user,
+			"token":         tokens.AccessToken,
+			"refresh_token": tokens.RefreshToken,
+		})
+		return
+	}
+
+	csrfToken, err := utils.SetSessionCookies(c, tokens.AccessToken, tokens.RefreshToken, services.RefreshTokenTTL)
+	if err != nil {
+		log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to set session cookies")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"user":       user,
+		"csrf_token": csrfToken,
+	})
+}
+
+// sessionMetadataFromRequest describes the calling client. The device name falls back to the
+// user agent when the client does not name itself.
+func sessionMetadataFromRequest(c *gin.Context, deviceName string) models.SessionMetadata {
+	userAgent := c.Request.UserAgent()
+	if deviceName == "" {
+		deviceName = userAgent
+	}
+	return models.SessionMetadata{
+		DeviceName: deviceName,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  userAgent,
+	}
+}
+
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	h.log.Info().Msg("Handling ForgotPassword request")
+	var form models.ForgotPasswordModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ForgotPassword")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if form.Email == "" {
+		h.log.Warn().Msg("Email is required for ForgotPassword")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+		return
+	}
+
+	if err := h.userService.ForgotPassword(c.Request.Context(), form.Email); err != nil {
+		h.log.Error().Err(err).Msg("Failed to start password reset via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start password reset"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the address belongs to an account, a reset link has been sent"})
+}
+
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	h.log.Info().Msg("Handling ResetPassword request")
+	var form models.ResetPasswordModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ResetPassword")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.userService.ResetPassword(c.Request.Context(), form.Token, form.NewPassword)
+	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Msg("Password reset rejected")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to reset password via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	h.log.Info().Msg("Password reset successfully")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	h.log.Info().Msg("Handling ChangePassword request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ChangePassword")
+		return
+	}
+	sessionID, err := utils.GetSessionIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get session ID from context in ChangePassword")
+		return
+	}
+
+	var form models.ChangePasswordModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ChangePassword")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.userService.ChangePassword(c.Request.Context(), userID, sessionID, form)
+	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Password change rejected")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.UnauthorizedError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("Password change with wrong current password")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("User not found for password change")
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
-		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to delete user via service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to change password via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
 		return
 	}
 
-	h.log.Info().Int("user_id", userID).Msg("User deleted successfully")
+	h.log.Info().Int("user_id", userID).Msg("Password changed successfully")
 	c.JSON(http.StatusNoContent, nil)
 }
+
+func (h *UserHandler) GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, h.userService.PasswordPolicy())
+}
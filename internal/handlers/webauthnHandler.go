@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"axis/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+type WebAuthnHandler struct {
+	webAuthnService services.WebAuthnService
+	sessionService  services.SessionService
+	log             zerolog.Logger
+}
+
+func NewWebAuthnHandler(ws services.WebAuthnService, ss services.SessionService, logger zerolog.Logger) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: ws,
+		sessionService:  ss,
+		log:             logger,
+	}
+}
+
+func (h *WebAuthnHandler) BeginRegistration(c *gin.Context) {
+	h.log.Info().Msg("Handling BeginWebAuthnRegistration request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in BeginRegistration")
+		return
+	}
+
+	options, err := h.webAuthnService.BeginRegistration(c.Request.Context(), userID)
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("User not found for WebAuthn registration")
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to start WebAuthn registration via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (h *WebAuthnHandler) FinishRegistration(c *gin.Context) {
+	h.log.Info().Msg("Handling FinishWebAuthnRegistration request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in FinishRegistration")
+		return
+	}
+
+	var form models.WebAuthnRegistrationModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for FinishWebAuthnRegistration")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credential, err := h.webAuthnService.FinishRegistration(c.Request.Context(), userID, form)
+	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("WebAuthn registration rejected")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ConflictError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Msg("WebAuthn credential already registered")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to finish WebAuthn registration via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register credential"})
+		return
+	}
+
+	h.log.Info().Int("user_id", userID).Int("credential_id", credential.ID).Msg("WebAuthn credential registered")
+	c.JSON(http.StatusCreated, credential)
+}
+
+func (h *WebAuthnHandler) BeginLogin(c *gin.Context) {
+	h.log.Info().Msg("Handling BeginWebAuthnLogin request")
+	var form models.WebAuthnLoginBeginModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for BeginWebAuthnLogin")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.webAuthnService.BeginLogin(c.Request.Context(), form.Email)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to start WebAuthn login via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"publicKey": options})
+}
+
+func (h *WebAuthnHandler) FinishLogin(c *gin.Context) {
+	h.log.Info().Msg("Handling FinishWebAuthnLogin request")
+	var form models.WebAuthnLoginModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for FinishWebAuthnLogin")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.webAuthnService.FinishLogin(c.Request.Context(), form.Credential)
+	if err != nil {
+		if _, ok := err.(*services.UnauthorizedError); ok {
+			h.log.Warn().Err(err).Msg("WebAuthn login rejected")
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Msg("Failed to finish WebAuthn login via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete login"})
+		return
+	}
+
+	startSession(c, h.log, h.sessionService, user, form.DeviceName, "webauthn")
+}
+
+func (h *WebAuthnHandler) ListCredentials(c *gin.Context) {
+	h.log.Info().Msg("Handling ListWebAuthnCredentials request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in ListCredentials")
+		return
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(c.Request.Context(), userID)
+	if err != nil {
+		h.log.Error().Err(err).Int("user_id", userID).Msg("Failed to list WebAuthn credentials via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list credentials"})
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+func (h *WebAuthnHandler) DeleteCredential(c *gin.Context) {
+	h.log.Info().Msg("Handling DeleteWebAuthnCredential request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in DeleteCredential")
+		return
+	}
+
+	idStr := c.Param("credentialID")
+	credentialID, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("credentialID_param", idStr).Msg("Invalid credential ID format")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	err = h.webAuthnService.DeleteCredential(c.Request.Context(), userID, credentialID)
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("user_id", userID).Int("credential_id", credentialID).Msg("WebAuthn credential not found")
+			c.JSON(http.StatusNotFound, gin.H{"error": "Credential not found"})
+			return
+		}
+		h.log.Error().Err(err).Int("user_id", userID).Int("credential_id", credentialID).Msg("Failed to delete WebAuthn credential via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete credential"})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -42,6 +42,14 @@ func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
 
 	createdWorkspace, err := h.workspaceService.CreateWorkspace(c.Request.Context(), &workspace)
 	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ConflictError); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		h.log.Error().Err(err).Int("creator_id", int(userID)).Str("workspace_name", workspace.Name).Msg("Failed to create workspace via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create workspace"})
 		return
@@ -108,17 +116,24 @@ func (h *WorkspaceHandler) UpdateWorkspace(c *gin.Context) {
 		return
 	}
 
-	var workspace models.Workspace
-	if err := c.ShouldBindJSON(&workspace); err != nil {
+	var update models.UpdateWorkspace
+	if err := c.ShouldBindJSON(&update); err != nil {
 		h.log.Error().Err(err).Msg("Failed to bind JSON for UpdateWorkspace")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	workspace.ID = id
-	h.log.Debug().Int("workspace_id", id).Int("user_id", int(userID)).Interface("request_body", workspace).Msg("UpdateWorkspace request body")
+	h.log.Debug().Int("workspace_id", id).Int("user_id", int(userID)).Interface("request_body", update).Msg("UpdateWorkspace request body")
 
-	updatedWorkspace, err := h.workspaceService.UpdateWorkspace(c.Request.Context(), int(userID), &workspace)
+	updatedWorkspace, err := h.workspaceService.UpdateWorkspace(c.Request.Context(), int(userID), id, update)
 	if err != nil {
+		if _, ok := err.(*services.ValidationError); ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ConflictError); ok {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		if _, ok := err.(*services.ForbiddenError); ok {
 			h.log.Warn().Err(err).Int("user_id", int(userID)).Int("workspace_id", id).Msg("User forbidden from updating workspace")
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
@@ -183,6 +198,50 @@ func (h *WorkspaceHandler) DeleteWorkspace(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+func (h *WorkspaceHandler) TransferOwnership(c *gin.Context) {
+	h.log.Info().Msg("Handling TransferOwnership request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in TransferOwnership")
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	var form models.TransferOwnershipModel
+	if err := c.ShouldBindJSON(&form); err != nil {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for TransferOwnership")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceService.TransferOwnership(c.Request.Context(), userID, id, form)
+	if err != nil {
+		switch err.(type) {
+		case *services.ValidationError:
+			h.log.Warn().Err(err).Int("workspace_id", id).Msg("Invalid ownership transfer")
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case *services.ForbiddenError:
+			h.log.Warn().Err(err).Int("user_id", userID).Int("workspace_id", id).Msg("User forbidden from transferring workspace")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case *services.NotFoundError:
+			h.log.Warn().Err(err).Int("workspace_id", id).Msg("Workspace not found for ownership transfer")
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			h.log.Error().Err(err).Int("user_id", userID).Int("workspace_id", id).Msg("Failed to transfer workspace ownership via service")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer workspace ownership"})
+		}
+		return
+	}
+
+	h.log.Info().Int("workspace_id", id).Int("owner_id", workspace.CreatorID).Msg("Workspace ownership transferred successfully")
+	c.JSON(http.StatusOK, workspace)
+}
+
 func (h *WorkspaceHandler) GetWorkspacesForUser(c *gin.Context) {
 	h.log.Info().Msg("Handling GetWorkspacesForUser request")
 	userID, err := utils.GetUserIDFromContext(c)
@@ -202,3 +261,46 @@ func (h *WorkspaceHandler) GetWorkspacesForUser(c *gin.Context) {
 	h.log.Info().Int("user_id", int(userID)).Int("workspaces_count", len(workspaces)).Msg("Workspaces for user retrieved successfully")
 	c.JSON(http.StatusOK, workspaces)
 }
+
+// GetWorkspaceBySlug finds a workspace by its slug, so links can name it instead of its ID.
+func (h *WorkspaceHandler) GetWorkspaceBySlug(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetWorkspaceBySlug")
+		return
+	}
+
+	summary, err := h.workspaceService.GetWorkspaceBySlug(c.Request.Context(), userID, c.Param("slug"))
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Str("slug", c.Param("slug")).Msg("Failed to get workspace by slug via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get workspace"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// SearchDirectory lists the discoverable workspaces, so users can find one to join.
+func (h *WorkspaceHandler) SearchDirectory(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
+		return
+	}
+
+	summaries, err := h.workspaceService.SearchDirectory(c.Request.Context(), c.Query("q"), limit, offset)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to search workspace directory via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search workspace directory"})
+		return
+	}
+	c.JSON(http.StatusOK, summaries)
+}
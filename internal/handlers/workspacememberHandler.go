@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -25,6 +27,12 @@ func NewWorkspaceMemberHandler(wms services.WorkspaceMemberService, logger zerol
 
 func (h *WorkspaceMemberHandler) AddMemberToWorkspace(c *gin.Context) {
 	h.log.Info().Msg("Handling AddMemberToWorkspace request")
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in AddMemberToWorkspace")
+		return
+	}
+
 	workspaceIDStr := c.Param("workspaceID")
 	h.log.Debug().Str("workspaceID_param", workspaceIDStr).Msg("Parsing workspace ID")
 	workspaceID, err := strconv.Atoi(workspaceIDStr)
@@ -35,20 +43,24 @@ func (h *WorkspaceMemberHandler) AddMemberToWorkspace(c *gin.Context) {
 	}
 
 	var reqBody struct {
-		UserID int             `json:"user_id"`
-		Role   models.UserRole `json:"role"`
+		UserID int `json:"user_id"`
+		// Role defaults to Member when left out.
+		Role *models.UserRole `json:"role"`
 	}
 	if err := c.ShouldBindJSON(&reqBody); err != nil {
 		h.log.Error().Err(err).Msg("Failed to bind JSON for AddMemberToWorkspace")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	h.log.Debug().Int("workspace_id", workspaceID).Int("user_id", reqBody.UserID).Str("role", reqBody.Role.String()).Msg("AddMemberToWorkspace request body")
+	role := models.Member
+	if reqBody.Role != nil {
+		role = *reqBody.Role
+	}
+	h.log.Debug().Int("workspace_id", workspaceID).Int("user_id", reqBody.UserID).Str("role", role.String()).Msg("AddMemberToWorkspace request body")
 
-	workspaceMember, err := h.workspaceMemberService.AddMemberToWorkspace(c.Request.Context(), workspaceID, reqBody.UserID, reqBody.Role)
+	workspaceMember, err := h.workspaceMemberService.AddMemberToWorkspace(c.Request.Context(), actorID, workspaceID, reqBody.UserID, role)
 	if err != nil {
-		h.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", reqBody.UserID).Msg("Failed to add member to workspace via service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member to workspace"})
+		h.writeError(c, err, "Failed to add member to workspace")
 		return
 	}
 
@@ -58,6 +70,12 @@ func (h *WorkspaceMemberHandler) AddMemberToWorkspace(c *gin.Context) {
 
 func (h *WorkspaceMemberHandler) RemoveMemberFromWorkspace(c *gin.Context) {
 	h.log.Info().Msg("Handling RemoveMemberFromWorkspace request")
+	actorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RemoveMemberFromWorkspace")
+		return
+	}
+
 	workspaceIDStr := c.Param("workspaceID")
 	h.log.Debug().Str("workspaceID_param", workspaceIDStr).Msg("Parsing workspace ID for removal")
 	workspaceID, err := strconv.Atoi(workspaceIDStr)
@@ -77,10 +95,9 @@ func (h *WorkspaceMemberHandler) RemoveMemberFromWorkspace(c *gin.Context) {
 	}
 	h.log.Debug().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Attempting to remove member from workspace")
 
-	err = h.workspaceMemberService.RemoveMemberFromWorkspace(c.Request.Context(), workspaceID, userID)
+	err = h.workspaceMemberService.RemoveMemberFromWorkspace(c.Request.Context(), actorID, workspaceID, userID)
 	if err != nil {
-		h.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to remove member from workspace via service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member from workspace"})
+		h.writeError(c, err, "Failed to remove member from workspace")
 		return
 	}
 
@@ -129,18 +146,36 @@ func (h *WorkspaceMemberHandler) JoinWorkspace(c *gin.Context) {
 	}
 	h.log.Debug().Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("Attempting to join workspace")
 
-	workspaceMember, err := h.workspaceMemberService.JoinWorkspace(c.Request.Context(), workspaceID, int(userID))
+	// The body is optional: open workspaces can be joined without an invitation.
+	var form models.AcceptInvitationModel
+	if err := c.ShouldBindJSON(&form); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for JoinWorkspace")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspaceMember, err := h.workspaceMemberService.JoinWorkspace(c.Request.Context(), workspaceID, int(userID), form.Token)
 	if err != nil {
 		if _, ok := err.(*services.NotFoundError); ok {
 			h.log.Warn().Err(err).Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("Workspace not found for joining")
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if _, ok := err.(*services.ForbiddenError); ok {
+			h.log.Warn().Err(err).Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("User forbidden from joining workspace")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		if _, ok := err.(*services.ConflictError); ok {
 			h.log.Warn().Err(err).Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("User already a member of workspace")
 			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 			return
 		}
+		if quotaErr, ok := err.(*services.QuotaExceededError); ok {
+			h.log.Warn().Err(err).Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("Workspace has no seat left for joining user")
+			writeQuotaError(c, quotaErr)
+			return
+		}
 		h.log.Error().Err(err).Int("user_id", int(userID)).Int("workspace_id", workspaceID).Msg("Failed to join workspace via service")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join workspace"})
 		return
@@ -149,3 +184,180 @@ func (h *WorkspaceMemberHandler) JoinWorkspace(c *gin.Context) {
 	h.log.Info().Int("workspace_id", workspaceID).Int("user_id", int(userID)).Msg("User joined workspace successfully")
 	c.JSON(http.StatusCreated, workspaceMember)
 }
+
+func (h *WorkspaceMemberHandler) UnlockMember(c *gin.Context) {
+	h.log.Info().Msg("Handling UnlockMember request")
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in UnlockMember")
+		return
+	}
+
+	workspaceIDStr := c.Param("workspaceID")
+	workspaceID, err := strconv.Atoi(workspaceIDStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("workspaceID_param", workspaceIDStr).Msg("Invalid workspace ID format for unlock")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+	userIDStr := c.Param("userID")
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		h.log.Error().Err(err).Str("userID_param", userIDStr).Msg("Invalid user ID format for unlock")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = h.workspaceMemberService.UnlockMember(c.Request.Context(), adminID, workspaceID, userID)
+	if err != nil {
+		if _, ok := err.(*services.NotFoundError); ok {
+			h.log.Warn().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Workspace or member not found for unlock")
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if _, ok := err.(*services.ForbiddenError); ok {
+			h.log.Warn().Err(err).Int("workspace_id", workspaceID).Int("admin_id", adminID).Msg("User forbidden from unlocking members")
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if tooMany, ok := err.(*services.TooManyRequestsError); ok {
+			h.log.Warn().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Sign-in link for locked-out member throttled")
+			c.Header("Retry-After", strconv.Itoa(int(tooMany.RetryAfter.Seconds())+1))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		h.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to unlock member via service")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock member"})
+		return
+	}
+
+	h.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Int("admin_id", adminID).Msg("Sign-in link sent to locked-out member")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *WorkspaceMemberHandler) RequestToJoin(c *gin.Context) {
+	h.log.Info().Msg("Handling RequestToJoin request")
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in RequestToJoin")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	// The message is optional, so is the body.
+	var form models.CreateJoinRequestModel
+	if err := c.ShouldBindJSON(&form); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for RequestToJoin")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := h.workspaceMemberService.RequestToJoin(c.Request.Context(), workspaceID, userID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to request to join workspace")
+		return
+	}
+	c.JSON(http.StatusCreated, request)
+}
+
+func (h *WorkspaceMemberHandler) GetJoinRequests(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context in GetJoinRequests")
+		return
+	}
+	workspaceID, err := strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return
+	}
+
+	requests, err := h.workspaceMemberService.GetJoinRequests(c.Request.Context(), adminID, workspaceID)
+	if err != nil {
+		h.writeError(c, err, "Failed to get join requests")
+		return
+	}
+	c.JSON(http.StatusOK, requests)
+}
+
+func (h *WorkspaceMemberHandler) ApproveJoinRequest(c *gin.Context) {
+	h.log.Info().Msg("Handling ApproveJoinRequest request")
+	adminID, workspaceID, requestID, ok := h.joinRequestParams(c)
+	if !ok {
+		return
+	}
+
+	var form models.ApproveJoinRequestModel
+	if err := c.ShouldBindJSON(&form); err != nil && !errors.Is(err, io.EOF) {
+		h.log.Error().Err(err).Msg("Failed to bind JSON for ApproveJoinRequest")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := h.workspaceMemberService.ApproveJoinRequest(c.Request.Context(), adminID, workspaceID, requestID, form)
+	if err != nil {
+		h.writeError(c, err, "Failed to approve join request")
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+func (h *WorkspaceMemberHandler) DenyJoinRequest(c *gin.Context) {
+	h.log.Info().Msg("Handling DenyJoinRequest request")
+	adminID, workspaceID, requestID, ok := h.joinRequestParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workspaceMemberService.DenyJoinRequest(c.Request.Context(), adminID, workspaceID, requestID); err != nil {
+		h.writeError(c, err, "Failed to deny join request")
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *WorkspaceMemberHandler) joinRequestParams(c *gin.Context) (adminID, workspaceID, requestID int, ok bool) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		h.log.Error().Err(err).Msg("Failed to get user ID from context for join request")
+		return 0, 0, 0, false
+	}
+	workspaceID, err = strconv.Atoi(c.Param("workspaceID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workspace ID"})
+		return 0, 0, 0, false
+	}
+	requestID, err = strconv.Atoi(c.Param("requestID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join request ID"})
+		return 0, 0, 0, false
+	}
+	return adminID, workspaceID, requestID, true
+}
+
+func (h *WorkspaceMemberHandler) writeError(c *gin.Context, err error, message string) {
+	switch err.(type) {
+	case *services.ValidationError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case *services.NotFoundError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case *services.ForbiddenError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case *services.ConflictError:
+		h.log.Warn().Err(err).Msg(message)
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case *services.QuotaExceededError:
+		h.log.Warn().Err(err).Msg(message)
+		writeQuotaError(c, err.(*services.QuotaExceededError))
+	default:
+		h.log.Error().Err(err).Msg(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
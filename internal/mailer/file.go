@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// FileMailer writes each message as an .eml file instead of sending it.
+type FileMailer struct {
+	dir  string
+	from string
+	log  zerolog.Logger
+}
+
+func NewFileMailer(dir, from string, logger zerolog.Logger) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+		log:  logger,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		m.log.Error().Err(err).Str("dir", m.dir).Msg("Failed to create mail directory")
+		return err
+	}
+	path := filepath.Join(m.dir, fmt.Sprintf("%d.eml", time.Now().UnixNano()))
+	if err := os.WriteFile(path, formatMessage(m.from, msg), 0o600); err != nil {
+		m.log.Error().Err(err).Str("path", path).Msg("Failed to write email to file")
+		return err
+	}
+	m.log.Info().Str("path", path).Str("subject", msg.Subject).Msg("Email written to file")
+	return nil
+}
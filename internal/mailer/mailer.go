@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+var (
+	driver       = os.Getenv("MAIL_DRIVER")
+	from         = os.Getenv("MAIL_FROM")
+	mailDir      = os.Getenv("MAIL_DIR")
+	smtpHost     = os.Getenv("SMTP_HOST")
+	smtpPort     = os.Getenv("SMTP_PORT")
+	smtpUsername = os.Getenv("SMTP_USERNAME")
+	smtpPassword = os.Getenv("SMTP_PASSWORD")
+)
+
+// New builds the mailer selected by MAIL_DRIVER: "smtp", "memory", or "file" (the default),
+// which writes every message to MAIL_DIR for local development.
+func New(logger zerolog.Logger) Mailer {
+	if from == "" {
+		from = "no-reply@axis.local"
+	}
+
+	switch driver {
+	case "smtp":
+		logger.Info().Str("host", smtpHost).Msg("Using SMTP mailer")
+		return NewSMTPMailer(smtpHost, smtpPort, smtpUsername, smtpPassword, from, logger)
+	case "memory":
+		logger.Info().Msg("Using in-memory mailer")
+		return NewMemoryMailer()
+	default:
+		dir := mailDir
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "axis-mail")
+		}
+		logger.Info().Str("dir", dir).Msg("Using file mailer")
+		return NewFileMailer(dir, from, logger)
+	}
+}
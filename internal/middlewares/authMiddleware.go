@@ -2,50 +2,181 @@ package middlewares
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
+	"axis/internal/models"
+	"axis/internal/services"
 	"axis/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 )
 
-func JWTAuth(logger zerolog.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		log := logger.With().Str("middleware", "JWTAuth").Logger()
+// authenticate identifies the caller and stores them in the context. Without a scope only access
+// tokens are accepted; with one, personal access tokens carrying it are accepted too. A token
+// restricted to a workspace is only accepted on routes whose path names a resource in that
+// workspace. It aborts the request and returns false when the caller cannot be authenticated.
+func authenticate(c *gin.Context, log zerolog.Logger, sessionService services.SessionService, apiTokenService services.APITokenService, scope models.APIScope) bool {
+	bearer, ok := requestAccessToken(c, log)
+	if !ok {
+		return false
+	}
 
-		h := c.GetHeader("Authorization")
-		if h == "" {
-			log.Warn().Msg("Missing authorization header")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "missing authorization header",
-			})
-			return
-		}
+	if scope == "" || !strings.HasPrefix(bearer, services.APITokenPrefix) {
+		return authenticateSession(c, log, sessionService, bearer)
+	}
 
-		parts := strings.SplitN(h, " ", 2)
+	token, err := apiTokenService.Authenticate(c.Request.Context(), bearer)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to authenticate API token")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to authenticate API token",
+		})
+		return false
+	}
+	if token == nil {
+		log.Warn().Msg("Invalid, expired or revoked API token")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid, expired or revoked API token",
+		})
+		return false
+	}
+	if !token.HasScope(scope) {
+		log.Warn().Int("token_id", token.ID).Msg("API token lacks the required scope")
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "API token lacks the required scope " + string(scope),
+		})
+		return false
+	}
 
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			log.Warn().Str("header", h).Msg("Invalid authorization header format")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid authorization header",
+	if token.WorkspaceID != nil {
+		workspaceID, err := requestWorkspaceID(c, apiTokenService)
+		if err != nil {
+			switch err.(type) {
+			case *services.NotFoundError:
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return false
+			case *services.ForbiddenError:
+				log.Warn().Int("token_id", token.ID).Msg("Workspace-restricted API token used on a route outside any workspace")
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return false
+			}
+			log.Error().Err(err).Int("token_id", token.ID).Msg("Failed to resolve workspace for API token")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to authorize API token",
 			})
-			return
+			return false
 		}
+		if workspaceID != *token.WorkspaceID {
+			log.Warn().Int("token_id", token.ID).Int("workspace_id", workspaceID).Msg("API token is restricted to another workspace")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "API token is not valid for this workspace",
+			})
+			return false
+		}
+	}
 
-		claims, err := utils.ParseToken(parts[1])
+	log.Debug().Int("user_id", token.UserID).Int("token_id", token.ID).Msg("API token authentication successful")
+	c.Set("user_id", token.UserID)
+	c.Set("api_token_id", token.ID)
+	return true
+}
 
-		if err != nil {
-			log.Warn().Err(err).Msg("Invalid or expired token")
+// requestAccessToken returns the bearer token from the Authorization header or, without one, the
+// access token from the session cookie. Cookies are sent by the browser on its own, so requests
+// authenticated by cookie must also pass the CSRF check. It aborts the request and returns false
+// when there is no usable token.
+func requestAccessToken(c *gin.Context, log zerolog.Logger) (string, bool) {
+	h := c.GetHeader("Authorization")
+	if h == "" {
+		token := utils.SessionCookieAccessToken(c)
+		if token == "" {
+			log.Warn().Msg("Missing authorization header")
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid or expired token",
+				"error": "missing authorization header",
 			})
-			return
+			return "", false
 		}
+		if !utils.ValidCSRF(c) {
+			log.Warn().Str("method", c.Request.Method).Msg("Missing or invalid CSRF token for cookie session")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "missing or invalid CSRF token",
+			})
+			return "", false
+		}
+		return token, true
+	}
+
+	parts := strings.SplitN(h, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		log.Warn().Msg("Invalid authorization header format")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid authorization header",
+		})
+		return "", false
+	}
+	return parts[1], true
+}
+
+// authenticateSession validates an access token and the session it belongs to, and stores the
+// user and session in the context. It aborts the request and returns false when they are invalid.
+func authenticateSession(c *gin.Context, log zerolog.Logger, sessionService services.SessionService, accessToken string) bool {
+	claims, err := utils.ParseToken(accessToken)
+
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid or expired token")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid or expired token",
+		})
+		return false
+	}
 
-		log.Debug().Int("user_id", claims.UserID).Msg("User ID extracted from token")
-		c.Set("user_id", claims.UserID)
+	active, err := sessionService.ValidateSession(c.Request.Context(), claims.SessionID, c.ClientIP())
+	if err != nil {
+		log.Error().Err(err).Int("session_id", claims.SessionID).Msg("Failed to validate session")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to validate session",
+		})
+		return false
+	}
+	if !active {
+		log.Warn().Int("user_id", claims.UserID).Int("session_id", claims.SessionID).Msg("Token belongs to a revoked or expired session")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "session has been revoked",
+		})
+		return false
+	}
 
-		log.Debug().Msg("JWT authentication successful, continuing to next handler")
-		c.Next()
+	log.Debug().Int("user_id", claims.UserID).Msg("User ID extracted from token")
+	c.Set("user_id", claims.UserID)
+	c.Set("session_id", claims.SessionID)
+	return true
+}
+
+// resourceParams maps path parameters to the resource they identify, most specific first.
+var resourceParams = []struct {
+	param    string
+	resource models.ResourceType
+}{
+	{"messageID", models.ResourceMessage},
+	{"meetingID", models.ResourceMeeting},
+	{"channelID", models.ResourceChannel},
+	{"workspaceID", models.ResourceWorkspace},
+}
+
+// requestWorkspaceID finds the workspace the request's path refers to. Requests whose path names
+// no resource, such as creating a message from a JSON body, cannot be attributed to a workspace.
+func requestWorkspaceID(c *gin.Context, apiTokenService services.APITokenService) (int, error) {
+	for _, p := range resourceParams {
+		value := c.Param(p.param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, services.NewNotFoundError("Invalid " + p.param)
+		}
+		return apiTokenService.ResourceWorkspaceID(c.Request.Context(), p.resource, id)
 	}
+	return 0, &services.ForbiddenError{Message: "This route is not available to workspace-restricted API tokens"}
 }
@@ -0,0 +1,131 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"axis/internal/models"
+	"axis/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Policy says who may call a route.
+type Policy struct {
+	// Public routes need no login. All others do, and accept personal access tokens only when
+	// Scope is set.
+	Public bool
+	Scope  models.APIScope
+	// Resource is what the route acts on, named by the path parameter Param or, for routes that
+	// create something inside it, by the JSON body field Field. The caller must be a member of the
+	// workspace it belongs to and, when Permission is set, hold that permission there. Routes
+	// without a Resource only need a login; their handlers act on the caller's own data.
+	Resource   models.ResourceType
+	Param      string
+	Field      string
+	Permission models.Permission
+}
+
+// Policies maps routes, written as the method and the path pattern gin registered them with
+// ("GET /api/channels/:channelID"), to their policy.
+type Policies map[string]Policy
+
+// PolicyKey returns the key of a route in Policies.
+func PolicyKey(method, path string) string {
+	return method + " " + path
+}
+
+// Authorize enforces policies on every route it is installed in front of. Requests to a route
+// without a policy are refused, so a route registered without one fails closed instead of open.
+// Requests that match no route are left for the 404 handler.
+func Authorize(logger zerolog.Logger, policies Policies, sessionService services.SessionService, apiTokenService services.APITokenService, authorizer services.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.FullPath() == "" {
+			c.Next()
+			return
+		}
+		route := PolicyKey(c.Request.Method, c.FullPath())
+		log := logger.With().Str("middleware", "Authorize").Str("route", route).Logger()
+
+		policy, ok := policies[route]
+		if !ok {
+			log.Error().Msg("Route has no access policy")
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "access to this route is not allowed",
+			})
+			return
+		}
+		if policy.Public {
+			c.Next()
+			return
+		}
+
+		if !authenticate(c, log, sessionService, apiTokenService, policy.Scope) {
+			return
+		}
+		if policy.Resource != "" && !authorizeResource(c, log, authorizer, policy) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeResource checks the caller against the resource named by the request. It aborts the
+// request and returns false when they may not act on it.
+func authorizeResource(c *gin.Context, log zerolog.Logger, authorizer services.Authorizer, policy Policy) bool {
+	id, err := policyResourceID(c, policy)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	userID := c.GetInt("user_id")
+
+	if policy.Permission != "" {
+		err = authorizer.AuthorizeResource(c.Request.Context(), userID, policy.Resource, id, policy.Permission)
+	} else {
+		err = authorizer.AuthorizeMember(c.Request.Context(), userID, policy.Resource, id)
+	}
+	if err != nil {
+		switch err.(type) {
+		case *services.NotFoundError:
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case *services.ForbiddenError:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			log.Error().Err(err).Int("user_id", userID).Msg("Failed to authorize request")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to authorize request",
+			})
+		}
+		return false
+	}
+	return true
+}
+
+// policyResourceID reads the ID of the policy's resource from the path or the JSON body. The body
+// is put back so the handler can still bind it.
+func policyResourceID(c *gin.Context, policy Policy) (int, error) {
+	if policy.Param != "" {
+		id, err := strconv.Atoi(c.Param(policy.Param))
+		if err != nil {
+			return 0, services.NewValidationError("Invalid " + policy.Param)
+		}
+		return id, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return 0, services.NewValidationError("Failed to read request body")
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var fields map[string]json.RawMessage
+	var id int
+	if json.Unmarshal(body, &fields) != nil || json.Unmarshal(fields[policy.Field], &id) != nil || id == 0 {
+		return 0, services.NewValidationError(policy.Field + " is required")
+	}
+	return id, nil
+}
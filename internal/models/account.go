@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending   DataExportStatus = "pending"
+	DataExportCompleted DataExportStatus = "completed"
+	DataExportFailed    DataExportStatus = "failed"
+)
+
+// DataExport is a user's request for a copy of their data. The archive is built in the
+// background and can be downloaded until ExpiresAt.
+type DataExport struct {
+	bun.BaseModel `bun:"table:data_exports,alias:de"`
+
+	ID          int              `bun:",pk,autoincrement" json:"id"`
+	UserID      int              `bun:",notnull" json:"user_id"`
+	Status      DataExportStatus `bun:",notnull" json:"status"`
+	FilePath    string           `bun:",nullzero" json:"-"`
+	FileSize    int64            `bun:",notnull,default:0" json:"file_size"`
+	CreatedAt   time.Time        `bun:",nullzero,default:current_timestamp" json:"created_at"`
+	CompletedAt *time.Time       `bun:",nullzero" json:"completed_at"`
+	ExpiresAt   *time.Time       `bun:",nullzero" json:"expires_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+type ReactivateAccountModel struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// EraseAccountModel confirms an erasure by repeating the account's email address. The password,
+// and a 2FA code when 2FA is enabled, prove it is the account holder asking.
+type EraseAccountModel struct {
+	ConfirmEmail string `json:"confirm_email"`
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+}
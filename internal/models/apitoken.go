@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// APIScope names what a personal access token may be used for.
+type APIScope string
+
+const (
+	ScopeUsersRead       APIScope = "users:read"
+	ScopeWorkspacesRead  APIScope = "workspaces:read"
+	ScopeWorkspacesWrite APIScope = "workspaces:write"
+	ScopeChannelsRead    APIScope = "channels:read"
+	ScopeChannelsWrite   APIScope = "channels:write"
+	ScopeMeetingsRead    APIScope = "meetings:read"
+	ScopeMeetingsWrite   APIScope = "meetings:write"
+	ScopeMessagesRead    APIScope = "messages:read"
+	ScopeMessagesWrite   APIScope = "messages:write"
+)
+
+// APIScopes lists every scope a token can be granted.
+var APIScopes = []APIScope{
+	ScopeUsersRead,
+	ScopeWorkspacesRead,
+	ScopeWorkspacesWrite,
+	ScopeChannelsRead,
+	ScopeChannelsWrite,
+	ScopeMeetingsRead,
+	ScopeMeetingsWrite,
+	ScopeMessagesRead,
+	ScopeMessagesWrite,
+}
+
+// APIToken is a long-lived personal access token for scripts and CI jobs. Only a hash of the
+// token is stored; the token itself is shown once when it is created.
+type APIToken struct {
+	bun.BaseModel `bun:"table:api_tokens,alias:at"`
+
+	ID          int        `bun:",pk,autoincrement" json:"id"`
+	UserID      int        `bun:",notnull" json:"user_id"`
+	Name        string     `bun:",notnull" json:"name"`
+	TokenHash   string     `bun:",notnull,unique" json:"-"`
+	Prefix      string     `bun:",notnull" json:"prefix"` // Start of the token, to tell tokens apart
+	Scopes      []string   `bun:",array" json:"scopes"`
+	WorkspaceID *int       `bun:"" json:"workspace_id"` // Restricts the token to one workspace when set
+	ExpiresAt   *time.Time `bun:",nullzero" json:"expires_at"`
+	LastUsedAt  *time.Time `bun:",nullzero" json:"last_used_at"`
+	RevokedAt   *time.Time `bun:",nullzero" json:"revoked_at"`
+	CreatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User      *User      `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id" json:"-"`
+}
+
+// IsActive reports whether the token can still be used to authenticate requests.
+func (t *APIToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// HasScope reports whether the token was granted scope.
+func (t *APIToken) HasScope(scope APIScope) bool {
+	for _, s := range t.Scopes {
+		if s == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPITokenModel struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	WorkspaceID   *int     `json:"workspace_id"`
+	ExpiresInDays *int     `json:"expires_in_days"`
+}
+
+// CreatedAPIToken is returned once, when a token is created, and is the only time the token is shown.
+type CreatedAPIToken struct {
+	APIToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AuditAction names a change recorded in a workspace's audit log.
+type AuditAction string
+
+const (
+	AuditMemberRoleChanged    AuditAction = "member.role_changed"
+	AuditMemberRemoved        AuditAction = "member.removed"
+	AuditOwnershipTransferred AuditAction = "workspace.ownership_transferred"
+)
+
+// WorkspaceAuditEntry records who changed a workspace's members or ownership, and how.
+type WorkspaceAuditEntry struct {
+	bun.BaseModel `bun:"table:workspace_audit_log,alias:wal"`
+
+	ID           int            `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID  int            `bun:",notnull" json:"workspace_id"`
+	ActorID      int            `bun:",notnull" json:"actor_id"`
+	Action       AuditAction    `bun:",notnull" json:"action"`
+	TargetUserID *int           `bun:"" json:"target_user_id"`
+	Details      map[string]any `bun:"type:jsonb" json:"details"`
+	CreatedAt    time.Time      `bun:",nullzero,default:current_timestamp" json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// WorkspaceInvitation lets people join a workspace. An invitation with an Email is sent to that
+// address and only works for the account using it; one without is a link that can be shared.
+type WorkspaceInvitation struct {
+	bun.BaseModel `bun:"table:workspace_invitations,alias:wi"`
+
+	ID          int        `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID int        `bun:",notnull" json:"workspace_id"`
+	TokenHash   string     `bun:",notnull,unique" json:"-"`
+	Email       string     `bun:",nullzero" json:"email,omitempty"`
+	Role        UserRole   `bun:",notnull" json:"role"`
+	MaxUses     *int       `bun:"" json:"max_uses"`
+	Uses        int        `bun:",notnull,default:0" json:"uses"`
+	ExpiresAt   time.Time  `bun:",notnull" json:"expires_at"`
+	RevokedAt   *time.Time `bun:",nullzero" json:"revoked_at,omitempty"`
+	CreatedByID int        `bun:",notnull" json:"created_by_id"`
+	CreatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	// Token and Link are only filled in the response that creates the invitation.
+	Token string `bun:"-" json:"token,omitempty"`
+	Link  string `bun:"-" json:"link,omitempty"`
+
+	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id" json:"-"`
+}
+
+// Usable reports whether the invitation can still be redeemed at now.
+func (i *WorkspaceInvitation) Usable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && (i.MaxUses == nil || i.Uses < *i.MaxUses)
+}
+
+type CreateInvitationModel struct {
+	Email string `json:"email"`
+	// Role defaults to Member when left out.
+	Role      *UserRole  `json:"role"`
+	MaxUses   *int       `json:"max_uses"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type AcceptInvitationModel struct {
+	Token string `json:"token"`
+}
+
+// InvitationPreview is what someone holding an invitation link sees before accepting it.
+type InvitationPreview struct {
+	WorkspaceID   int       `json:"workspace_id"`
+	WorkspaceName string    `json:"workspace_name"`
+	Role          UserRole  `json:"role"`
+	Email         string    `json:"email,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
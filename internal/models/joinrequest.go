@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type JoinRequestStatus string
+
+const (
+	JoinRequestPending  JoinRequestStatus = "pending"
+	JoinRequestApproved JoinRequestStatus = "approved"
+	JoinRequestDenied   JoinRequestStatus = "denied"
+)
+
+// WorkspaceJoinRequest is a user asking the admins of a workspace to let them in.
+type WorkspaceJoinRequest struct {
+	bun.BaseModel `bun:"table:workspace_join_requests,alias:wjr"`
+
+	ID          int               `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID int               `bun:",notnull" json:"workspace_id"`
+	UserID      int               `bun:",notnull" json:"user_id"`
+	Message     string            `bun:",nullzero" json:"message,omitempty"`
+	Status      JoinRequestStatus `bun:",notnull,default:'pending'" json:"status"`
+	// Role is the role the user was given when the request was approved.
+	Role        *UserRole  `bun:"" json:"role,omitempty"`
+	DecidedByID *int       `bun:"" json:"decided_by_id,omitempty"`
+	DecidedAt   *time.Time `bun:",nullzero" json:"decided_at,omitempty"`
+	CreatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User      *User      `bun:"rel:belongs-to,join:user_id=id" json:"user,omitempty"`
+	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id" json:"-"`
+}
+
+type CreateJoinRequestModel struct {
+	Message string `json:"message"`
+}
+
+type ApproveJoinRequestModel struct {
+	// Role defaults to Member when left out.
+	Role *UserRole `json:"role"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// LoginLink records an emailed sign-in link. The link itself is a signed action token; the row
+// makes it single use and lets sending be rate limited.
+type LoginLink struct {
+	bun.BaseModel `bun:"table:login_links,alias:ll"`
+
+	ID        int        `bun:",pk,autoincrement" json:"id"`
+	TokenID   string     `bun:",notnull,unique" json:"-"`
+	UserID    int        `bun:",notnull" json:"user_id"`
+	ExpiresAt time.Time  `bun:",notnull" json:"expires_at"`
+	UsedAt    *time.Time `bun:",nullzero" json:"used_at"`
+	CreatedAt time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+type LoginLinkRequestModel struct {
+	Email string `json:"email"`
+}
+
+type LoginLinkModel struct {
+	Token      string `json:"token"`
+	DeviceName string `json:"device_name"`
+}
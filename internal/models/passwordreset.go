@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type PasswordResetToken struct {
+	bun.BaseModel `bun:"table:password_reset_tokens,alias:prt"`
+
+	ID        int        `bun:",pk,autoincrement" json:"id"`
+	UserID    int        `bun:",notnull" json:"user_id"`
+	TokenHash string     `bun:",notnull,unique" json:"-"`
+	ExpiresAt time.Time  `bun:",notnull" json:"expires_at"`
+	UsedAt    *time.Time `bun:",nullzero" json:"used_at"`
+	CreatedAt time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+type ForgotPasswordModel struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordModel struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordModel struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
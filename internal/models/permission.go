@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Permission names something a workspace member may be allowed to do. Members may always act on
+// what they created themselves; these permissions cover acting on the workspace and on what
+// others created.
+type Permission string
+
+const (
+	PermWorkspaceUpdate Permission = "workspace.update"
+	PermWorkspaceDelete Permission = "workspace.delete"
+	PermWorkspaceSSO    Permission = "workspace.sso"
+	PermWorkspaceUsage  Permission = "workspace.usage"
+	PermWorkspaceAudit  Permission = "workspace.audit"
+	PermMemberInvite    Permission = "member.invite"
+	PermMemberApprove   Permission = "member.approve"
+	PermMemberRemove    Permission = "member.remove"
+	PermMemberRoles     Permission = "member.roles"
+	PermMemberUnlock    Permission = "member.unlock"
+	PermChannelCreate   Permission = "channel.create"
+	PermChannelUpdate   Permission = "channel.update"
+	PermChannelDelete   Permission = "channel.delete"
+	PermMeetingCreate   Permission = "meeting.create"
+	PermMeetingUpdate   Permission = "meeting.update"
+	PermMeetingDelete   Permission = "meeting.delete"
+	PermMessageDelete   Permission = "message.delete"
+)
+
+// Permissions lists every permission a role can be granted.
+var Permissions = []Permission{
+	PermWorkspaceUpdate,
+	PermWorkspaceDelete,
+	PermWorkspaceSSO,
+	PermWorkspaceUsage,
+	PermWorkspaceAudit,
+	PermMemberInvite,
+	PermMemberApprove,
+	PermMemberRemove,
+	PermMemberRoles,
+	PermMemberUnlock,
+	PermChannelCreate,
+	PermChannelUpdate,
+	PermChannelDelete,
+	PermMeetingCreate,
+	PermMeetingUpdate,
+	PermMeetingDelete,
+	PermMessageDelete,
+}
+
+// RolePermissions is what each built-in role may do. Owners may do everything.
+var RolePermissions = map[UserRole][]Permission{
+	Owner: Permissions,
+	Admin: {
+		PermWorkspaceUpdate,
+		PermWorkspaceSSO,
+		PermWorkspaceUsage,
+		PermWorkspaceAudit,
+		PermMemberInvite,
+		PermMemberApprove,
+		PermMemberRemove,
+		PermMemberRoles,
+		PermMemberUnlock,
+		PermChannelCreate,
+		PermChannelUpdate,
+		PermChannelDelete,
+		PermMeetingCreate,
+		PermMeetingUpdate,
+		PermMeetingDelete,
+		PermMessageDelete,
+	},
+	Member: {PermChannelCreate, PermMeetingCreate},
+	Guest:  {},
+}
+
+// WorkspaceRole is a role a workspace defines for itself, granting exactly its Permissions.
+type WorkspaceRole struct {
+	bun.BaseModel `bun:"table:workspace_roles,alias:wr"`
+
+	ID          int       `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID int       `bun:",notnull,unique:workspace_role_name" json:"workspace_id"`
+	Name        string    `bun:",notnull,unique:workspace_role_name" json:"name"`
+	Permissions []string  `bun:",array" json:"permissions"`
+	CreatedAt   time.Time `bun:",nullzero,default:current_timestamp" json:"created_at"`
+}
+
+type RoleModel struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+// AssignRoleModel sets a member's role: either a built-in Role, or a CustomRoleID.
+type AssignRoleModel struct {
+	Role         *UserRole `json:"role"`
+	CustomRoleID *int      `json:"custom_role_id"`
+}
+
+// PermissionMatrix describes the permission catalogue and what each built-in role is granted.
+type PermissionMatrix struct {
+	Permissions []Permission            `json:"permissions"`
+	Roles       map[string][]Permission `json:"roles"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// PlanName identifies the plan a workspace is on. Plans only differ in their limits.
+type PlanName string
+
+const (
+	PlanFree       PlanName = "free"
+	PlanTeam       PlanName = "team"
+	PlanEnterprise PlanName = "enterprise"
+)
+
+// Quota names a limited resource of a workspace.
+type Quota string
+
+const (
+	// QuotaSeats limits the number of members.
+	QuotaSeats Quota = "seats"
+	// QuotaStorage limits the total size of attachments, in bytes.
+	QuotaStorage Quota = "storage_bytes"
+	// QuotaMessageHistory limits how many days back message history can be read.
+	QuotaMessageHistory Quota = "message_history_days"
+	// QuotaMeetings limits the number of meetings.
+	QuotaMeetings Quota = "meetings"
+)
+
+// Quotas lists every quota, in the order usage is reported.
+var Quotas = []Quota{QuotaSeats, QuotaStorage, QuotaMessageHistory, QuotaMeetings}
+
+// Plans holds the limits of each plan. A quota missing from a plan is unlimited.
+var Plans = map[PlanName]map[Quota]int64{
+	PlanFree: {
+		QuotaSeats:          10,
+		QuotaStorage:        5 << 30,
+		QuotaMessageHistory: 90,
+		QuotaMeetings:       100,
+	},
+	PlanTeam: {
+		QuotaSeats:   250,
+		QuotaStorage: 250 << 30,
+	},
+	PlanEnterprise: {},
+}
+
+type QuotaUsage struct {
+	Quota Quota `json:"quota"`
+	Used  int64 `json:"used"`
+	// Limit is nil when the quota is unlimited.
+	Limit *int64 `json:"limit"`
+}
+
+// WorkspaceUsage is what a workspace uses of its plan.
+type WorkspaceUsage struct {
+	WorkspaceID int          `json:"workspace_id"`
+	Plan        PlanName     `json:"plan"`
+	Quotas      []QuotaUsage `json:"quotas"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type Presence string
+
+const (
+	PresenceOnline  Presence = "online"
+	PresenceAway    Presence = "away"
+	PresenceOffline Presence = "offline"
+)
+
+// UserPresence is whether a user is around right now, derived from their live connections.
+type UserPresence struct {
+	UserID   int      `json:"user_id"`
+	Presence Presence `json:"presence"`
+	// LastActiveAt is the last activity seen from any of the user's devices since this server
+	// started, if any.
+	LastActiveAt *time.Time `json:"last_active_at"`
+}
+
+// WSHeartbeatData is sent by clients every minute or so on any WebSocket to report whether the
+// user is idle, for example because the window lost focus.
+type WSHeartbeatData struct {
+	Idle bool `json:"idle"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// ResourceType identifies the kind of object a request acts on, for checks that depend on which
+// workspace the object belongs to.
+type ResourceType string
+
+const (
+	ResourceWorkspace  ResourceType = "workspace"
+	ResourceChannel    ResourceType = "channel"
+	ResourceMeeting    ResourceType = "meeting"
+	ResourceMessage    ResourceType = "message"
+	ResourceAttachment ResourceType = "attachment"
+)
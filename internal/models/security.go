@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// LoginAttempt counts recent failed logins for one key, an account or a client IP.
+type LoginAttempt struct {
+	bun.BaseModel `bun:"table:login_attempts,alias:la"`
+
+	Key           string     `bun:",pk"`
+	Failures      int        `bun:",notnull,default:0"`
+	LastFailureAt time.Time  `bun:",notnull"`
+	LockedUntil   *time.Time `bun:",nullzero"`
+}
+
+type SecurityEventType string
+
+const (
+	SecurityEventAccountLocked SecurityEventType = "account_locked"
+	SecurityEventIPLocked      SecurityEventType = "ip_locked"
+)
+
+// SecurityEvent records something an administrator or the affected user may want to know about.
+type SecurityEvent struct {
+	bun.BaseModel `bun:"table:security_events,alias:se"`
+
+	ID        int               `bun:",pk,autoincrement" json:"id"`
+	Type      SecurityEventType `bun:",notnull" json:"type"`
+	UserID    *int              `bun:"" json:"user_id"`
+	ActorID   *int              `bun:"" json:"actor_id"` // User who caused the event, when it is not UserID
+	IPAddress string            `bun:"" json:"ip_address"`
+	Details   map[string]any    `bun:"type:jsonb" json:"details"`
+	CreatedAt time.Time         `bun:",nullzero,default:current_timestamp" json:"created_at"`
+}
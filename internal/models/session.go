@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Session struct {
+	bun.BaseModel `bun:"table:sessions,alias:s"`
+
+	ID               int        `bun:",pk,autoincrement" json:"id"`
+	UserID           int        `bun:",notnull" json:"user_id"`
+	RefreshTokenHash string     `bun:",notnull" json:"-"`
+	DeviceName       string     `bun:"" json:"device_name"`
+	IPAddress        string     `bun:"" json:"ip_address"`
+	UserAgent        string     `bun:"" json:"user_agent"`
+	LastSeenAt       time.Time  `bun:",nullzero,default:current_timestamp" json:"last_seen_at"`
+	ExpiresAt        time.Time  `bun:",notnull" json:"expires_at"`
+	RevokedAt        *time.Time `bun:",nullzero" json:"revoked_at"`
+	CreatedAt        time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+	UpdatedAt        time.Time  `bun:",nullzero,default:current_timestamp" json:"updated_at"`
+
+	Current bool `bun:"-" json:"current"` // Non-persisted, set when listing the caller's sessions
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+// SessionMetadata describes the client a session is created for.
+type SessionMetadata struct {
+	DeviceName string
+	IPAddress  string
+	UserAgent  string
+}
+
+// IsActive reports whether the session can still be used to authenticate requests.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// TokenPair is returned whenever a session is created or its refresh token is rotated.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshTokenModel struct {
+	RefreshToken string `json:"refresh_token"`
+}
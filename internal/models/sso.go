@@ -0,0 +1,122 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type WorkspaceOIDCConfig struct {
+	bun.BaseModel `bun:"table:workspace_oidc_configs,alias:woc"`
+
+	ID             int       `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID    int       `bun:",notnull,unique" json:"workspace_id"`
+	Issuer         string    `bun:",notnull" json:"issuer"`
+	ClientID       string    `bun:",notnull" json:"client_id"`
+	ClientSecret   string    `bun:",notnull" json:"-"`
+	AllowedDomains []string  `bun:",array" json:"allowed_domains"`
+	Enabled        bool      `bun:",notnull,default:true" json:"enabled"`
+	CreatedAt      time.Time `bun:",nullzero,default:current_timestamp" json:"created_at"`
+	UpdatedAt      time.Time `bun:",nullzero,default:current_timestamp" json:"updated_at"`
+
+	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id" json:"-"`
+}
+
+type WorkspaceSAMLConfig struct {
+	bun.BaseModel `bun:"table:workspace_saml_configs,alias:wsc"`
+
+	ID             int      `bun:",pk,autoincrement" json:"id"`
+	WorkspaceID    int      `bun:",notnull,unique" json:"workspace_id"`
+	IdPEntityID    string   `bun:"idp_entity_id,notnull" json:"idp_entity_id"`
+	IdPSSOURL      string   `bun:"idp_sso_url,notnull" json:"idp_sso_url"`
+	IdPCertificate string   `bun:"idp_certificate,notnull" json:"idp_certificate"`
+	AllowedDomains []string `bun:",array" json:"allowed_domains"`
+	Enabled        bool     `bun:",notnull,default:true" json:"enabled"`
+
+	// Names of the assertion attributes that map onto user fields. An empty email attribute
+	// means the NameID is the email address.
+	EmailAttribute    string `json:"email_attribute"`
+	NameAttribute     string `json:"name_attribute"`
+	UsernameAttribute string `json:"username_attribute"`
+	TimezoneAttribute string `json:"timezone_attribute"`
+	LocaleAttribute   string `json:"locale_attribute"`
+
+	CreatedAt time.Time `bun:",nullzero,default:current_timestamp" json:"created_at"`
+	UpdatedAt time.Time `bun:",nullzero,default:current_timestamp" json:"updated_at"`
+
+	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id" json:"-"`
+}
+
+// UserIdentity links a user to an account at an external identity provider.
+type UserIdentity struct {
+	bun.BaseModel `bun:"table:user_identities,alias:ui"`
+
+	ID          int        `bun:",pk,autoincrement" json:"id"`
+	UserID      int        `bun:",notnull" json:"user_id"`
+	Provider    string     `bun:",notnull,unique:provider_issuer_subject" json:"provider"`
+	Issuer      string     `bun:",notnull,unique:provider_issuer_subject" json:"issuer"`
+	Subject     string     `bun:",notnull,unique:provider_issuer_subject" json:"subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `bun:",nullzero" json:"last_login_at"`
+	CreatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+// SSOLoginState carries a started SSO login across the redirect to the identity provider.
+type SSOLoginState struct {
+	bun.BaseModel `bun:"table:sso_login_states,alias:sls"`
+
+	ID           int       `bun:",pk,autoincrement"`
+	State        string    `bun:",notnull,unique"`
+	WorkspaceID  int       `bun:",notnull"`
+	Nonce        string    `bun:""`
+	CodeVerifier string    `bun:""`
+	LinkUserID   *int      `bun:""` // The signed-in user linking the identity to their account
+	ExpiresAt    time.Time `bun:",notnull"`
+	CreatedAt    time.Time `bun:",nullzero,default:current_timestamp"`
+}
+
+// SSOLoginCode is a one-time code handed to the web client after a login that finished with a
+// browser redirect, such as a SAML POST to the ACS endpoint, to be exchanged for tokens.
+type SSOLoginCode struct {
+	bun.BaseModel `bun:"table:sso_login_codes,alias:slc"`
+
+	ID        int       `bun:",pk,autoincrement"`
+	CodeHash  string    `bun:",notnull,unique"`
+	UserID    int       `bun:",notnull"`
+	ExpiresAt time.Time `bun:",notnull"`
+	CreatedAt time.Time `bun:",nullzero,default:current_timestamp"`
+}
+
+type OIDCConfigModel struct {
+	Issuer         string   `json:"issuer"`
+	ClientID       string   `json:"client_id"`
+	ClientSecret   string   `json:"client_secret"`
+	AllowedDomains []string `json:"allowed_domains"`
+	Enabled        *bool    `json:"enabled"`
+}
+
+type OIDCCallbackModel struct {
+	Code       string `json:"code"`
+	State      string `json:"state"`
+	DeviceName string `json:"device_name"`
+}
+
+type SAMLConfigModel struct {
+	IdPEntityID       string   `json:"idp_entity_id"`
+	IdPSSOURL         string   `json:"idp_sso_url"`
+	IdPCertificate    string   `json:"idp_certificate"`
+	AllowedDomains    []string `json:"allowed_domains"`
+	Enabled           *bool    `json:"enabled"`
+	EmailAttribute    string   `json:"email_attribute"`
+	NameAttribute     string   `json:"name_attribute"`
+	UsernameAttribute string   `json:"username_attribute"`
+	TimezoneAttribute string   `json:"timezone_attribute"`
+	LocaleAttribute   string   `json:"locale_attribute"`
+}
+
+type SSOCodeExchangeModel struct {
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name"`
+}
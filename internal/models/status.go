@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// DNDSchedule is a recurring Do Not Disturb window, evaluated in the user's timezone. Start and
+// End are "HH:MM"; a window whose end is not after its start runs past midnight, and belongs to
+// the day it starts on.
+type DNDSchedule struct {
+	Days  []time.Weekday `json:"days"` // 0 is Sunday
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+}
+
+// UserStatusInfo is what other users see of someone's status.
+type UserStatusInfo struct {
+	UserID       int          `json:"user_id"`
+	Status       UserStatus   `json:"status"`
+	Text         string       `json:"text"`
+	Emoji        string       `json:"emoji"`
+	ExpiresAt    *time.Time   `json:"expires_at"`
+	DoNotDisturb bool         `json:"do_not_disturb"`
+	DNDUntil     *time.Time   `json:"dnd_until"`
+	DNDSchedule  *DNDSchedule `json:"dnd_schedule"`
+}
+
+// SetStatusModel replaces the custom status. Without expires_at it stays until cleared.
+type SetStatusModel struct {
+	Status    *UserStatus `json:"status"`
+	Text      string      `json:"text"`
+	Emoji     string      `json:"emoji"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+type SetDoNotDisturbModel struct {
+	Until time.Time `json:"until"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes,alias:rc"`
+
+	ID        int        `bun:",pk,autoincrement" json:"id"`
+	UserID    int        `bun:",notnull" json:"user_id"`
+	CodeHash  string     `bun:",notnull,unique" json:"-"`
+	UsedAt    *time.Time `bun:",nullzero" json:"used_at"`
+	CreatedAt time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+// TwoFactorChallenge is handed out by Login instead of tokens when the account has 2FA enabled.
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type TOTPSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TwoFactorCodeModel struct {
+	Code string `json:"code"`
+}
+
+type DisableTwoFactorModel struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type RegenerateRecoveryCodesModel struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type TwoFactorLoginModel struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	DeviceName     string `json:"device_name"`
+}
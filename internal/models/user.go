@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/uptrace/bun"
 	"time"
 )
@@ -28,6 +30,77 @@ type User struct {
 	LastLoginAt *time.Time `bun:",nullzero" json:"last_login_at"`
 	CreatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
 	UpdatedAt   time.Time  `bun:",nullzero,default:current_timestamp" json:"updated_at"`
+
+	Title    string `bun:",nullzero" json:"title"`
+	Pronouns string `bun:",nullzero" json:"pronouns"`
+	Phone    string `bun:",nullzero" json:"phone"`
+	// StatusText and StatusEmoji make up the custom status, which is cleared at StatusExpiresAt.
+	StatusText      string     `bun:",nullzero" json:"status_text"`
+	StatusEmoji     string     `bun:",nullzero" json:"status_emoji"`
+	StatusExpiresAt *time.Time `bun:",nullzero" json:"status_expires_at"`
+	// DNDUntil holds a manual Do Not Disturb period; DNDSchedule a recurring one.
+	DNDUntil    *time.Time   `bun:",nullzero" json:"dnd_until"`
+	DNDSchedule *DNDSchedule `bun:"type:jsonb" json:"dnd_schedule"`
+
+	// AvatarUpdatedAt is set while the user has an uploaded avatar; without one a generated
+	// identicon is served.
+	AvatarUpdatedAt *time.Time `bun:",nullzero" json:"-"`
+
+	VerificationSentAt *time.Time `bun:",nullzero" json:"-"`
+
+	TwoFactorEnabled bool   `bun:",notnull,default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `bun:",nullzero" json:"-"`
+	TOTPLastUsedStep int64  `bun:",notnull,default:0" json:"-"`
+
+	// DeactivatedAt is set while the account is deactivated; it cannot sign in until reactivated.
+	DeactivatedAt *time.Time `bun:",nullzero" json:"deactivated_at,omitempty"`
+	// ErasedAt is set once the account's personal data has been erased. Such accounts stay
+	// deactivated for good and only keep authored messages attributable to a placeholder.
+	ErasedAt *time.Time `bun:",nullzero" json:"erased_at,omitempty"`
+}
+
+// AvatarURL returns the path of the user's avatar. It changes whenever a new avatar is uploaded,
+// so clients may cache it for long. Append &size=N to pick one of the standard sizes.
+func (u *User) AvatarURL() string {
+	var version int64
+	if u.AvatarUpdatedAt != nil {
+		version = u.AvatarUpdatedAt.Unix()
+	}
+	return fmt.Sprintf("/api/users/%d/avatar?v=%d", u.ID, version)
+}
+
+// PublicProfile is what other users may see of an account. Custom status and Do Not Disturb are
+// only shown to workspace peers, through the status endpoint; contact details, security settings
+// and activity are private.
+type PublicProfile struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+	Title     string `json:"title"`
+	Pronouns  string `json:"pronouns"`
+	Timezone  string `json:"timezone"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+func (u *User) PublicProfile() PublicProfile {
+	return PublicProfile{
+		ID:        u.ID,
+		Name:      u.Name,
+		Username:  u.Username,
+		Title:     u.Title,
+		Pronouns:  u.Pronouns,
+		Timezone:  u.Timezone,
+		AvatarURL: u.AvatarURL(),
+	}
+}
+
+// MarshalJSON adds the computed avatar_url to the stored fields.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	return json.Marshal(struct {
+		user
+		AvatarURL string `json:"avatar_url"`
+	}{user(u), u.AvatarURL()})
 }
 
 type RegisterModel struct {
@@ -40,8 +113,9 @@ type RegisterModel struct {
 }
 
 type LoginModel struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name"`
 }
 
 type UpdateUser struct {
@@ -50,4 +124,23 @@ type UpdateUser struct {
 	Email    *string `json:"email"`
 	Timezone *string `json:"timezone"`
 	Locale   *string `json:"locale"`
+	Title    *string `json:"title"`
+	Pronouns *string `json:"pronouns"`
+	Phone    *string `json:"phone"`
+}
+
+type VerifyEmailModel struct {
+	Token string `json:"token"`
+}
+
+// UserAvatar is one standard size of an uploaded avatar. Avatars are kept in the database so
+// every instance serves the same picture.
+type UserAvatar struct {
+	bun.BaseModel `bun:"table:user_avatars,alias:ua"`
+
+	UserID      int       `bun:",pk" json:"user_id"`
+	Size        int       `bun:",pk" json:"size"`
+	ContentType string    `bun:",notnull" json:"content_type"`
+	Data        []byte    `bun:",notnull" json:"-"`
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
 }
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"axis/internal/webauthn"
+)
+
+type WebAuthnCredential struct {
+	bun.BaseModel `bun:"table:webauthn_credentials,alias:wc"`
+
+	ID           int        `bun:",pk,autoincrement" json:"id"`
+	UserID       int        `bun:",notnull" json:"user_id"`
+	CredentialID string     `bun:",notnull,unique" json:"credential_id"`
+	PublicKey    []byte     `bun:",notnull" json:"-"`
+	SignCount    int64      `bun:",notnull,default:0" json:"sign_count"`
+	AAGUID       []byte     `bun:"aaguid" json:"-"`
+	Name         string     `bun:",notnull" json:"name"`
+	Transports   []string   `bun:",array" json:"transports"`
+	LastUsedAt   *time.Time `bun:",nullzero" json:"last_used_at"`
+	CreatedAt    time.Time  `bun:",nullzero,default:current_timestamp" json:"created_at"`
+
+	User *User `bun:"rel:belongs-to,join:user_id=id" json:"-"`
+}
+
+// WebAuthnChallenge remembers an issued ceremony challenge until the browser's response comes back.
+type WebAuthnChallenge struct {
+	bun.BaseModel `bun:"table:webauthn_challenges,alias:wch"`
+
+	ID        int       `bun:",pk,autoincrement"`
+	Challenge string    `bun:",notnull,unique"`
+	Purpose   string    `bun:",notnull"`
+	UserID    int       `bun:",nullzero"`
+	ExpiresAt time.Time `bun:",notnull"`
+	CreatedAt time.Time `bun:",nullzero,default:current_timestamp"`
+}
+
+type WebAuthnRegistrationModel struct {
+	Name       string                          `json:"name"`
+	Credential webauthn.RegistrationCredential `json:"credential"`
+}
+
+type WebAuthnLoginBeginModel struct {
+	Email string `json:"email"`
+}
+
+type WebAuthnLoginModel struct {
+	Credential webauthn.AssertionCredential `json:"credential"`
+	DeviceName string                       `json:"device_name"`
+}
@@ -10,11 +10,53 @@ type Workspace struct {
 
 	ID          int       `bun:",pk,autoincrement" json:"id"`
 	Name        string    `bun:",notnull" json:"name"`
+	Slug        string    `bun:",notnull,unique" json:"slug"` // Names the workspace in URLs; made from the name unless chosen
 	Description *string   `bun:"" json:"description"`
 	MaxMembers  *int      `bun:"" json:"max_members"`
 	IsActive    bool      `bun:",notnull,default:false" json:"is_active"`
-	CreatorID   int       `bun:",notnull" json:"creator_id"`
+	CreatorID   int       `bun:",notnull" json:"creator_id"` // The owner: the creator, until ownership is transferred
 	CreatedAt   time.Time `bun:",nullzero,default:current_timestamp" json:"created_at"`
 
+	RequireVerifiedEmail bool `bun:",notnull,default:false" json:"require_verified_email"`
+	// IsOpen lets anyone join without an invitation.
+	IsOpen bool `bun:",notnull,default:false" json:"is_open"`
+	// AllowJoinRequests lets people ask the admins for access to a workspace that is not open.
+	AllowJoinRequests bool `bun:",notnull,default:false" json:"allow_join_requests"`
+	// IsDiscoverable lists the workspace in the public directory.
+	IsDiscoverable bool `bun:",notnull,default:false" json:"is_discoverable"`
+	// Plan sets the workspace's limits. Only operators change it, directly in the database.
+	Plan PlanName `bun:",notnull,default:'free'" json:"plan"`
+
 	Creator *User `bun:"rel:belongs-to,join:creator_id=id" json:"-"`
 }
+
+// UpdateWorkspace changes a workspace's settings. Fields left out of the request are kept.
+type UpdateWorkspace struct {
+	Name                 *string `json:"name"`
+	Slug                 *string `json:"slug"`
+	RequireVerifiedEmail *bool   `json:"require_verified_email"`
+	IsOpen               *bool   `json:"is_open"`
+	AllowJoinRequests    *bool   `json:"allow_join_requests"`
+	IsDiscoverable       *bool   `json:"is_discoverable"`
+}
+
+// TransferOwnershipModel hands a workspace to another of its members. ConfirmName must repeat the
+// workspace's name.
+type TransferOwnershipModel struct {
+	UserID      int    `json:"user_id" binding:"required"`
+	ConfirmName string `json:"confirm_name"`
+}
+
+// WorkspaceSummary is what anyone signed in may see of a workspace they found in the directory or
+// by its slug: enough to tell what it is and how to get in.
+type WorkspaceSummary struct {
+	bun.BaseModel `bun:"table:workspaces,alias:w"`
+
+	ID                int     `bun:"id" json:"id"`
+	Name              string  `bun:"name" json:"name"`
+	Slug              string  `bun:"slug" json:"slug"`
+	Description       *string `bun:"description" json:"description"`
+	IsOpen            bool    `bun:"is_open" json:"is_open"`
+	AllowJoinRequests bool    `bun:"allow_join_requests" json:"allow_join_requests"`
+	MemberCount       int     `bun:"member_count,scanonly" json:"member_count"`
+}
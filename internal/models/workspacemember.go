@@ -6,11 +6,17 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// UserRole is a member's built-in role in a workspace. The values are stored, so new roles go at
+// the end.
 type UserRole int
 
 const (
 	Admin UserRole = iota
 	Member
+	// Owner can do everything in the workspace, deleting it included.
+	Owner
+	// Guest can take part in the channels and meetings they are added to, and nothing else.
+	Guest
 )
 
 func (r UserRole) String() string {
@@ -19,6 +25,10 @@ func (r UserRole) String() string {
 		return "admin"
 	case Member:
 		return "member"
+	case Owner:
+		return "owner"
+	case Guest:
+		return "guest"
 	default:
 		return "unknown"
 	}
@@ -32,6 +42,10 @@ type WorkspaceMember struct {
 	Role        UserRole  `bun:",notnull" json:"role"`
 	CreatedAt   time.Time `bun:",nullzero,default:current_timestamp" json:"created_at"`
 
+	// CustomRoleID gives the member the permissions of one of the workspace's own roles
+	// instead of those of Role.
+	CustomRoleID *int `bun:"" json:"custom_role_id,omitempty"`
+
 	// Relationships
 	Workspace *Workspace `bun:"rel:belongs-to,join:workspace_id=id"`
 	User      *User      `bun:"rel:belongs-to,join:user_id=id"`
@@ -0,0 +1,279 @@
+// Package oidc is a minimal OpenID Connect relying party: provider discovery, the authorization
+// code flow with PKCE, and ID token verification against the provider's published keys.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// metadataTTL bounds how long discovery documents and signing keys are cached.
+const metadataTTL = time.Hour
+
+// Config identifies this application at one provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// IDTokenClaims are the standard claims Axis reads from an ID token.
+type IDTokenClaims struct {
+	Nonce             string `json:"nonce"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type provider struct {
+	metadata  providerMetadata
+	keys      map[string]any
+	fetchedAt time.Time
+}
+
+// Client talks to OpenID providers and caches their metadata and keys.
+type Client struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	providers map[string]*provider
+}
+
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{httpClient: httpClient, providers: make(map[string]*provider)}
+}
+
+// NewCodeVerifier returns a random PKCE code verifier (RFC 7636).
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewState returns a random value suitable for the state and nonce parameters.
+func NewState() (string, error) {
+	return randomString(24)
+}
+
+// CodeChallenge derives the S256 PKCE challenge for verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the provider URL the browser is sent to.
+func (c *Client) AuthCodeURL(ctx context.Context, cfg Config, state, nonce, verifier string) (string, error) {
+	p, err := c.provider(ctx, cfg.Issuer, false)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg.ClientID)
+	q.Set("redirect_uri", cfg.RedirectURL)
+	q.Set("scope", "openid email profile")
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", CodeChallenge(verifier))
+	q.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token claims.
+func (c *Client) Exchange(ctx context.Context, cfg Config, code, verifier, nonce string) (*IDTokenClaims, error) {
+	p, err := c.provider(ctx, cfg.Issuer, false)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return c.VerifyIDToken(ctx, cfg, tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the ID token signature, issuer, audience, expiry and nonce.
+func (c *Client) VerifyIDToken(ctx context.Context, cfg Config, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	p, err := c.provider(ctx, cfg.Issuer, false)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFunc := func(refresh bool) jwt.Keyfunc {
+		return func(token *jwt.Token) (any, error) {
+			kid, _ := token.Header["kid"].(string)
+			keys := p.keys
+			if refresh {
+				fresh, err := c.provider(ctx, cfg.Issuer, true)
+				if err != nil {
+					return nil, err
+				}
+				keys = fresh.keys
+			}
+			if kid == "" && len(keys) == 1 {
+				for _, k := range keys {
+					return k, nil
+				}
+			}
+			key, ok := keys[kid]
+			if !ok {
+				return nil, errUnknownKey
+			}
+			return key, nil
+		}
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, keyFunc(false), opts...)
+	if errors.Is(err, errUnknownKey) {
+		// The provider may have rotated its keys since they were cached.
+		claims = &IDTokenClaims{}
+		_, err = jwt.ParseWithClaims(rawIDToken, claims, keyFunc(true), opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id_token has no subject")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("id_token nonce mismatch")
+	}
+	return claims, nil
+}
+
+var errUnknownKey = errors.New("unknown signing key")
+
+func (c *Client) provider(ctx context.Context, issuer string, refresh bool) (*provider, error) {
+	issuer = strings.TrimRight(issuer, "/")
+
+	c.mu.Lock()
+	p, ok := c.providers[issuer]
+	c.mu.Unlock()
+	if ok && !refresh && time.Since(p.fetchedAt) < metadataTTL {
+		return p, nil
+	}
+
+	var metadata providerMetadata
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("discovery failed: %w", err)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %q, not %q", metadata.Issuer, issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing endpoints")
+	}
+
+	var jwks jsonWebKeySet
+	if err := c.getJSON(ctx, metadata.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("fetching signing keys failed: %w", err)
+	}
+	keys, err := jwks.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	p = &provider{metadata: metadata, keys: keys, fetchedAt: time.Now()}
+	c.mu.Lock()
+	c.providers[issuer] = p
+	c.mu.Unlock()
+	return p, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(dest)
+}
+
+func randomString(numBytes int) (string, error) {
+	b := make([]byte, numBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,172 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a local OpenID provider that issues one authorization code at a time.
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	code          string
+	codeChallenge string
+	nonce         string
+	subject       string
+	email         string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	idp := &mockIdP{t: t, key: key, subject: "user-123", email: "jane@example.com"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []JSONWebKey{{
+			Kty: "RSA",
+			Kid: "test-key",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "axis" || secret != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.PostForm.Get("code") != idp.code || CodeChallenge(r.PostForm.Get("code_verifier")) != idp.codeChallenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idp.code = ""
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken("axis", idp.nonce), "token_type": "Bearer"})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize mimics the user approving the request at the authorization endpoint.
+func (idp *mockIdP) authorize(authURL string) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatalf("invalid authorization URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		idp.t.Fatalf("expected S256 PKCE, got %q", q.Get("code_challenge_method"))
+	}
+	idp.code = "code-" + q.Get("state")
+	idp.codeChallenge = q.Get("code_challenge")
+	idp.nonce = q.Get("nonce")
+	return idp.code, q.Get("state")
+}
+
+func (idp *mockIdP) idToken(audience, nonce string) string {
+	claims := IDTokenClaims{
+		Nonce:         nonce,
+		Email:         idp.email,
+		EmailVerified: true,
+		Name:          "Jane Doe",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idp.server.URL,
+			Subject:   idp.subject,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		idp.t.Fatalf("failed to sign id_token: %v", err)
+	}
+	return signed
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewClient(idp.server.Client())
+	cfg := Config{Issuer: idp.server.URL, ClientID: "axis", ClientSecret: "s3cret", RedirectURL: "http://localhost:5173/sso/oidc/callback"}
+
+	state, _ := NewState()
+	nonce, _ := NewState()
+	verifier, _ := NewCodeVerifier()
+	authURL, err := client.AuthCodeURL(context.Background(), cfg, state, nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL returned error: %v", err)
+	}
+
+	code, returnedState := idp.authorize(authURL)
+	if returnedState != state {
+		t.Fatalf("state = %q, want %q", returnedState, state)
+	}
+
+	claims, err := client.Exchange(context.Background(), cfg, code, verifier, nonce)
+	if err != nil {
+		t.Fatalf("Exchange returned error: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "jane@example.com" || !claims.EmailVerified {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewClient(idp.server.Client())
+	cfg := Config{Issuer: idp.server.URL, ClientID: "axis", ClientSecret: "s3cret", RedirectURL: "http://localhost/cb"}
+
+	verifier, _ := NewCodeVerifier()
+	authURL, _ := client.AuthCodeURL(context.Background(), cfg, "state", "nonce", verifier)
+	code, _ := idp.authorize(authURL)
+
+	other, _ := NewCodeVerifier()
+	if _, err := client.Exchange(context.Background(), cfg, code, other, "nonce"); err == nil {
+		t.Fatal("expected exchange with the wrong PKCE verifier to fail")
+	}
+}
+
+func TestVerifyIDTokenChecksAudienceAndNonce(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewClient(idp.server.Client())
+	cfg := Config{Issuer: idp.server.URL, ClientID: "axis"}
+
+	if _, err := client.VerifyIDToken(context.Background(), cfg, idp.idToken("axis", "n1"), "n1"); err != nil {
+		t.Fatalf("VerifyIDToken returned error for a valid token: %v", err)
+	}
+	if _, err := client.VerifyIDToken(context.Background(), cfg, idp.idToken("someone-else", "n1"), "n1"); err == nil {
+		t.Error("expected token for another audience to be rejected")
+	}
+	if _, err := client.VerifyIDToken(context.Background(), cfg, idp.idToken("axis", "n1"), "n2"); err == nil {
+		t.Error("expected token with a different nonce to be rejected")
+	}
+}
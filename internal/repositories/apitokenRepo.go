@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type APITokenRepo interface {
+	CreateToken(ctx context.Context, token *models.APIToken) error
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	GetTokensForUser(ctx context.Context, userID int) ([]models.APIToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID int) (bool, error)
+	RevokeTokensForUser(ctx context.Context, userID int) error
+	TouchToken(ctx context.Context, tokenID int, usedAt time.Time) error
+}
+
+type apiTokenRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewAPITokenRepo(db *bun.DB, logger zerolog.Logger) APITokenRepo {
+	return &apiTokenRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ar *apiTokenRepository) CreateToken(ctx context.Context, token *models.APIToken) error {
+	_, err := ar.db.NewInsert().Model(token).Exec(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", token.UserID).Msg("Failed to create API token")
+		return err
+	}
+	return nil
+}
+
+func (ar *apiTokenRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	token := new(models.APIToken)
+	err := ar.db.NewSelect().Model(token).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		ar.log.Error().Err(err).Msg("Failed to get API token by hash")
+		return nil, err
+	}
+	return token, nil
+}
+
+func (ar *apiTokenRepository) GetTokensForUser(ctx context.Context, userID int) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	err := ar.db.NewSelect().
+		Model(&tokens).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get API tokens for user")
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeToken revokes one of the user's tokens and reports whether there was an active token to revoke.
+func (ar *apiTokenRepository) RevokeToken(ctx context.Context, userID, tokenID int) (bool, error) {
+	res, err := ar.db.NewUpdate().
+		Model((*models.APIToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", tokenID).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Int("token_id", tokenID).Msg("Failed to revoke API token")
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (ar *apiTokenRepository) TouchToken(ctx context.Context, tokenID int, usedAt time.Time) error {
+	_, err := ar.db.NewUpdate().
+		Model((*models.APIToken)(nil)).
+		Set("last_used_at = ?", usedAt).
+		Where("id = ?", tokenID).
+		Exec(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("token_id", tokenID).Msg("Failed to update API token last used time")
+		return err
+	}
+	return nil
+}
+
+// RevokeTokensForUser revokes every active token of the user.
+func (ar *apiTokenRepository) RevokeTokensForUser(ctx context.Context, userID int) error {
+	_, err := ar.db.NewUpdate().
+		Model((*models.APIToken)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Msg("Failed to revoke API tokens for user")
+		return err
+	}
+	return nil
+}
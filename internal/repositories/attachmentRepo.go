@@ -13,6 +13,7 @@ type AttachmentRepo interface {
 	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
 	GetAttachmentByID(ctx context.Context, attachmentID int) (*models.Attachment, error)
 	GetAttachmentsByMessageID(ctx context.Context, messageID int) ([]models.Attachment, error)
+	GetAttachmentsByUser(ctx context.Context, userID int) ([]models.Attachment, error)
 	DeleteAttachment(ctx context.Context, attachmentID int) error
 }
 
@@ -69,3 +70,17 @@ func (ar *attachmentRepository) DeleteAttachment(ctx context.Context, attachment
 	}
 	return nil
 }
+
+func (ar *attachmentRepository) GetAttachmentsByUser(ctx context.Context, userID int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := ar.db.NewSelect().
+		Model(&attachments).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get attachments by user")
+		return nil, err
+	}
+	return attachments, nil
+}
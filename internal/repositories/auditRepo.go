@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type AuditRepo interface {
+	CreateEntry(ctx context.Context, entry *models.WorkspaceAuditEntry) error
+	// GetEntriesForWorkspace lists a workspace's audit log, newest first.
+	GetEntriesForWorkspace(ctx context.Context, workspaceID, limit, offset int) ([]models.WorkspaceAuditEntry, error)
+}
+
+type auditRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewAuditRepo(db *bun.DB, logger zerolog.Logger) AuditRepo {
+	return &auditRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ar *auditRepository) CreateEntry(ctx context.Context, entry *models.WorkspaceAuditEntry) error {
+	_, err := ar.db.NewInsert().Model(entry).Exec(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("workspace_id", entry.WorkspaceID).Str("action", string(entry.Action)).Msg("Failed to create audit entry")
+		return err
+	}
+	return nil
+}
+
+func (ar *auditRepository) GetEntriesForWorkspace(ctx context.Context, workspaceID, limit, offset int) ([]models.WorkspaceAuditEntry, error) {
+	var entries []models.WorkspaceAuditEntry
+	err := ar.db.NewSelect().
+		Model(&entries).
+		Where("workspace_id = ?", workspaceID).
+		OrderExpr("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		ar.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get audit entries for workspace")
+		return nil, err
+	}
+	return entries, nil
+}
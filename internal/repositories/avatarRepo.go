@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type AvatarRepo interface {
+	ReplaceAvatars(ctx context.Context, userID int, avatars []models.UserAvatar, updatedAt time.Time) error
+	GetAvatar(ctx context.Context, userID, size int) (*models.UserAvatar, error)
+	DeleteAvatars(ctx context.Context, userID int) error
+}
+
+type avatarRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewAvatarRepo(db *bun.DB, logger zerolog.Logger) AvatarRepo {
+	return &avatarRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+// ReplaceAvatars swaps the user's stored sizes for avatars and records the upload time on the
+// user, which versions the avatar URL.
+func (ar *avatarRepository) ReplaceAvatars(ctx context.Context, userID int, avatars []models.UserAvatar, updatedAt time.Time) error {
+	err := ar.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*models.UserAvatar)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(&avatars).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().
+			Model((*models.User)(nil)).
+			Set("avatar_updated_at = ?", updatedAt).
+			Where("id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Msg("Failed to replace avatars")
+		return err
+	}
+	return nil
+}
+
+func (ar *avatarRepository) GetAvatar(ctx context.Context, userID, size int) (*models.UserAvatar, error) {
+	avatar := new(models.UserAvatar)
+	err := ar.db.NewSelect().
+		Model(avatar).
+		Where("user_id = ?", userID).
+		Where("size = ?", size).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		ar.log.Error().Err(err).Int("user_id", userID).Int("size", size).Msg("Failed to get avatar")
+		return nil, err
+	}
+	return avatar, nil
+}
+
+func (ar *avatarRepository) DeleteAvatars(ctx context.Context, userID int) error {
+	err := ar.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().Model((*models.UserAvatar)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().
+			Model((*models.User)(nil)).
+			Set("avatar_updated_at = NULL").
+			Where("id = ?", userID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		ar.log.Error().Err(err).Int("user_id", userID).Msg("Failed to delete avatars")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type DataExportRepo interface {
+	CreateExport(ctx context.Context, export *models.DataExport) error
+	GetExport(ctx context.Context, exportID int) (*models.DataExport, error)
+	GetExportsForUser(ctx context.Context, userID int) ([]models.DataExport, error)
+	UpdateExport(ctx context.Context, export *models.DataExport) error
+}
+
+type dataExportRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewDataExportRepo(db *bun.DB, logger zerolog.Logger) DataExportRepo {
+	return &dataExportRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (dr *dataExportRepository) CreateExport(ctx context.Context, export *models.DataExport) error {
+	_, err := dr.db.NewInsert().Model(export).Exec(ctx)
+	if err != nil {
+		dr.log.Error().Err(err).Int("user_id", export.UserID).Msg("Failed to create data export")
+		return err
+	}
+	return nil
+}
+
+func (dr *dataExportRepository) GetExport(ctx context.Context, exportID int) (*models.DataExport, error) {
+	export := new(models.DataExport)
+	err := dr.db.NewSelect().Model(export).Where("id = ?", exportID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		dr.log.Error().Err(err).Int("export_id", exportID).Msg("Failed to get data export")
+		return nil, err
+	}
+	return export, nil
+}
+
+func (dr *dataExportRepository) GetExportsForUser(ctx context.Context, userID int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := dr.db.NewSelect().
+		Model(&exports).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		dr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get data exports for user")
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (dr *dataExportRepository) UpdateExport(ctx context.Context, export *models.DataExport) error {
+	_, err := dr.db.NewUpdate().Model(export).WherePK().Exec(ctx)
+	if err != nil {
+		dr.log.Error().Err(err).Int("export_id", export.ID).Msg("Failed to update data export")
+		return err
+	}
+	return nil
+}
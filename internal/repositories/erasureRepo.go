@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+// ErasureRepo removes a user's personal data across tables in one transaction.
+type ErasureRepo interface {
+	EraseUser(ctx context.Context, anonymized *models.User, email, redactedContent string) error
+}
+
+type erasureRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewErasureRepo(db *bun.DB, logger zerolog.Logger) ErasureRepo {
+	return &erasureRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+// userOwnedModels are deleted outright on erasure. They hold the user's ID in user_id and no other
+// table references them.
+var userOwnedModels = []any{
+	(*models.Session)(nil),
+	(*models.APIToken)(nil),
+	(*models.PasswordResetToken)(nil),
+	(*models.LoginLink)(nil),
+	(*models.RecoveryCode)(nil),
+	(*models.WebAuthnCredential)(nil),
+	(*models.WebAuthnChallenge)(nil),
+	(*models.UserIdentity)(nil),
+	(*models.SSOLoginCode)(nil),
+	(*models.Reaction)(nil),
+	(*models.Attachment)(nil),
+	(*models.ChannelMember)(nil),
+	(*models.MeetingMember)(nil),
+	(*models.WorkspaceMember)(nil),
+	(*models.WorkspaceJoinRequest)(nil),
+	(*models.SecurityEvent)(nil),
+	(*models.DataExport)(nil),
+	(*models.UserAvatar)(nil),
+}
+
+// EraseUser deletes the rows that only belong to the user and the invitations sent to email, the
+// address they had. It then replaces the content of their messages and overwrites the user row with
+// anonymized, which must carry the user's ID. Messages, channels, meetings, workspaces and
+// invitations the user created keep pointing at that row, so nothing dangles.
+func (er *erasureRepository) EraseUser(ctx context.Context, anonymized *models.User, email, redactedContent string) error {
+	err := er.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, model := range userOwnedModels {
+			if _, err := tx.NewDelete().Model(model).Where("user_id = ?", anonymized.ID).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.NewDelete().
+			Model((*models.WorkspaceInvitation)(nil)).
+			Where("email = ?", strings.ToLower(email)).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if _, err := tx.NewUpdate().
+			Model((*models.Message)(nil)).
+			Set("content = ?", redactedContent).
+			Where("sender_id = ?", anonymized.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		_, err := tx.NewUpdate().Model(anonymized).WherePK().Exec(ctx)
+		return err
+	})
+	if err != nil {
+		er.log.Error().Err(err).Int("user_id", anonymized.ID).Msg("Failed to erase user")
+		return err
+	}
+	return nil
+}
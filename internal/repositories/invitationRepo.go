@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type InvitationRepo interface {
+	CreateInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error
+	GetInvitation(ctx context.Context, invitationID int) (*models.WorkspaceInvitation, error)
+	GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error)
+	GetInvitationsForWorkspace(ctx context.Context, workspaceID int) ([]models.WorkspaceInvitation, error)
+	RevokeInvitation(ctx context.Context, invitationID int) error
+	// RedeemInvitation uses up one use of a still usable invitation and adds member to its
+	// workspace, in one transaction. It reports false if the invitation was no longer usable.
+	RedeemInvitation(ctx context.Context, invitationID int, member *models.WorkspaceMember) (bool, error)
+}
+
+type invitationRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewInvitationRepo(db *bun.DB, logger zerolog.Logger) InvitationRepo {
+	return &invitationRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ir *invitationRepository) CreateInvitation(ctx context.Context, invitation *models.WorkspaceInvitation) error {
+	_, err := ir.db.NewInsert().Model(invitation).Exec(ctx)
+	if err != nil {
+		ir.log.Error().Err(err).Int("workspace_id", invitation.WorkspaceID).Msg("Failed to create workspace invitation")
+		return err
+	}
+	return nil
+}
+
+func (ir *invitationRepository) GetInvitation(ctx context.Context, invitationID int) (*models.WorkspaceInvitation, error) {
+	invitation := new(models.WorkspaceInvitation)
+	err := ir.db.NewSelect().Model(invitation).Where("id = ?", invitationID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		ir.log.Error().Err(err).Int("invitation_id", invitationID).Msg("Failed to get workspace invitation")
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (ir *invitationRepository) GetInvitationByTokenHash(ctx context.Context, tokenHash string) (*models.WorkspaceInvitation, error) {
+	invitation := new(models.WorkspaceInvitation)
+	err := ir.db.NewSelect().
+		Model(invitation).
+		Relation("Workspace").
+		Where("wi.token_hash = ?", tokenHash).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		ir.log.Error().Err(err).Msg("Failed to get workspace invitation by token")
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (ir *invitationRepository) GetInvitationsForWorkspace(ctx context.Context, workspaceID int) ([]models.WorkspaceInvitation, error) {
+	var invitations []models.WorkspaceInvitation
+	err := ir.db.NewSelect().
+		Model(&invitations).
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		ir.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get workspace invitations")
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ir *invitationRepository) RevokeInvitation(ctx context.Context, invitationID int) error {
+	_, err := ir.db.NewUpdate().
+		Model((*models.WorkspaceInvitation)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("id = ?", invitationID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		ir.log.Error().Err(err).Int("invitation_id", invitationID).Msg("Failed to revoke workspace invitation")
+		return err
+	}
+	return nil
+}
+
+func (ir *invitationRepository) RedeemInvitation(ctx context.Context, invitationID int, member *models.WorkspaceMember) (bool, error) {
+	redeemed := false
+	err := ir.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*models.WorkspaceInvitation)(nil)).
+			Set("uses = uses + 1").
+			Where("id = ?", invitationID).
+			Where("revoked_at IS NULL").
+			Where("expires_at > ?", time.Now()).
+			Where("(max_uses IS NULL OR uses < max_uses)").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected != 1 {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(member).Exec(ctx); err != nil {
+			return err
+		}
+		redeemed = true
+		return nil
+	})
+	if err != nil {
+		ir.log.Error().Err(err).Int("invitation_id", invitationID).Int("user_id", member.UserID).Msg("Failed to redeem workspace invitation")
+		return false, err
+	}
+	return redeemed, nil
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type JoinRequestRepo interface {
+	CreateJoinRequest(ctx context.Context, request *models.WorkspaceJoinRequest) error
+	GetJoinRequest(ctx context.Context, requestID int) (*models.WorkspaceJoinRequest, error)
+	GetPendingJoinRequest(ctx context.Context, workspaceID, userID int) (*models.WorkspaceJoinRequest, error)
+	GetPendingJoinRequests(ctx context.Context, workspaceID int) ([]models.WorkspaceJoinRequest, error)
+	// DecideJoinRequest stores the decision on a pending request and, when member is not nil, adds
+	// it to the workspace, in one transaction. It reports false if the request was already decided.
+	DecideJoinRequest(ctx context.Context, request *models.WorkspaceJoinRequest, member *models.WorkspaceMember) (bool, error)
+}
+
+type joinRequestRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewJoinRequestRepo(db *bun.DB, logger zerolog.Logger) JoinRequestRepo {
+	return &joinRequestRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (jr *joinRequestRepository) CreateJoinRequest(ctx context.Context, request *models.WorkspaceJoinRequest) error {
+	_, err := jr.db.NewInsert().Model(request).Returning("*").Exec(ctx)
+	if err != nil {
+		jr.log.Error().Err(err).Int("workspace_id", request.WorkspaceID).Int("user_id", request.UserID).Msg("Failed to create join request")
+		return err
+	}
+	return nil
+}
+
+func (jr *joinRequestRepository) GetJoinRequest(ctx context.Context, requestID int) (*models.WorkspaceJoinRequest, error) {
+	request := new(models.WorkspaceJoinRequest)
+	err := jr.db.NewSelect().
+		Model(request).
+		Relation("User").
+		Where("wjr.id = ?", requestID).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		jr.log.Error().Err(err).Int("request_id", requestID).Msg("Failed to get join request")
+		return nil, err
+	}
+	return request, nil
+}
+
+func (jr *joinRequestRepository) GetPendingJoinRequest(ctx context.Context, workspaceID, userID int) (*models.WorkspaceJoinRequest, error) {
+	request := new(models.WorkspaceJoinRequest)
+	err := jr.db.NewSelect().
+		Model(request).
+		Where("workspace_id = ?", workspaceID).
+		Where("user_id = ?", userID).
+		Where("status = ?", models.JoinRequestPending).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		jr.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to get pending join request")
+		return nil, err
+	}
+	return request, nil
+}
+
+func (jr *joinRequestRepository) GetPendingJoinRequests(ctx context.Context, workspaceID int) ([]models.WorkspaceJoinRequest, error) {
+	var requests []models.WorkspaceJoinRequest
+	err := jr.db.NewSelect().
+		Model(&requests).
+		Relation("User").
+		Where("wjr.workspace_id = ?", workspaceID).
+		Where("wjr.status = ?", models.JoinRequestPending).
+		Order("wjr.created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		jr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get pending join requests")
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (jr *joinRequestRepository) DecideJoinRequest(ctx context.Context, request *models.WorkspaceJoinRequest, member *models.WorkspaceMember) (bool, error) {
+	decided := false
+	err := jr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model(request).
+			Column("status", "role", "decided_by_id", "decided_at").
+			WherePK().
+			Where("status = ?", models.JoinRequestPending).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil || affected != 1 {
+			return err
+		}
+		if member != nil {
+			if _, err := tx.NewInsert().Model(member).Exec(ctx); err != nil {
+				return err
+			}
+		}
+		decided = true
+		return nil
+	})
+	if err != nil {
+		jr.log.Error().Err(err).Int("request_id", request.ID).Msg("Failed to decide join request")
+		return false, err
+	}
+	return decided, nil
+}
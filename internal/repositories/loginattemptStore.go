@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+// LoginAttemptStore keeps failed login counters. Counters whose last failure is older than the
+// window passed to RecordFailure start over.
+type LoginAttemptStore interface {
+	GetAttempt(ctx context.Context, key string) (*models.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error)
+	SetLockedUntil(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// NewLoginAttemptStore builds the store selected by LOGIN_ATTEMPT_STORE: "memory", which only
+// suits a single instance, or "postgres" (the default).
+func NewLoginAttemptStore(db *bun.DB, logger zerolog.Logger) LoginAttemptStore {
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		logger.Info().Msg("Using in-memory login attempt store")
+		return NewMemoryLoginAttemptStore()
+	}
+	return NewPostgresLoginAttemptStore(db, logger)
+}
+
+type postgresLoginAttemptStore struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewPostgresLoginAttemptStore(db *bun.DB, logger zerolog.Logger) LoginAttemptStore {
+	return &postgresLoginAttemptStore{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ps *postgresLoginAttemptStore) GetAttempt(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	attempt := new(models.LoginAttempt)
+	err := ps.db.NewSelect().Model(attempt).Where("key = ?", key).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		ps.log.Error().Err(err).Msg("Failed to get login attempt")
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (ps *postgresLoginAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	attempt := &models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now}
+	_, err := ps.db.NewInsert().
+		Model(attempt).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = CASE WHEN la.last_failure_at < ? THEN 1 ELSE la.failures + 1 END", now.Add(-window)).
+		Set("last_failure_at = EXCLUDED.last_failure_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		ps.log.Error().Err(err).Msg("Failed to record failed login attempt")
+		return nil, err
+	}
+	return attempt, nil
+}
+
+func (ps *postgresLoginAttemptStore) SetLockedUntil(ctx context.Context, key string, until time.Time) error {
+	_, err := ps.db.NewUpdate().
+		Model((*models.LoginAttempt)(nil)).
+		Set("locked_until = ?", until).
+		Where("key = ?", key).
+		Exec(ctx)
+	if err != nil {
+		ps.log.Error().Err(err).Msg("Failed to lock login key")
+		return err
+	}
+	return nil
+}
+
+func (ps *postgresLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	_, err := ps.db.NewDelete().Model((*models.LoginAttempt)(nil)).Where("key = ?", key).Exec(ctx)
+	if err != nil {
+		ps.log.Error().Err(err).Msg("Failed to reset login attempts")
+		return err
+	}
+	return nil
+}
+
+// maxMemoryLoginAttempts bounds the in-memory store; past it, expired counters are dropped.
+const maxMemoryLoginAttempts = 100000
+
+type memoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: make(map[string]models.LoginAttempt)}
+}
+
+func (ms *memoryLoginAttemptStore) GetAttempt(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	attempt, ok := ms.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (ms *memoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*models.LoginAttempt, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	attempt, ok := ms.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(now.Add(-window)) {
+		if len(ms.attempts) >= maxMemoryLoginAttempts {
+			ms.prune(now, window)
+		}
+		attempt = models.LoginAttempt{Key: key, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	ms.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (ms *memoryLoginAttemptStore) SetLockedUntil(ctx context.Context, key string, until time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if attempt, ok := ms.attempts[key]; ok {
+		attempt.LockedUntil = &until
+		ms.attempts[key] = attempt
+	}
+	return nil
+}
+
+func (ms *memoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.attempts, key)
+	return nil
+}
+
+func (ms *memoryLoginAttemptStore) prune(now time.Time, window time.Duration) {
+	for key, attempt := range ms.attempts {
+		if attempt.LastFailureAt.Before(now.Add(-window)) && (attempt.LockedUntil == nil || attempt.LockedUntil.Before(now)) {
+			delete(ms.attempts, key)
+		}
+	}
+}
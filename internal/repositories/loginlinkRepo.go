@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type LoginLinkRepo interface {
+	CreateLink(ctx context.Context, link *models.LoginLink) error
+	ConsumeLink(ctx context.Context, tokenID string, userID int) (bool, error)
+	CountLinksSince(ctx context.Context, userID int, since time.Time) (int, error)
+}
+
+type loginLinkRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewLoginLinkRepo(db *bun.DB, logger zerolog.Logger) LoginLinkRepo {
+	return &loginLinkRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (lr *loginLinkRepository) CreateLink(ctx context.Context, link *models.LoginLink) error {
+	_, err := lr.db.NewInsert().Model(link).Exec(ctx)
+	if err != nil {
+		lr.log.Error().Err(err).Int("user_id", link.UserID).Msg("Failed to create login link")
+		return err
+	}
+	return nil
+}
+
+// ConsumeLink marks an unexpired link as used. It reports false if the link is unknown, expired or
+// was already used, so a link cannot log in twice even when opened concurrently.
+func (lr *loginLinkRepository) ConsumeLink(ctx context.Context, tokenID string, userID int) (bool, error) {
+	now := time.Now()
+	res, err := lr.db.NewUpdate().
+		Model((*models.LoginLink)(nil)).
+		Set("used_at = ?", now).
+		Where("token_id = ?", tokenID).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Where("expires_at > ?", now).
+		Exec(ctx)
+	if err != nil {
+		lr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to consume login link")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (lr *loginLinkRepository) CountLinksSince(ctx context.Context, userID int, since time.Time) (int, error) {
+	count, err := lr.db.NewSelect().
+		Model((*models.LoginLink)(nil)).
+		Where("user_id = ?", userID).
+		Where("created_at >= ?", since).
+		Count(ctx)
+	if err != nil {
+		lr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to count login links")
+		return 0, err
+	}
+	return count, nil
+}
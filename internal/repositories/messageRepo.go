@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"axis/internal/models"
 	"github.com/rs/zerolog"
@@ -12,8 +13,11 @@ import (
 type MessageRepo interface {
 	CreateMessage(ctx context.Context, message *models.Message) error
 	GetMessageByID(ctx context.Context, messageID int) (*models.Message, error)
-	GetMessagesByMeetingID(ctx context.Context, meetingID int, limit, offset int) ([]models.Message, error)
+	// GetMessagesByMeetingID lists a meeting's messages, newest first. When since is set, older
+	// messages are left out.
+	GetMessagesByMeetingID(ctx context.Context, meetingID int, since *time.Time, limit, offset int) ([]models.Message, error)
 	GetThreadedMessages(ctx context.Context, parentMessageID int) ([]models.Message, error)
+	GetMessagesBySender(ctx context.Context, senderID int) ([]models.Message, error)
 	UpdateMessage(ctx context.Context, message *models.Message) error
 	DeleteMessage(ctx context.Context, messageID int) error
 }
@@ -53,11 +57,15 @@ func (mr *messageRepository) GetMessageByID(ctx context.Context, messageID int)
 	return message, nil
 }
 
-func (mr *messageRepository) GetMessagesByMeetingID(ctx context.Context, meetingID int, limit, offset int) ([]models.Message, error) {
+func (mr *messageRepository) GetMessagesByMeetingID(ctx context.Context, meetingID int, since *time.Time, limit, offset int) ([]models.Message, error) {
 	var messages []models.Message
-	err := mr.db.NewSelect().
+	query := mr.db.NewSelect().
 		Model(&messages).
-		Where("meeting_id = ?", meetingID).
+		Where("meeting_id = ?", meetingID)
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
+	err := query.
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -100,3 +108,17 @@ func (mr *messageRepository) DeleteMessage(ctx context.Context, messageID int) e
 	}
 	return nil
 }
+
+func (mr *messageRepository) GetMessagesBySender(ctx context.Context, senderID int) ([]models.Message, error) {
+	var messages []models.Message
+	err := mr.db.NewSelect().
+		Model(&messages).
+		Where("sender_id = ?", senderID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		mr.log.Error().Err(err).Int("sender_id", senderID).Msg("Failed to get messages by sender")
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type PasswordResetRepo interface {
+	CreateToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetTokenByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	ConsumeToken(ctx context.Context, tokenID int) (bool, error)
+	InvalidateTokensForUser(ctx context.Context, userID int) error
+}
+
+type passwordResetRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewPasswordResetRepo(db *bun.DB, logger zerolog.Logger) PasswordResetRepo {
+	return &passwordResetRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (pr *passwordResetRepository) CreateToken(ctx context.Context, token *models.PasswordResetToken) error {
+	_, err := pr.db.NewInsert().Model(token).Exec(ctx)
+	if err != nil {
+		pr.log.Error().Err(err).Int("user_id", token.UserID).Msg("Failed to create password reset token")
+		return err
+	}
+	return nil
+}
+
+func (pr *passwordResetRepository) GetTokenByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	token := new(models.PasswordResetToken)
+	err := pr.db.NewSelect().Model(token).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		pr.log.Error().Err(err).Msg("Failed to get password reset token")
+		return nil, err
+	}
+	return token, nil
+}
+
+// ConsumeToken marks the token as used. It reports false if the token was already used, so
+// two concurrent resets with the same token cannot both succeed.
+func (pr *passwordResetRepository) ConsumeToken(ctx context.Context, tokenID int) (bool, error) {
+	res, err := pr.db.NewUpdate().
+		Model((*models.PasswordResetToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("id = ?", tokenID).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		pr.log.Error().Err(err).Int("token_id", tokenID).Msg("Failed to consume password reset token")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (pr *passwordResetRepository) InvalidateTokensForUser(ctx context.Context, userID int) error {
+	_, err := pr.db.NewUpdate().
+		Model((*models.PasswordResetToken)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		pr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to invalidate password reset tokens")
+		return err
+	}
+	return nil
+}
@@ -13,6 +13,7 @@ type ReactionRepo interface {
 	CreateReaction(ctx context.Context, reaction *models.Reaction) error
 	DeleteReaction(ctx context.Context, messageID, userID int, emoji string) error
 	GetReactionsByMessageID(ctx context.Context, messageID int) ([]models.Reaction, error)
+	GetReactionsByUser(ctx context.Context, userID int) ([]models.Reaction, error)
 	GetReactionByMessageUserEmoji(ctx context.Context, messageID, userID int, emoji string) (*models.Reaction, error)
 }
 
@@ -82,3 +83,17 @@ func (rr *reactionRepository) GetReactionByMessageUserEmoji(ctx context.Context,
 	}
 	return reaction, nil
 }
+
+func (rr *reactionRepository) GetReactionsByUser(ctx context.Context, userID int) ([]models.Reaction, error) {
+	var reactions []models.Reaction
+	err := rr.db.NewSelect().
+		Model(&reactions).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get reactions by user")
+		return nil, err
+	}
+	return reactions, nil
+}
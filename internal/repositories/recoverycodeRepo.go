@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type RecoveryCodeRepo interface {
+	ReplaceCodesForUser(ctx context.Context, userID int, codes []*models.RecoveryCode) error
+	ConsumeCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	DeleteCodesForUser(ctx context.Context, userID int) error
+	CountUnusedCodes(ctx context.Context, userID int) (int, error)
+}
+
+type recoveryCodeRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewRecoveryCodeRepo(db *bun.DB, logger zerolog.Logger) RecoveryCodeRepo {
+	return &recoveryCodeRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+// ReplaceCodesForUser drops every existing code for the user and stores the new set atomically.
+func (rr *recoveryCodeRepository) ReplaceCodesForUser(ctx context.Context, userID int, codes []*models.RecoveryCode) error {
+	err := rr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*models.RecoveryCode)(nil)).
+			Where("user_id = ?", userID).
+			Exec(ctx); err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&codes).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		rr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to replace recovery codes")
+		return err
+	}
+	return nil
+}
+
+// ConsumeCode marks a matching unused code as used. It reports false when no such code exists.
+func (rr *recoveryCodeRepository) ConsumeCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	res, err := rr.db.NewUpdate().
+		Model((*models.RecoveryCode)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to consume recovery code")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (rr *recoveryCodeRepository) DeleteCodesForUser(ctx context.Context, userID int) error {
+	_, err := rr.db.NewDelete().
+		Model((*models.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to delete recovery codes")
+		return err
+	}
+	return nil
+}
+
+func (rr *recoveryCodeRepository) CountUnusedCodes(ctx context.Context, userID int) (int, error) {
+	count, err := rr.db.NewSelect().
+		Model((*models.RecoveryCode)(nil)).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Count(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to count recovery codes")
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type RoleRepo interface {
+	CreateRole(ctx context.Context, role *models.WorkspaceRole) error
+	GetRole(ctx context.Context, roleID int) (*models.WorkspaceRole, error)
+	GetRolesForWorkspace(ctx context.Context, workspaceID int) ([]models.WorkspaceRole, error)
+	UpdateRole(ctx context.Context, role *models.WorkspaceRole) error
+	// DeleteRole deletes a role and moves the members who had it back to their built-in role, in
+	// one transaction.
+	DeleteRole(ctx context.Context, roleID int) error
+	// AssignRole sets a member's built-in role and custom role. It reports false if the user is
+	// not a member of the workspace.
+	AssignRole(ctx context.Context, workspaceID, userID int, role models.UserRole, customRoleID *int) (bool, error)
+}
+
+type roleRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewRoleRepo(db *bun.DB, logger zerolog.Logger) RoleRepo {
+	return &roleRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (rr *roleRepository) CreateRole(ctx context.Context, role *models.WorkspaceRole) error {
+	_, err := rr.db.NewInsert().Model(role).Returning("*").Exec(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("workspace_id", role.WorkspaceID).Str("name", role.Name).Msg("Failed to create workspace role")
+		return err
+	}
+	return nil
+}
+
+func (rr *roleRepository) GetRole(ctx context.Context, roleID int) (*models.WorkspaceRole, error) {
+	role := new(models.WorkspaceRole)
+	err := rr.db.NewSelect().Model(role).Where("id = ?", roleID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		rr.log.Error().Err(err).Int("role_id", roleID).Msg("Failed to get workspace role")
+		return nil, err
+	}
+	return role, nil
+}
+
+func (rr *roleRepository) GetRolesForWorkspace(ctx context.Context, workspaceID int) ([]models.WorkspaceRole, error) {
+	var roles []models.WorkspaceRole
+	err := rr.db.NewSelect().
+		Model(&roles).
+		Where("workspace_id = ?", workspaceID).
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get workspace roles")
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (rr *roleRepository) UpdateRole(ctx context.Context, role *models.WorkspaceRole) error {
+	_, err := rr.db.NewUpdate().
+		Model(role).
+		Column("name", "permissions").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("role_id", role.ID).Msg("Failed to update workspace role")
+		return err
+	}
+	return nil
+}
+
+func (rr *roleRepository) DeleteRole(ctx context.Context, roleID int) error {
+	err := rr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*models.WorkspaceMember)(nil)).
+			Set("custom_role_id = NULL").
+			Where("custom_role_id = ?", roleID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.NewDelete().
+			Model((*models.WorkspaceRole)(nil)).
+			Where("id = ?", roleID).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		rr.log.Error().Err(err).Int("role_id", roleID).Msg("Failed to delete workspace role")
+		return err
+	}
+	return nil
+}
+
+func (rr *roleRepository) AssignRole(ctx context.Context, workspaceID, userID int, role models.UserRole, customRoleID *int) (bool, error) {
+	res, err := rr.db.NewUpdate().
+		Model((*models.WorkspaceMember)(nil)).
+		Set("role = ?", role).
+		Set("custom_role_id = ?", customRoleID).
+		Where("workspace_id = ?", workspaceID).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		rr.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Str("role", role.String()).Msg("Failed to assign workspace role")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
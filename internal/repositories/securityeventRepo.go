@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type SecurityEventRepo interface {
+	CreateEvent(ctx context.Context, event *models.SecurityEvent) error
+}
+
+type securityEventRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewSecurityEventRepo(db *bun.DB, logger zerolog.Logger) SecurityEventRepo {
+	return &securityEventRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (sr *securityEventRepository) CreateEvent(ctx context.Context, event *models.SecurityEvent) error {
+	_, err := sr.db.NewInsert().Model(event).Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Str("type", string(event.Type)).Msg("Failed to create security event")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type SessionRepo interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByID(ctx context.Context, sessionID int) (*models.Session, error)
+	UpdateSession(ctx context.Context, session *models.Session) error
+	// RotateRefreshToken saves session, which carries a new refresh token, as long as the session is
+	// still active under previousHash. It reports false when another refresh got there first.
+	RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) (bool, error)
+	RevokeSession(ctx context.Context, sessionID int) error
+	GetActiveSessionsForUser(ctx context.Context, userID int) ([]models.Session, error)
+	RevokeSessionsForUser(ctx context.Context, userID, exceptSessionID int) error
+	TouchSession(ctx context.Context, sessionID int, ipAddress string, seenAt time.Time) error
+}
+
+type sessionRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewSessionRepo(db *bun.DB, logger zerolog.Logger) SessionRepo {
+	return &sessionRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (sr *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	_, err := sr.db.NewInsert().Model(session).Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", session.UserID).Msg("Failed to create session")
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) GetSessionByID(ctx context.Context, sessionID int) (*models.Session, error) {
+	session := new(models.Session)
+	err := sr.db.NewSelect().Model(session).Where("id = ?", sessionID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			sr.log.Info().Int("session_id", sessionID).Msg("Session not found")
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to get session by ID")
+		return nil, err
+	}
+	return session, nil
+}
+
+func (sr *sessionRepository) UpdateSession(ctx context.Context, session *models.Session) error {
+	_, err := sr.db.NewUpdate().Model(session).WherePK().Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("session_id", session.ID).Msg("Failed to update session")
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) RotateRefreshToken(ctx context.Context, session *models.Session, previousHash string) (bool, error) {
+	res, err := sr.db.NewUpdate().
+		Model(session).
+		Column("refresh_token_hash", "expires_at", "ip_address", "user_agent", "last_seen_at", "updated_at").
+		WherePK().
+		Where("refresh_token_hash = ?", previousHash).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("session_id", session.ID).Msg("Failed to rotate refresh token")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (sr *sessionRepository) RevokeSession(ctx context.Context, sessionID int) error {
+	_, err := sr.db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", sessionID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to revoke session")
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) GetActiveSessionsForUser(ctx context.Context, userID int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := sr.db.NewSelect().
+		Model(&sessions).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("last_seen_at DESC").
+		Scan(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get active sessions for user")
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSessionsForUser revokes every active session of the user except exceptSessionID.
+// Pass 0 to revoke all of them.
+func (sr *sessionRepository) RevokeSessionsForUser(ctx context.Context, userID, exceptSessionID int) error {
+	_, err := sr.db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Set("updated_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("id != ?", exceptSessionID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", userID).Int("except_session_id", exceptSessionID).Msg("Failed to revoke sessions for user")
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) TouchSession(ctx context.Context, sessionID int, ipAddress string, seenAt time.Time) error {
+	_, err := sr.db.NewUpdate().
+		Model((*models.Session)(nil)).
+		Set("last_seen_at = ?", seenAt).
+		Set("ip_address = ?", ipAddress).
+		Where("id = ?", sessionID).
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to update session last seen")
+		return err
+	}
+	return nil
+}
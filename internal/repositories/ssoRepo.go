@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type SSORepo interface {
+	GetOIDCConfig(ctx context.Context, workspaceID int) (*models.WorkspaceOIDCConfig, error)
+	SaveOIDCConfig(ctx context.Context, config *models.WorkspaceOIDCConfig) error
+	DeleteOIDCConfig(ctx context.Context, workspaceID int) error
+	GetSAMLConfig(ctx context.Context, workspaceID int) (*models.WorkspaceSAMLConfig, error)
+	SaveSAMLConfig(ctx context.Context, config *models.WorkspaceSAMLConfig) error
+	DeleteSAMLConfig(ctx context.Context, workspaceID int) error
+	GetIdentity(ctx context.Context, provider, issuer, subject string) (*models.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *models.UserIdentity) error
+	UpdateIdentity(ctx context.Context, identity *models.UserIdentity) error
+	CreateLoginState(ctx context.Context, state *models.SSOLoginState) error
+	ConsumeLoginState(ctx context.Context, state string) (*models.SSOLoginState, error)
+	CreateLoginCode(ctx context.Context, code *models.SSOLoginCode) error
+	ConsumeLoginCode(ctx context.Context, codeHash string) (*models.SSOLoginCode, error)
+}
+
+type ssoRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewSSORepo(db *bun.DB, logger zerolog.Logger) SSORepo {
+	return &ssoRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (sr *ssoRepository) GetOIDCConfig(ctx context.Context, workspaceID int) (*models.WorkspaceOIDCConfig, error) {
+	config := new(models.WorkspaceOIDCConfig)
+	err := sr.db.NewSelect().Model(config).Where("workspace_id = ?", workspaceID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get OIDC config")
+		return nil, err
+	}
+	return config, nil
+}
+
+// SaveOIDCConfig inserts the workspace's configuration or replaces the existing one.
+func (sr *ssoRepository) SaveOIDCConfig(ctx context.Context, config *models.WorkspaceOIDCConfig) error {
+	_, err := sr.db.NewInsert().
+		Model(config).
+		On("CONFLICT (workspace_id) DO UPDATE").
+		Set("issuer = EXCLUDED.issuer").
+		Set("client_id = EXCLUDED.client_id").
+		Set("client_secret = EXCLUDED.client_secret").
+		Set("allowed_domains = EXCLUDED.allowed_domains").
+		Set("enabled = EXCLUDED.enabled").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("workspace_id", config.WorkspaceID).Msg("Failed to save OIDC config")
+		return err
+	}
+	return nil
+}
+
+func (sr *ssoRepository) DeleteOIDCConfig(ctx context.Context, workspaceID int) error {
+	_, err := sr.db.NewDelete().
+		Model((*models.WorkspaceOIDCConfig)(nil)).
+		Where("workspace_id = ?", workspaceID).
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to delete OIDC config")
+		return err
+	}
+	return nil
+}
+
+func (sr *ssoRepository) GetSAMLConfig(ctx context.Context, workspaceID int) (*models.WorkspaceSAMLConfig, error) {
+	config := new(models.WorkspaceSAMLConfig)
+	err := sr.db.NewSelect().Model(config).Where("workspace_id = ?", workspaceID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get SAML config")
+		return nil, err
+	}
+	return config, nil
+}
+
+// SaveSAMLConfig inserts the workspace's configuration or replaces the existing one.
+func (sr *ssoRepository) SaveSAMLConfig(ctx context.Context, config *models.WorkspaceSAMLConfig) error {
+	_, err := sr.db.NewInsert().
+		Model(config).
+		On("CONFLICT (workspace_id) DO UPDATE").
+		Set("idp_entity_id = EXCLUDED.idp_entity_id").
+		Set("idp_sso_url = EXCLUDED.idp_sso_url").
+		Set("idp_certificate = EXCLUDED.idp_certificate").
+		Set("allowed_domains = EXCLUDED.allowed_domains").
+		Set("enabled = EXCLUDED.enabled").
+		Set("email_attribute = EXCLUDED.email_attribute").
+		Set("name_attribute = EXCLUDED.name_attribute").
+		Set("username_attribute = EXCLUDED.username_attribute").
+		Set("timezone_attribute = EXCLUDED.timezone_attribute").
+		Set("locale_attribute = EXCLUDED.locale_attribute").
+		Set("updated_at = EXCLUDED.updated_at").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("workspace_id", config.WorkspaceID).Msg("Failed to save SAML config")
+		return err
+	}
+	return nil
+}
+
+func (sr *ssoRepository) DeleteSAMLConfig(ctx context.Context, workspaceID int) error {
+	_, err := sr.db.NewDelete().
+		Model((*models.WorkspaceSAMLConfig)(nil)).
+		Where("workspace_id = ?", workspaceID).
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to delete SAML config")
+		return err
+	}
+	return nil
+}
+
+func (sr *ssoRepository) GetIdentity(ctx context.Context, provider, issuer, subject string) (*models.UserIdentity, error) {
+	identity := new(models.UserIdentity)
+	err := sr.db.NewSelect().
+		Model(identity).
+		Where("provider = ?", provider).
+		Where("issuer = ?", issuer).
+		Where("subject = ?", subject).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Str("provider", provider).Msg("Failed to get user identity")
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (sr *ssoRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	_, err := sr.db.NewInsert().Model(identity).Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", identity.UserID).Str("provider", identity.Provider).Msg("Failed to create user identity")
+		return err
+	}
+	return nil
+}
+
+func (sr *ssoRepository) UpdateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	_, err := sr.db.NewUpdate().Model(identity).WherePK().Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("identity_id", identity.ID).Msg("Failed to update user identity")
+		return err
+	}
+	return nil
+}
+
+// CreateLoginState stores a new login state and clears out expired ones.
+func (sr *ssoRepository) CreateLoginState(ctx context.Context, state *models.SSOLoginState) error {
+	if _, err := sr.db.NewDelete().
+		Model((*models.SSOLoginState)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		sr.log.Warn().Err(err).Msg("Failed to delete expired SSO login states")
+	}
+
+	_, err := sr.db.NewInsert().Model(state).Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("workspace_id", state.WorkspaceID).Msg("Failed to create SSO login state")
+		return err
+	}
+	return nil
+}
+
+// ConsumeLoginState deletes and returns the matching state so each login can only complete once.
+func (sr *ssoRepository) ConsumeLoginState(ctx context.Context, state string) (*models.SSOLoginState, error) {
+	consumed := new(models.SSOLoginState)
+	err := sr.db.NewDelete().
+		Model(consumed).
+		Where("state = ?", state).
+		Returning("*").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Msg("Failed to consume SSO login state")
+		return nil, err
+	}
+	return consumed, nil
+}
+
+func (sr *ssoRepository) CreateLoginCode(ctx context.Context, code *models.SSOLoginCode) error {
+	if _, err := sr.db.NewDelete().
+		Model((*models.SSOLoginCode)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		sr.log.Warn().Err(err).Msg("Failed to delete expired SSO login codes")
+	}
+
+	_, err := sr.db.NewInsert().Model(code).Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", code.UserID).Msg("Failed to create SSO login code")
+		return err
+	}
+	return nil
+}
+
+// ConsumeLoginCode deletes and returns the matching code so it can only be exchanged once.
+func (sr *ssoRepository) ConsumeLoginCode(ctx context.Context, codeHash string) (*models.SSOLoginCode, error) {
+	consumed := new(models.SSOLoginCode)
+	err := sr.db.NewDelete().
+		Model(consumed).
+		Where("code_hash = ?", codeHash).
+		Returning("*").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		sr.log.Error().Err(err).Msg("Failed to consume SSO login code")
+		return nil, err
+	}
+	return consumed, nil
+}
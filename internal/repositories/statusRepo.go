@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+// StatusRepo reads and writes the status columns of users without touching the rest of the row.
+type StatusRepo interface {
+	UpdateStatus(ctx context.Context, user *models.User) error
+	// GetExpiredStatuses returns users whose custom status or manual Do Not Disturb has run out.
+	GetExpiredStatuses(ctx context.Context, now time.Time) ([]models.User, error)
+	GetScheduledDoNotDisturb(ctx context.Context) ([]models.User, error)
+}
+
+type statusRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewStatusRepo(db *bun.DB, logger zerolog.Logger) StatusRepo {
+	return &statusRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+var statusColumns = []string{"status", "status_text", "status_emoji", "status_expires_at", "dnd_until", "dnd_schedule"}
+
+func (sr *statusRepository) UpdateStatus(ctx context.Context, user *models.User) error {
+	_, err := sr.db.NewUpdate().
+		Model(user).
+		Column(statusColumns...).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to update user status")
+		return err
+	}
+	return nil
+}
+
+func (sr *statusRepository) GetExpiredStatuses(ctx context.Context, now time.Time) ([]models.User, error) {
+	var users []models.User
+	err := sr.db.NewSelect().
+		Model(&users).
+		WhereOr("status_expires_at <= ?", now).
+		WhereOr("dnd_until <= ?", now).
+		Scan(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Msg("Failed to get expired user statuses")
+		return nil, err
+	}
+	return users, nil
+}
+
+func (sr *statusRepository) GetScheduledDoNotDisturb(ctx context.Context) ([]models.User, error) {
+	var users []models.User
+	err := sr.db.NewSelect().
+		Model(&users).
+		Where("dnd_schedule IS NOT NULL").
+		Where("deactivated_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		sr.log.Error().Err(err).Msg("Failed to get users with a Do Not Disturb schedule")
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+// UsageRepo meters what a workspace uses of its plan. Usage is counted from the data itself, so it
+// never drifts from what is stored.
+type UsageRepo interface {
+	CountMembers(ctx context.Context, workspaceID int) (int64, error)
+	// StorageBytes returns the total size of the attachments posted in the workspace.
+	StorageBytes(ctx context.Context, workspaceID int) (int64, error)
+	CountMeetings(ctx context.Context, workspaceID int) (int64, error)
+	// OldestMessageAt returns when the workspace's oldest message was sent, or nil if it has none.
+	OldestMessageAt(ctx context.Context, workspaceID int) (*time.Time, error)
+}
+
+type usageRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewUsageRepo(db *bun.DB, logger zerolog.Logger) UsageRepo {
+	return &usageRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (ur *usageRepository) CountMembers(ctx context.Context, workspaceID int) (int64, error) {
+	count, err := ur.db.NewSelect().
+		Model((*models.WorkspaceMember)(nil)).
+		Where("workspace_id = ?", workspaceID).
+		Count(ctx)
+	if err != nil {
+		ur.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to count workspace members")
+		return 0, err
+	}
+	return int64(count), nil
+}
+
+func (ur *usageRepository) StorageBytes(ctx context.Context, workspaceID int) (int64, error) {
+	var total int64
+	err := ur.db.NewSelect().
+		Model((*models.Attachment)(nil)).
+		ColumnExpr("COALESCE(SUM(a.file_size), 0)").
+		Join("JOIN messages AS msg ON msg.id = a.message_id").
+		Join("JOIN meetings AS mt ON mt.id = msg.meeting_id").
+		Join("JOIN channels AS c ON c.id = mt.channel_id").
+		Where("c.workspace_id = ?", workspaceID).
+		Scan(ctx, &total)
+	if err != nil {
+		ur.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to sum workspace storage")
+		return 0, err
+	}
+	return total, nil
+}
+
+func (ur *usageRepository) CountMeetings(ctx context.Context, workspaceID int) (int64, error) {
+	count, err := ur.db.NewSelect().
+		Model((*models.Meeting)(nil)).
+		Join("JOIN channels AS c ON c.id = m.channel_id").
+		Where("c.workspace_id = ?", workspaceID).
+		Count(ctx)
+	if err != nil {
+		ur.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to count workspace meetings")
+		return 0, err
+	}
+	return int64(count), nil
+}
+
+func (ur *usageRepository) OldestMessageAt(ctx context.Context, workspaceID int) (*time.Time, error) {
+	var oldest bun.NullTime
+	err := ur.db.NewSelect().
+		Model((*models.Message)(nil)).
+		ColumnExpr("MIN(m.created_at)").
+		Join("JOIN meetings AS mt ON mt.id = m.meeting_id").
+		Join("JOIN channels AS c ON c.id = mt.channel_id").
+		Where("c.workspace_id = ?", workspaceID).
+		Scan(ctx, &oldest)
+	if err != nil {
+		ur.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get oldest workspace message")
+		return nil, err
+	}
+	if oldest.IsZero() {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
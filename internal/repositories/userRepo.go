@@ -16,6 +16,7 @@ type UserRepo interface {
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) error
 	UpdateUser(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
 	DeleteUser(ctx context.Context, userID int) error
 }
 
@@ -61,10 +62,10 @@ func (ur *userRepository) GetUserByEmail(ctx context.Context, email string) (*mo
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ur.log.Info().Str("email", email).Msg("User not found by email.")
+			ur.log.Info().Msg("User not found by email.")
 			return nil, err
 		}
-		ur.log.Error().Err(err).Str("email", email).Msg("Failed to fetch user by email.")
+		ur.log.Error().Err(err).Msg("Failed to fetch user by email.")
 		return nil, err
 	}
 
@@ -98,7 +99,7 @@ func (ur *userRepository) CreateUser(ctx context.Context, user *models.User) err
 		Exec(ctx)
 
 	if err != nil {
-		ur.log.Error().Err(err).Str("email", user.Email).Msg("Failed to create user.")
+		ur.log.Error().Err(err).Str("username", user.Username).Msg("Failed to create user.")
 		return err
 	}
 	return nil
@@ -118,6 +119,21 @@ func (ur *userRepository) UpdateUser(ctx context.Context, user *models.User) err
 	return nil
 }
 
+func (ur *userRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	_, err := ur.db.NewUpdate().
+		Model((*models.User)(nil)).
+		Set("password = ?", passwordHash).
+		Where("id = ?", userID).
+		Exec(ctx)
+
+	if err != nil {
+		ur.log.Error().Err(err).Int("user_id", userID).Msg("Failed to update password hash.")
+		return err
+	}
+
+	return nil
+}
+
 func (ur *userRepository) DeleteUser(ctx context.Context, userID int) error {
 	_, err := ur.db.NewDelete().
 		Model(&models.User{}).
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"axis/internal/models"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+)
+
+type WebAuthnRepo interface {
+	CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	GetCredentialByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	GetCredentialsForUser(ctx context.Context, userID int) ([]models.WebAuthnCredential, error)
+	UpdateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	DeleteCredential(ctx context.Context, userID, id int) (bool, error)
+	CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error
+	ConsumeChallenge(ctx context.Context, challenge, purpose string) (*models.WebAuthnChallenge, error)
+}
+
+type webAuthnRepository struct {
+	db  *bun.DB
+	log zerolog.Logger
+}
+
+func NewWebAuthnRepo(db *bun.DB, logger zerolog.Logger) WebAuthnRepo {
+	return &webAuthnRepository{
+		db:  db,
+		log: logger,
+	}
+}
+
+func (wr *webAuthnRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	_, err := wr.db.NewInsert().Model(credential).Exec(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Int("user_id", credential.UserID).Msg("Failed to create WebAuthn credential")
+		return err
+	}
+	return nil
+}
+
+func (wr *webAuthnRepository) GetCredentialByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	credential := new(models.WebAuthnCredential)
+	err := wr.db.NewSelect().Model(credential).Where("credential_id = ?", credentialID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wr.log.Error().Err(err).Msg("Failed to get WebAuthn credential")
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (wr *webAuthnRepository) GetCredentialsForUser(ctx context.Context, userID int) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := wr.db.NewSelect().
+		Model(&credentials).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Scan(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to list WebAuthn credentials")
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (wr *webAuthnRepository) UpdateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	_, err := wr.db.NewUpdate().Model(credential).WherePK().Exec(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Int("credential_id", credential.ID).Msg("Failed to update WebAuthn credential")
+		return err
+	}
+	return nil
+}
+
+// DeleteCredential removes one of the user's credentials and reports whether it existed.
+func (wr *webAuthnRepository) DeleteCredential(ctx context.Context, userID, id int) (bool, error) {
+	res, err := wr.db.NewDelete().
+		Model((*models.WebAuthnCredential)(nil)).
+		Where("id = ?", id).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Int("credential_id", id).Msg("Failed to delete WebAuthn credential")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// CreateChallenge stores a new challenge and clears out expired ones.
+func (wr *webAuthnRepository) CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	if _, err := wr.db.NewDelete().
+		Model((*models.WebAuthnChallenge)(nil)).
+		Where("expires_at < ?", time.Now()).
+		Exec(ctx); err != nil {
+		wr.log.Warn().Err(err).Msg("Failed to delete expired WebAuthn challenges")
+	}
+
+	_, err := wr.db.NewInsert().Model(challenge).Exec(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Str("purpose", challenge.Purpose).Msg("Failed to create WebAuthn challenge")
+		return err
+	}
+	return nil
+}
+
+// ConsumeChallenge deletes and returns the matching challenge, so each one can be answered once.
+func (wr *webAuthnRepository) ConsumeChallenge(ctx context.Context, challenge, purpose string) (*models.WebAuthnChallenge, error) {
+	consumed := new(models.WebAuthnChallenge)
+	err := wr.db.NewDelete().
+		Model(consumed).
+		Where("challenge = ?", challenge).
+		Where("purpose = ?", purpose).
+		Returning("*").
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wr.log.Error().Err(err).Str("purpose", purpose).Msg("Failed to consume WebAuthn challenge")
+		return nil, err
+	}
+	return consumed, nil
+}
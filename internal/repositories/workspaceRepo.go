@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"axis/internal/models"
 	"github.com/rs/zerolog"
@@ -12,8 +13,16 @@ import (
 type WorkspaceRepo interface {
 	CreateWorkspace(ctx context.Context, workspace *models.Workspace) error
 	GetWorkspaceByID(ctx context.Context, workspaceID int) (*models.Workspace, error)
+	// GetWorkspaceBySlug returns nil when no workspace has the slug.
+	GetWorkspaceBySlug(ctx context.Context, slug string) (*models.Workspace, error)
+	GetWorkspaceSummary(ctx context.Context, workspaceID int) (*models.WorkspaceSummary, error)
+	// SearchDirectory lists the discoverable workspaces whose name or slug contains query, by name.
+	// An empty query lists them all.
+	SearchDirectory(ctx context.Context, query string, limit, offset int) ([]models.WorkspaceSummary, error)
 	UpdateWorkspace(ctx context.Context, workspace *models.Workspace) error
 	DeleteWorkspace(ctx context.Context, workspaceID int) error
+	// TransferOwnership makes toUserID the workspace's owner and fromUserID an admin, together.
+	TransferOwnership(ctx context.Context, workspaceID, fromUserID, toUserID int) error
 }
 
 type workspaceRepository struct {
@@ -68,3 +77,91 @@ func (wr *workspaceRepository) DeleteWorkspace(ctx context.Context, workspaceID
 	}
 	return nil
 }
+
+func (wr *workspaceRepository) TransferOwnership(ctx context.Context, workspaceID, fromUserID, toUserID int) error {
+	err := wr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*models.Workspace)(nil)).
+			Set("creator_id = ?", toUserID).
+			Where("id = ?", workspaceID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		for userID, role := range map[int]models.UserRole{fromUserID: models.Admin, toUserID: models.Owner} {
+			_, err = tx.NewUpdate().
+				Model((*models.WorkspaceMember)(nil)).
+				Set("role = ?", role).
+				Set("custom_role_id = NULL").
+				Where("workspace_id = ?", workspaceID).
+				Where("user_id = ?", userID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		wr.log.Error().Err(err).Int("workspace_id", workspaceID).Int("from_user_id", fromUserID).Int("to_user_id", toUserID).Msg("Failed to transfer workspace ownership")
+		return err
+	}
+	return nil
+}
+
+func (wr *workspaceRepository) GetWorkspaceBySlug(ctx context.Context, slug string) (*models.Workspace, error) {
+	workspace := new(models.Workspace)
+	err := wr.db.NewSelect().Model(workspace).Where("slug = ?", slug).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wr.log.Error().Err(err).Str("slug", slug).Msg("Failed to get workspace by slug")
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (wr *workspaceRepository) GetWorkspaceSummary(ctx context.Context, workspaceID int) (*models.WorkspaceSummary, error) {
+	summary := new(models.WorkspaceSummary)
+	err := wr.summaryQuery(summary).Where("w.id = ?", workspaceID).Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wr.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get workspace summary")
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (wr *workspaceRepository) SearchDirectory(ctx context.Context, query string, limit, offset int) ([]models.WorkspaceSummary, error) {
+	var summaries []models.WorkspaceSummary
+	q := wr.summaryQuery(&summaries).Where("w.is_discoverable")
+	if query != "" {
+		pattern := "%" + likeEscaper.Replace(query) + "%"
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("w.name ILIKE ?", pattern).WhereOr("w.slug ILIKE ?", pattern)
+		})
+	}
+	err := q.OrderExpr("lower(w.name), w.id").
+		Limit(limit).
+		Offset(offset).
+		Scan(ctx)
+	if err != nil {
+		wr.log.Error().Err(err).Str("query", query).Msg("Failed to search workspace directory")
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// summaryQuery selects workspace summaries together with their member counts.
+func (wr *workspaceRepository) summaryQuery(model any) *bun.SelectQuery {
+	return wr.db.NewSelect().
+		Model(model).
+		Column("w.id", "w.name", "w.slug", "w.description", "w.is_open", "w.allow_join_requests").
+		ColumnExpr("(SELECT count(*) FROM workspace_members AS wm WHERE wm.workspace_id = w.id) AS member_count")
+}
+
+// likeEscaper makes user input match literally inside a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
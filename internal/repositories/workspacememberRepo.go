@@ -17,6 +17,9 @@ type WorkspaceMemberRepo interface {
 	UpdateWorkspaceMemberRole(ctx context.Context, workspaceID, userID int, role models.UserRole) error
 	IsMemberOfWorkspace(ctx context.Context, workspaceID, userID int) (bool, error)
 	GetWorkspaceMember(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error)
+	// GetWorkspacePeerIDs returns the IDs of everyone sharing at least one workspace with the user,
+	// the user included when they belong to any workspace.
+	GetWorkspacePeerIDs(ctx context.Context, userID int) ([]int, error)
 }
 
 type workspaceMemberRepository struct {
@@ -133,3 +136,20 @@ func (wmr *workspaceMemberRepository) GetWorkspaceMember(ctx context.Context, wo
 	}
 	return member, nil
 }
+
+func (wmr *workspaceMemberRepository) GetWorkspacePeerIDs(ctx context.Context, userID int) ([]int, error) {
+	var userIDs []int
+	err := wmr.db.NewSelect().
+		Model((*models.WorkspaceMember)(nil)).
+		ColumnExpr("DISTINCT wm.user_id").
+		Where("wm.workspace_id IN (?)", wmr.db.NewSelect().
+			Model((*models.WorkspaceMember)(nil)).
+			Column("workspace_id").
+			Where("user_id = ?", userID)).
+		Scan(ctx, &userIDs)
+	if err != nil {
+		wmr.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get workspace peers")
+		return nil, err
+	}
+	return userIDs, nil
+}
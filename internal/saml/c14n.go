@@ -0,0 +1,118 @@
+package saml
+
+import (
+	"sort"
+	"strings"
+)
+
+// canonicalize serializes e with Exclusive XML Canonicalization without comments
+// (https://www.w3.org/TR/xml-exc-c14n/). skip, if set, is left out of the output, which is how
+// the enveloped-signature transform removes the signature itself. inclusive lists prefixes from
+// an InclusiveNamespaces PrefixList ("#default" for the default namespace).
+func canonicalize(e *element, skip *element, inclusive []string) string {
+	var b strings.Builder
+	writeCanonical(&b, e, skip, inclusive, map[string]string{})
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, e *element, skip *element, inclusive []string, rendered map[string]string) {
+	utilized := map[string]bool{e.prefix: true}
+	for _, a := range e.attrs {
+		if a.prefix != "" {
+			utilized[a.prefix] = true
+		}
+	}
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		if _, ok := e.lookupNamespace(p); ok {
+			utilized[p] = true
+		}
+	}
+
+	var prefixes []string
+	for p := range utilized {
+		if p == "xml" {
+			continue
+		}
+		uri, _ := e.lookupNamespace(p)
+		prev, seen := rendered[p]
+		if p == "" && !seen {
+			seen, prev = true, ""
+		}
+		if seen && prev == uri {
+			continue
+		}
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	childRendered := rendered
+	if len(prefixes) > 0 {
+		childRendered = make(map[string]string, len(rendered)+len(prefixes))
+		for k, v := range rendered {
+			childRendered[k] = v
+		}
+	}
+
+	name := e.local
+	if e.prefix != "" {
+		name = e.prefix + ":" + e.local
+	}
+	b.WriteString("<")
+	b.WriteString(name)
+	for _, p := range prefixes {
+		uri, _ := e.lookupNamespace(p)
+		childRendered[p] = uri
+		if p == "" {
+			b.WriteString(` xmlns="`)
+		} else {
+			b.WriteString(` xmlns:` + p + `="`)
+		}
+		b.WriteString(escapeAttr(uri))
+		b.WriteString(`"`)
+	}
+
+	attrs := append([]attribute(nil), e.attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, a := range attrs {
+		b.WriteString(" ")
+		if a.prefix != "" {
+			b.WriteString(a.prefix + ":")
+		}
+		b.WriteString(a.local)
+		b.WriteString(`="`)
+		b.WriteString(escapeAttr(a.value))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">")
+
+	for _, child := range e.children {
+		switch c := child.(type) {
+		case string:
+			b.WriteString(escapeText(c))
+		case *element:
+			if c != skip {
+				writeCanonical(b, c, skip, inclusive, childRendered)
+			}
+		}
+	}
+
+	b.WriteString("</")
+	b.WriteString(name)
+	b.WriteString(">")
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
@@ -0,0 +1,170 @@
+package saml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"encoding/xml"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// element is a parsed XML element that remembers prefixes and namespace declarations exactly as
+// written, which canonicalization needs and encoding/xml's resolved tokens do not keep.
+type element struct {
+	parent   *element
+	prefix   string
+	local    string
+	space    string
+	nsDecls  map[string]string
+	attrs    []attribute
+	children []any // *element or string
+}
+
+type attribute struct {
+	prefix string
+	local  string
+	space  string
+	value  string
+}
+
+// parseXML builds an element tree. Documents with a DOCTYPE are rejected.
+func parseXML(data []byte) (*element, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *element
+
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &element{parent: current, prefix: t.Name.Space, local: t.Name.Local, nsDecls: map[string]string{}}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					el.nsDecls[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					el.nsDecls[""] = a.Value
+				default:
+					el.attrs = append(el.attrs, attribute{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("xml: multiple root elements")
+				}
+				root = el
+			} else {
+				current.children = append(current.children, el)
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil || t.Name.Space != current.prefix || t.Name.Local != current.local {
+				return nil, errors.New("xml: mismatched end element")
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, string(t))
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, errors.New("xml: text outside root element")
+			}
+		case xml.Directive:
+			return nil, errors.New("xml: DOCTYPE and other directives are not allowed")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("xml: incomplete document")
+	}
+	if err := root.resolve(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func (e *element) resolve() error {
+	var ok bool
+	if e.space, ok = e.lookupNamespace(e.prefix); !ok {
+		return fmt.Errorf("xml: undeclared prefix %q", e.prefix)
+	}
+	for i := range e.attrs {
+		if e.attrs[i].prefix == "" {
+			continue
+		}
+		if e.attrs[i].space, ok = e.lookupNamespace(e.attrs[i].prefix); !ok {
+			return fmt.Errorf("xml: undeclared prefix %q", e.attrs[i].prefix)
+		}
+	}
+	for _, child := range e.children {
+		if c, ok := child.(*element); ok {
+			if err := c.resolve(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lookupNamespace returns the namespace bound to prefix at this element. The default namespace
+// is always bound, possibly to the empty string.
+func (e *element) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for el := e; el != nil; el = el.parent {
+		if uri, ok := el.nsDecls[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", prefix == ""
+}
+
+func (e *element) is(space, local string) bool {
+	return e.space == space && e.local == local
+}
+
+func (e *element) attr(local string) string {
+	for _, a := range e.attrs {
+		if a.space == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+func (e *element) childElements(space, local string) []*element {
+	var out []*element
+	for _, child := range e.children {
+		if c, ok := child.(*element); ok && c.is(space, local) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (e *element) child(space, local string) *element {
+	children := e.childElements(space, local)
+	if len(children) != 1 {
+		return nil
+	}
+	return children[0]
+}
+
+func (e *element) text() string {
+	var b strings.Builder
+	for _, child := range e.children {
+		if s, ok := child.(string); ok {
+			b.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
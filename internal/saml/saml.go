@@ -0,0 +1,342 @@
+// Package saml implements the service provider side of SAML 2.0 Web Browser SSO: SP metadata,
+// SP-initiated AuthnRequests over the HTTP-Redirect binding, and validation of signed responses
+// received over the HTTP-POST binding. Encrypted assertions are not supported.
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+	nsMetadata  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	bindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	bindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	statusSuccess      = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	confirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	NameIDFormatUnspecified  = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	NameIDFormatEmailAddress = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+)
+
+// clockSkew is how far the IdP's clock may drift from ours.
+const clockSkew = 3 * time.Minute
+
+// ServiceProvider describes this application to an identity provider.
+type ServiceProvider struct {
+	EntityID string
+	ACSURL   string
+}
+
+// IdentityProvider is what a workspace admin configures about their IdP.
+type IdentityProvider struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// Assertion holds the validated contents of a SAML assertion.
+type Assertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	InResponseTo string
+	SessionIndex string
+	Attributes   map[string][]string
+}
+
+// Attribute returns the first value of the named attribute.
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ParseCertificates reads one or more PEM certificates. Several may be configured so an IdP can
+// rotate its signing certificate without downtime. Bare base64 DER, as copied out of IdP
+// metadata, is accepted too.
+func ParseCertificates(data string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(strings.TrimSpace(data))
+	if !bytes.Contains(rest, []byte("-----BEGIN")) {
+		der, err := decodeBase64(string(rest))
+		if err != nil {
+			return nil, errors.New("certificate is neither PEM nor base64")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{cert}, nil
+	}
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// Metadata returns the SP's EntityDescriptor.
+func (sp *ServiceProvider) Metadata() ([]byte, error) {
+	type acs struct {
+		Binding  string `xml:"Binding,attr"`
+		Location string `xml:"Location,attr"`
+		Index    int    `xml:"index,attr"`
+	}
+	type spDescriptor struct {
+		AuthnRequestsSigned        bool     `xml:"AuthnRequestsSigned,attr"`
+		WantAssertionsSigned       bool     `xml:"WantAssertionsSigned,attr"`
+		ProtocolSupportEnumeration string   `xml:"protocolSupportEnumeration,attr"`
+		NameIDFormats              []string `xml:"md:NameIDFormat"`
+		ACS                        acs      `xml:"md:AssertionConsumerService"`
+	}
+	type entityDescriptor struct {
+		XMLName  xml.Name     `xml:"md:EntityDescriptor"`
+		NS       string       `xml:"xmlns:md,attr"`
+		EntityID string       `xml:"entityID,attr"`
+		SP       spDescriptor `xml:"md:SPSSODescriptor"`
+	}
+
+	out, err := xml.MarshalIndent(entityDescriptor{
+		NS:       nsMetadata,
+		EntityID: sp.EntityID,
+		SP: spDescriptor{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: nsProtocol,
+			NameIDFormats:              []string{NameIDFormatEmailAddress, NameIDFormatUnspecified},
+			ACS:                        acs{Binding: bindingPOST, Location: sp.ACSURL},
+		},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// AuthnRequestURL builds an AuthnRequest for idp and returns its ID, to be matched against the
+// response's InResponseTo, and the URL to redirect the browser to.
+func (sp *ServiceProvider) AuthnRequestURL(idp *IdentityProvider, relayState string) (string, string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	id := "_" + hex.EncodeToString(b)
+
+	type nameIDPolicy struct {
+		AllowCreate bool   `xml:"AllowCreate,attr"`
+		Format      string `xml:"Format,attr"`
+	}
+	type authnRequest struct {
+		XMLName                     xml.Name     `xml:"samlp:AuthnRequest"`
+		NSP                         string       `xml:"xmlns:samlp,attr"`
+		NSA                         string       `xml:"xmlns:saml,attr"`
+		ID                          string       `xml:"ID,attr"`
+		Version                     string       `xml:"Version,attr"`
+		IssueInstant                string       `xml:"IssueInstant,attr"`
+		Destination                 string       `xml:"Destination,attr"`
+		AssertionConsumerServiceURL string       `xml:"AssertionConsumerServiceURL,attr"`
+		ProtocolBinding             string       `xml:"ProtocolBinding,attr"`
+		Issuer                      string       `xml:"saml:Issuer"`
+		NameIDPolicy                nameIDPolicy `xml:"samlp:NameIDPolicy"`
+	}
+
+	raw, err := xml.Marshal(authnRequest{
+		NSP:                         nsProtocol,
+		NSA:                         nsAssertion,
+		ID:                          id,
+		Version:                     "2.0",
+		IssueInstant:                time.Now().UTC().Format(time.RFC3339),
+		Destination:                 idp.SSOURL,
+		AssertionConsumerServiceURL: sp.ACSURL,
+		ProtocolBinding:             bindingPOST,
+		Issuer:                      sp.EntityID,
+		NameIDPolicy:                nameIDPolicy{AllowCreate: true, Format: NameIDFormatUnspecified},
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	var deflated bytes.Buffer
+	w, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	w.Write(raw)
+	w.Close()
+
+	q := url.Values{}
+	q.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		q.Set("RelayState", relayState)
+	}
+	sep := "?"
+	if strings.Contains(idp.SSOURL, "?") {
+		sep = "&"
+	}
+	return id, idp.SSOURL + sep + q.Encode(), nil
+}
+
+// ParseResponse validates a base64 encoded SAMLResponse posted to the ACS URL. Either the
+// response or its single assertion must carry a valid signature from one of idp's certificates,
+// and only data from inside the signed element is used. The caller must still check that
+// InResponseTo matches a request it issued.
+func (sp *ServiceProvider) ParseResponse(idp *IdentityProvider, samlResponse string, now time.Time) (*Assertion, error) {
+	raw, err := decodeBase64(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SAMLResponse encoding: %w", err)
+	}
+	root, err := parseXML(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !root.is(nsProtocol, "Response") {
+		return nil, errors.New("document is not a SAML response")
+	}
+
+	if dest := root.attr("Destination"); dest != "" && dest != sp.ACSURL {
+		return nil, fmt.Errorf("response is addressed to %q", dest)
+	}
+	status := root.child(nsProtocol, "Status")
+	if status == nil {
+		return nil, errors.New("response has no status")
+	}
+	if code := status.child(nsProtocol, "StatusCode"); code == nil || code.attr("Value") != statusSuccess {
+		return nil, errors.New("identity provider did not report success")
+	}
+	if len(root.childElements(nsAssertion, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertions are not supported")
+	}
+	assertions := root.childElements(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("response must contain exactly one assertion")
+	}
+	assertion := assertions[0]
+
+	responseErr := verifyEnvelopedSignature(root, idp.Certificates)
+	if responseErr != nil && responseErr != errNoSignature {
+		return nil, fmt.Errorf("invalid response signature: %w", responseErr)
+	}
+	assertionErr := verifyEnvelopedSignature(assertion, idp.Certificates)
+	if assertionErr != nil && assertionErr != errNoSignature {
+		return nil, fmt.Errorf("invalid assertion signature: %w", assertionErr)
+	}
+	if responseErr == errNoSignature && assertionErr == errNoSignature {
+		return nil, errors.New("neither the response nor the assertion is signed")
+	}
+
+	return sp.validateAssertion(idp, assertion, now)
+}
+
+func (sp *ServiceProvider) validateAssertion(idp *IdentityProvider, a *element, now time.Time) (*Assertion, error) {
+	issuer := a.child(nsAssertion, "Issuer")
+	if issuer == nil || issuer.text() != idp.EntityID {
+		return nil, errors.New("assertion was issued by an unexpected party")
+	}
+
+	result := &Assertion{ID: a.attr("ID"), Issuer: issuer.text(), Attributes: map[string][]string{}}
+
+	subject := a.child(nsAssertion, "Subject")
+	if subject == nil {
+		return nil, errors.New("assertion has no subject")
+	}
+	nameID := subject.child(nsAssertion, "NameID")
+	if nameID == nil || nameID.text() == "" {
+		return nil, errors.New("assertion has no NameID")
+	}
+	result.NameID = nameID.text()
+	result.NameIDFormat = nameID.attr("Format")
+
+	confirmed := false
+	for _, sc := range subject.childElements(nsAssertion, "SubjectConfirmation") {
+		if sc.attr("Method") != confirmationBearer {
+			continue
+		}
+		data := sc.child(nsAssertion, "SubjectConfirmationData")
+		if data == nil || data.attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		notOnOrAfter, err := parseTime(data.attr("NotOnOrAfter"))
+		if err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+			continue
+		}
+		result.InResponseTo = data.attr("InResponseTo")
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, errors.New("assertion has no valid bearer subject confirmation for this service provider")
+	}
+
+	if conditions := a.child(nsAssertion, "Conditions"); conditions != nil {
+		if v := conditions.attr("NotBefore"); v != "" {
+			notBefore, err := parseTime(v)
+			if err != nil || now.Add(clockSkew).Before(notBefore) {
+				return nil, errors.New("assertion is not yet valid")
+			}
+		}
+		if v := conditions.attr("NotOnOrAfter"); v != "" {
+			notOnOrAfter, err := parseTime(v)
+			if err != nil || !now.Before(notOnOrAfter.Add(clockSkew)) {
+				return nil, errors.New("assertion has expired")
+			}
+		}
+		for _, restriction := range conditions.childElements(nsAssertion, "AudienceRestriction") {
+			allowed := false
+			for _, audience := range restriction.childElements(nsAssertion, "Audience") {
+				if audience.text() == sp.EntityID {
+					allowed = true
+				}
+			}
+			if !allowed {
+				return nil, errors.New("assertion is intended for another audience")
+			}
+		}
+	}
+
+	if authn := a.childElements(nsAssertion, "AuthnStatement"); len(authn) > 0 {
+		result.SessionIndex = authn[0].attr("SessionIndex")
+	}
+	for _, statement := range a.childElements(nsAssertion, "AttributeStatement") {
+		for _, attr := range statement.childElements(nsAssertion, "Attribute") {
+			name := attr.attr("Name")
+			for _, v := range attr.childElements(nsAssertion, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], v.text())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, v)
+}
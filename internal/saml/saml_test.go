@@ -0,0 +1,166 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalizeExclusive(t *testing.T) {
+	root, err := parseXML([]byte(`<root xmlns="urn:a" xmlns:x="urn:x" xmlns:unused="urn:u"><x:child b="2" a="1" x:c="3">t&amp;&lt;&gt;</x:child><empty/></root>`))
+	if err != nil {
+		t.Fatalf("parseXML returned error: %v", err)
+	}
+
+	want := `<x:child xmlns:x="urn:x" a="1" b="2" x:c="3">t&amp;&lt;&gt;</x:child>`
+	if got := canonicalize(root.children[0].(*element), nil, nil); got != want {
+		t.Errorf("canonicalize(child) =\n%s\nwant\n%s", got, want)
+	}
+
+	want = `<root xmlns="urn:a"><x:child xmlns:x="urn:x" a="1" b="2" x:c="3">t&amp;&lt;&gt;</x:child><empty></empty></root>`
+	if got := canonicalize(root, nil, nil); got != want {
+		t.Errorf("canonicalize(root) =\n%s\nwant\n%s", got, want)
+	}
+
+	want = `<x:child xmlns="urn:a" xmlns:x="urn:x" a="1" b="2" x:c="3">t&amp;&lt;&gt;</x:child>`
+	if got := canonicalize(root.children[0].(*element), nil, []string{"#default"}); got != want {
+		t.Errorf("canonicalize(child, #default) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseXMLRejectsDoctype(t *testing.T) {
+	if _, err := parseXML([]byte(`<!DOCTYPE r [<!ENTITY e "x">]><r>&e;</r>`)); err == nil {
+		t.Fatal("expected a document with a DOCTYPE to be rejected")
+	}
+}
+
+type testIdP struct {
+	t    *testing.T
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testIdP{t: t, key: key, cert: cert}
+}
+
+// signedResponse returns a SAMLResponse whose assertion carries an enveloped signature.
+func (idp *testIdP) signedResponse(sp *ServiceProvider, inResponseTo, audience string, notOnOrAfter time.Time) string {
+	until := notOnOrAfter.UTC().Format(time.RFC3339)
+	assertion := `<saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_a1" Version="2.0" IssueInstant="` + time.Now().UTC().Format(time.RFC3339) + `">` +
+		`<saml:Issuer>https://idp.example.com</saml:Issuer>` +
+		`{{SIGNATURE}}` +
+		`<saml:Subject><saml:NameID Format="` + NameIDFormatEmailAddress + `">jane@example.com</saml:NameID>` +
+		`<saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">` +
+		`<saml:SubjectConfirmationData InResponseTo="` + inResponseTo + `" NotOnOrAfter="` + until + `" Recipient="` + sp.ACSURL + `"/>` +
+		`</saml:SubjectConfirmation></saml:Subject>` +
+		`<saml:Conditions NotOnOrAfter="` + until + `"><saml:AudienceRestriction><saml:Audience>` + audience + `</saml:Audience></saml:AudienceRestriction></saml:Conditions>` +
+		`<saml:AuthnStatement SessionIndex="s1"/>` +
+		`<saml:AttributeStatement><saml:Attribute Name="displayName"><saml:AttributeValue>Jane Doe</saml:AttributeValue></saml:Attribute></saml:AttributeStatement>` +
+		`</saml:Assertion>`
+
+	unsigned, err := parseXML([]byte(strings.Replace(assertion, "{{SIGNATURE}}", "", 1)))
+	if err != nil {
+		idp.t.Fatalf("failed to parse assertion: %v", err)
+	}
+	digest := sha256.Sum256([]byte(canonicalize(unsigned, nil, nil)))
+
+	signature := `<ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + algExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + algRSASHA256 + `"/>` +
+		`<ds:Reference URI="#_a1"><ds:Transforms><ds:Transform Algorithm="` + algEnveloped + `"/><ds:Transform Algorithm="` + algExcC14N + `"/></ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + algDigestSHA256 + `"/><ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue></ds:Reference>` +
+		`</ds:SignedInfo><ds:SignatureValue>{{VALUE}}</ds:SignatureValue></ds:Signature>`
+	withSignature := strings.Replace(assertion, "{{SIGNATURE}}", signature, 1)
+
+	parsed, err := parseXML([]byte(withSignature))
+	if err != nil {
+		idp.t.Fatalf("failed to parse signed assertion: %v", err)
+	}
+	signedInfo := parsed.child(nsDSig, "Signature").child(nsDSig, "SignedInfo")
+	hashed := sha256.Sum256([]byte(canonicalize(signedInfo, nil, nil)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		idp.t.Fatalf("failed to sign: %v", err)
+	}
+	withSignature = strings.Replace(withSignature, "{{VALUE}}", base64.StdEncoding.EncodeToString(sig), 1)
+
+	response := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_r1" Version="2.0" Destination="` + sp.ACSURL + `">` +
+		`<samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>` +
+		withSignature + `</samlp:Response>`
+	return base64.StdEncoding.EncodeToString([]byte(response))
+}
+
+func testSetup(t *testing.T) (*ServiceProvider, *IdentityProvider, *testIdP) {
+	signer := newTestIdP(t)
+	sp := &ServiceProvider{EntityID: "https://axis.example.com/saml/1", ACSURL: "https://axis.example.com/saml/1/acs"}
+	idp := &IdentityProvider{EntityID: "https://idp.example.com", SSOURL: "https://idp.example.com/sso", Certificates: []*x509.Certificate{signer.cert}}
+	return sp, idp, signer
+}
+
+func TestParseResponseAcceptsSignedAssertion(t *testing.T) {
+	sp, idp, signer := testSetup(t)
+
+	assertion, err := sp.ParseResponse(idp, signer.signedResponse(sp, "_req1", sp.EntityID, time.Now().Add(5*time.Minute)), time.Now())
+	if err != nil {
+		t.Fatalf("ParseResponse returned error: %v", err)
+	}
+	if assertion.NameID != "jane@example.com" || assertion.InResponseTo != "_req1" || assertion.Attribute("displayName") != "Jane Doe" {
+		t.Fatalf("unexpected assertion: %+v", assertion)
+	}
+}
+
+func TestParseResponseRejectsInvalidResponses(t *testing.T) {
+	sp, idp, signer := testSetup(t)
+	valid := signer.signedResponse(sp, "_req1", sp.EntityID, time.Now().Add(5*time.Minute))
+	raw, _ := base64.StdEncoding.DecodeString(valid)
+
+	tampered := base64.StdEncoding.EncodeToString([]byte(strings.Replace(string(raw), "Jane Doe", "Mallory", 1)))
+	if _, err := sp.ParseResponse(idp, tampered, time.Now()); err == nil {
+		t.Error("expected a modified assertion to be rejected")
+	}
+
+	otherIdP := newTestIdP(t)
+	untrusted := &IdentityProvider{EntityID: idp.EntityID, SSOURL: idp.SSOURL, Certificates: []*x509.Certificate{otherIdP.cert}}
+	if _, err := sp.ParseResponse(untrusted, valid, time.Now()); err == nil {
+		t.Error("expected a signature from an untrusted certificate to be rejected")
+	}
+
+	if _, err := sp.ParseResponse(idp, signer.signedResponse(sp, "_req1", "https://other.example.com", time.Now().Add(5*time.Minute)), time.Now()); err == nil {
+		t.Error("expected an assertion for another audience to be rejected")
+	}
+
+	if _, err := sp.ParseResponse(idp, signer.signedResponse(sp, "_req1", sp.EntityID, time.Now().Add(-10*time.Minute)), time.Now()); err == nil {
+		t.Error("expected an expired assertion to be rejected")
+	}
+
+	start := strings.Index(string(raw), "<ds:Signature")
+	end := strings.Index(string(raw), "</ds:Signature>") + len("</ds:Signature>")
+	unsigned := base64.StdEncoding.EncodeToString([]byte(string(raw[:start]) + string(raw[end:])))
+	if _, err := sp.ParseResponse(idp, unsigned, time.Now()); err == nil {
+		t.Error("expected an unsigned response to be rejected")
+	}
+}
@@ -0,0 +1,171 @@
+package saml
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+)
+
+const (
+	nsDSig    = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC14N = "http://www.w3.org/2001/10/xml-exc-c14n#"
+
+	algExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnveloped    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algRSASHA256    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRSASHA512    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	algECDSASHA256  = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	algDigestSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algDigestSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+)
+
+var errNoSignature = errors.New("element is not signed")
+
+// verifyEnvelopedSignature checks the XML signature that is a direct child of e and covers e
+// itself. Only the certificates the workspace configured are trusted; any KeyInfo in the
+// document is ignored. SHA-1 based algorithms are refused.
+func verifyEnvelopedSignature(e *element, certs []*x509.Certificate) error {
+	signatures := e.childElements(nsDSig, "Signature")
+	if len(signatures) == 0 {
+		return errNoSignature
+	}
+	if len(signatures) > 1 {
+		return errors.New("element has more than one signature")
+	}
+	sig := signatures[0]
+
+	signedInfo := sig.child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("signature has no SignedInfo")
+	}
+
+	c14nMethod := signedInfo.child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.attr("Algorithm") != algExcC14N {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	sigMethod := signedInfo.child(nsDSig, "SignatureMethod")
+	if sigMethod == nil {
+		return errors.New("signature has no SignatureMethod")
+	}
+
+	references := signedInfo.childElements(nsDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("signature must have exactly one reference")
+	}
+	ref := references[0]
+	id := e.attr("ID")
+	if id == "" || ref.attr("URI") != "#"+id {
+		return errors.New("signature does not reference the signed element")
+	}
+
+	var refInclusive []string
+	if transforms := ref.child(nsDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.childElements(nsDSig, "Transform") {
+			switch t.attr("Algorithm") {
+			case algEnveloped:
+			case algExcC14N:
+				refInclusive = inclusivePrefixes(t)
+			default:
+				return fmt.Errorf("unsupported transform %q", t.attr("Algorithm"))
+			}
+		}
+	}
+
+	digestMethod := ref.child(nsDSig, "DigestMethod")
+	digestValue := ref.child(nsDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return errors.New("reference has no digest")
+	}
+	var h hash.Hash
+	switch digestMethod.attr("Algorithm") {
+	case algDigestSHA256:
+		h = sha256.New()
+	case algDigestSHA512:
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported digest method %q", digestMethod.attr("Algorithm"))
+	}
+	h.Write([]byte(canonicalize(e, sig, refInclusive)))
+	expectedDigest, err := decodeBase64(digestValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid digest value: %w", err)
+	}
+	if subtle.ConstantTimeCompare(h.Sum(nil), expectedDigest) != 1 {
+		return errors.New("digest mismatch; the signed content was modified")
+	}
+
+	sigValue := sig.child(nsDSig, "SignatureValue")
+	if sigValue == nil {
+		return errors.New("signature has no SignatureValue")
+	}
+	rawSig, err := decodeBase64(sigValue.text())
+	if err != nil {
+		return fmt.Errorf("invalid signature value: %w", err)
+	}
+	signed := []byte(canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod)))
+
+	for _, cert := range certs {
+		if err := verifyWithKey(sigMethod.attr("Algorithm"), cert.PublicKey, signed, rawSig); err == nil {
+			return nil
+		}
+	}
+	return errors.New("signature was not made by a trusted certificate")
+}
+
+func verifyWithKey(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	switch alg {
+	case algRSASHA256, algRSASHA512:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("certificate key is not RSA")
+		}
+		if alg == algRSASHA256 {
+			digest := sha256.Sum256(signed)
+			return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig)
+		}
+		digest := sha512.Sum512(signed)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA512, digest[:], sig)
+	case algECDSASHA256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("certificate key is not ECDSA")
+		}
+		digest := sha256.Sum256(signed)
+		if ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return nil
+		}
+		// XML-DSig encodes ECDSA signatures as r||s rather than ASN.1.
+		if len(sig)%2 == 0 {
+			r, s := sig[:len(sig)/2], sig[len(sig)/2:]
+			if ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(r), new(big.Int).SetBytes(s)) {
+				return nil
+			}
+		}
+		return errors.New("invalid signature")
+	default:
+		return fmt.Errorf("unsupported signature method %q", alg)
+	}
+}
+
+func inclusivePrefixes(e *element) []string {
+	in := e.child(nsExcC14N, "InclusiveNamespaces")
+	if in == nil {
+		return nil
+	}
+	return strings.Fields(in.attr("PrefixList"))
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
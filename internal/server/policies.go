@@ -0,0 +1,186 @@
+package server
+
+import (
+	"axis/internal/middlewares"
+	"axis/internal/models"
+)
+
+// Shorthands for the policies most routes share.
+var (
+	public = middlewares.Policy{Public: true}
+	// signedIn routes act on the caller's own data, or check what the caller may do themselves.
+	signedIn = middlewares.Policy{}
+)
+
+func member(resource models.ResourceType, param string) middlewares.Policy {
+	return middlewares.Policy{Resource: resource, Param: param}
+}
+
+func permitted(resource models.ResourceType, param string, permission models.Permission) middlewares.Policy {
+	return middlewares.Policy{Resource: resource, Param: param, Permission: permission}
+}
+
+func scoped(scope models.APIScope, policy middlewares.Policy) middlewares.Policy {
+	policy.Scope = scope
+	return policy
+}
+
+// routePolicies says who may call each route RegisterRoutes registers. Routes that are missing
+// here are refused. Where a policy only asks for membership, the service makes the finer checks,
+// such as letting the creator of a channel edit it.
+var routePolicies = middlewares.Policies{
+	"GET /":                      public,
+	"GET /health":                public,
+	"GET /.well-known/jwks.json": public,
+
+	// Users
+	"POST /api/register":                          public,
+	"POST /api/login":                             public,
+	"POST /api/login/link":                        public,
+	"POST /api/login/link/verify":                 public,
+	"POST /api/login/2fa":                         public,
+	"POST /api/token/refresh":                     public,
+	"POST /api/logout":                            signedIn,
+	"POST /api/verify-email":                      public,
+	"POST /api/verify-email/resend":               signedIn,
+	"POST /api/password/forgot":                   public,
+	"POST /api/password/reset":                    public,
+	"GET /api/password/policy":                    public,
+	"PUT /api/password":                           signedIn,
+	"GET /api/users":                              scoped(models.ScopeUsersRead, signedIn),
+	"GET /api/users/by-email":                     signedIn,
+	"GET /api/users/by-username":                  signedIn,
+	"PUT /api/users":                              signedIn,
+	"DELETE /api/users":                           signedIn,
+	"PUT /api/users/avatar":                       signedIn,
+	"DELETE /api/users/avatar":                    signedIn,
+	"GET /api/users/:userID/avatar":               public,
+	"GET /api/users/:userID/status":               signedIn,
+	"PUT /api/users/status":                       signedIn,
+	"DELETE /api/users/status":                    signedIn,
+	"PUT /api/users/dnd":                          signedIn,
+	"DELETE /api/users/dnd":                       signedIn,
+	"PUT /api/users/dnd/schedule":                 signedIn,
+	"DELETE /api/users/dnd/schedule":              signedIn,
+	"GET /api/presence":                           signedIn,
+	"POST /api/account/deactivate":                signedIn,
+	"POST /api/account/reactivate":                public,
+	"GET /api/account/exports":                    signedIn,
+	"POST /api/account/exports":                   signedIn,
+	"GET /api/account/exports/:exportID/download": signedIn,
+
+	// Sessions, tokens and second factors
+	"GET /api/sessions":                              signedIn,
+	"DELETE /api/sessions":                           signedIn,
+	"DELETE /api/sessions/:sessionID":                signedIn,
+	"GET /api/tokens":                                signedIn,
+	"POST /api/tokens":                               signedIn,
+	"DELETE /api/tokens/:tokenID":                    signedIn,
+	"GET /api/tokens/scopes":                         public,
+	"POST /api/2fa/setup":                            signedIn,
+	"POST /api/2fa/enable":                           signedIn,
+	"POST /api/2fa/disable":                          signedIn,
+	"POST /api/2fa/recovery-codes":                   signedIn,
+	"POST /api/webauthn/register/begin":              signedIn,
+	"POST /api/webauthn/register/finish":             signedIn,
+	"POST /api/webauthn/login/begin":                 public,
+	"POST /api/webauthn/login/finish":                public,
+	"GET /api/webauthn/credentials":                  signedIn,
+	"DELETE /api/webauthn/credentials/:credentialID": signedIn,
+
+	// Workspaces
+	"POST /api/workspaces":                scoped(models.ScopeWorkspacesWrite, signedIn),
+	"GET /api/workspaces/:workspaceID":    scoped(models.ScopeWorkspacesRead, member(models.ResourceWorkspace, "workspaceID")),
+	"PUT /api/workspaces/:workspaceID":    scoped(models.ScopeWorkspacesWrite, permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceUpdate)),
+	"DELETE /api/workspaces/:workspaceID": scoped(models.ScopeWorkspacesWrite, permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceDelete)),
+	"GET /api/workspaces":                 scoped(models.ScopeWorkspacesRead, signedIn),
+	// Who may find which workspace is up to the service.
+	"GET /api/workspaces/by-slug/:slug":          scoped(models.ScopeWorkspacesRead, signedIn),
+	"GET /api/directory":                         scoped(models.ScopeWorkspacesRead, signedIn),
+	"GET /api/workspaces/:workspaceID/usage":     permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceUsage),
+	"GET /api/workspaces/:workspaceID/audit-log": permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceAudit),
+	// Only the owner may transfer ownership, which the service checks.
+	"POST /api/workspaces/:workspaceID/transfer-ownership": member(models.ResourceWorkspace, "workspaceID"),
+
+	// Single sign-on
+	"GET /api/workspaces/:workspaceID/sso/oidc":        permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"PUT /api/workspaces/:workspaceID/sso/oidc":        permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"DELETE /api/workspaces/:workspaceID/sso/oidc":     permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"POST /api/workspaces/:workspaceID/sso/oidc/start": public,
+	"POST /api/workspaces/:workspaceID/sso/oidc/link":  signedIn,
+	"POST /api/sso/oidc/callback":                      public,
+	"GET /api/workspaces/:workspaceID/sso/saml":        permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"PUT /api/workspaces/:workspaceID/sso/saml":        permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"DELETE /api/workspaces/:workspaceID/sso/saml":     permitted(models.ResourceWorkspace, "workspaceID", models.PermWorkspaceSSO),
+	"POST /api/workspaces/:workspaceID/sso/saml/start": public,
+	"POST /api/workspaces/:workspaceID/sso/saml/link":  signedIn,
+	"GET /api/sso/saml/:workspaceID/metadata":          public,
+	"POST /api/sso/saml/:workspaceID/acs":              public,
+	"POST /api/sso/exchange":                           public,
+
+	// Workspace members, join requests and invitations. Joining is for people who are not members
+	// yet, and anyone may leave, so those only need a login.
+	"POST /api/workspaces/:workspaceID/members":                          permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberInvite),
+	"DELETE /api/workspaces/:workspaceID/members/:userID":                signedIn,
+	"GET /api/workspaces/:workspaceID/members":                           member(models.ResourceWorkspace, "workspaceID"),
+	"POST /api/workspaces/:workspaceID/join":                             signedIn,
+	"POST /api/workspaces/:workspaceID/members/:userID/unlock":           permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberUnlock),
+	"POST /api/workspaces/:workspaceID/join-requests":                    signedIn,
+	"GET /api/workspaces/:workspaceID/join-requests":                     permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberApprove),
+	"POST /api/workspaces/:workspaceID/join-requests/:requestID/approve": permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberApprove),
+	"POST /api/workspaces/:workspaceID/join-requests/:requestID/deny":    permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberApprove),
+	"POST /api/workspaces/:workspaceID/invitations":                      permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberInvite),
+	"GET /api/workspaces/:workspaceID/invitations":                       permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberInvite),
+	"DELETE /api/workspaces/:workspaceID/invitations/:invitationID":      permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberInvite),
+	"POST /api/invitations/preview":                                      public,
+	"POST /api/invitations/accept":                                       signedIn,
+
+	// Roles and permissions
+	"GET /api/permissions":                                      public,
+	"GET /api/workspaces/:workspaceID/permissions":              member(models.ResourceWorkspace, "workspaceID"),
+	"GET /api/workspaces/:workspaceID/roles":                    member(models.ResourceWorkspace, "workspaceID"),
+	"POST /api/workspaces/:workspaceID/roles":                   permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+	"PUT /api/workspaces/:workspaceID/roles/:roleID":            permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+	"DELETE /api/workspaces/:workspaceID/roles/:roleID":         permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+	"PUT /api/workspaces/:workspaceID/members/:userID/role":     permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+	"POST /api/workspaces/:workspaceID/members/:userID/promote": permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+	"POST /api/workspaces/:workspaceID/members/:userID/demote":  permitted(models.ResourceWorkspace, "workspaceID", models.PermMemberRoles),
+
+	// Channels
+	"POST /api/channels":                        scoped(models.ScopeChannelsWrite, middlewares.Policy{Resource: models.ResourceWorkspace, Field: "workspace_id", Permission: models.PermChannelCreate}),
+	"GET /api/channels/:channelID":              scoped(models.ScopeChannelsRead, member(models.ResourceChannel, "channelID")),
+	"PUT /api/channels/:channelID":              scoped(models.ScopeChannelsWrite, member(models.ResourceChannel, "channelID")),
+	"DELETE /api/channels/:channelID":           scoped(models.ScopeChannelsWrite, member(models.ResourceChannel, "channelID")),
+	"GET /api/workspaces/:workspaceID/channels": scoped(models.ScopeChannelsRead, member(models.ResourceWorkspace, "workspaceID")),
+	// Managing a channel's members needs its creator or channel.update, like editing it, which
+	// the service checks. Anyone can leave.
+	"POST /api/channels/:channelID/members":           member(models.ResourceChannel, "channelID"),
+	"DELETE /api/channels/:channelID/members/:userID": member(models.ResourceChannel, "channelID"),
+	"GET /api/channels/:channelID/members":            member(models.ResourceChannel, "channelID"),
+
+	// Meetings
+	"POST /api/meetings":                                          scoped(models.ScopeMeetingsWrite, middlewares.Policy{Resource: models.ResourceChannel, Field: "channel_id", Permission: models.PermMeetingCreate}),
+	"GET /api/meetings/:meetingID":                                scoped(models.ScopeMeetingsRead, member(models.ResourceMeeting, "meetingID")),
+	"PUT /api/meetings/:meetingID":                                scoped(models.ScopeMeetingsWrite, member(models.ResourceMeeting, "meetingID")),
+	"DELETE /api/meetings/:meetingID":                             scoped(models.ScopeMeetingsWrite, member(models.ResourceMeeting, "meetingID")),
+	"GET /api/channels/:channelID/meetings":                       scoped(models.ScopeMeetingsRead, member(models.ResourceChannel, "channelID")),
+	"POST /api/meetings/:meetingID/participants":                  scoped(models.ScopeMeetingsWrite, member(models.ResourceMeeting, "meetingID")),
+	"DELETE /api/meetings/:meetingID/participants/:participantID": scoped(models.ScopeMeetingsWrite, member(models.ResourceMeeting, "meetingID")),
+
+	// Messages, attachments and reactions
+	"POST /api/messages":                                       scoped(models.ScopeMessagesWrite, middlewares.Policy{Resource: models.ResourceMeeting, Field: "meeting_id"}),
+	"GET /api/messages/:messageID":                             scoped(models.ScopeMessagesRead, member(models.ResourceMessage, "messageID")),
+	"PUT /api/messages/:messageID":                             scoped(models.ScopeMessagesWrite, member(models.ResourceMessage, "messageID")),
+	"DELETE /api/messages/:messageID":                          scoped(models.ScopeMessagesWrite, member(models.ResourceMessage, "messageID")),
+	"GET /api/meetings/:meetingID/messages":                    scoped(models.ScopeMessagesRead, member(models.ResourceMeeting, "meetingID")),
+	"POST /api/attachments":                                    middlewares.Policy{Resource: models.ResourceMessage, Field: "message_id"},
+	"GET /api/attachments/:attachmentID":                       member(models.ResourceAttachment, "attachmentID"),
+	"GET /api/messages/:messageID/attachments":                 member(models.ResourceMessage, "messageID"),
+	"POST /api/messages/:messageID/reactions":                  member(models.ResourceMessage, "messageID"),
+	"DELETE /api/messages/:messageID/reactions/:userID/:emoji": member(models.ResourceMessage, "messageID"),
+	"GET /api/messages/:messageID/reactions":                   member(models.ResourceMessage, "messageID"),
+
+	// WebSockets. What can be sent over a meeting chat is checked per message by the chat service.
+	"GET /ws/meeting/:meeting_id/chat": member(models.ResourceMeeting, "meeting_id"),
+	"GET /ws/events":                   signedIn,
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"axis/internal/middlewares"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// unconnectedDB lets RegisterRoutes build its repositories without a database. Nothing connects
+// until a query runs.
+type unconnectedDB struct{}
+
+func (unconnectedDB) Health() map[string]string { return nil }
+func (unconnectedDB) Close() error              { return nil }
+func (unconnectedDB) GetDB() *bun.DB {
+	return bun.NewDB(sql.OpenDB(pgdriver.NewConnector()), pgdialect.New())
+}
+
+func TestEveryRouteHasPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &Server{db: unconnectedDB{}, log: zerolog.Nop()}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		key := middlewares.PolicyKey(route.Method, route.Path)
+		registered[key] = true
+		if _, ok := routePolicies[key]; !ok {
+			t.Errorf("%s has no entry in routePolicies", key)
+		}
+	}
+	for key := range routePolicies {
+		if !registered[key] {
+			t.Errorf("routePolicies has an entry for %s, which is not registered", key)
+		}
+	}
+}
+
+func TestAuthorizeRefusesRoutesWithoutPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policies := middlewares.Policies{"GET /open": {Public: true}}
+	r := gin.New()
+	r.Use(middlewares.Authorize(zerolog.Nop(), policies, nil, nil, nil))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/open", ok)
+	r.GET("/forgotten", ok)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/open", want: http.StatusOK},
+		{path: "/forgotten", want: http.StatusForbidden},
+		{path: "/missing", want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rr.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, rr.Code, tt.want)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package server
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"time"
 
 
 	"axis/internal/handlers"
+	"axis/internal/mailer"
 	"axis/internal/middlewares"
+	"axis/internal/oidc"
 	"axis/internal/repositories"
 	"axis/internal/services"
+	"axis/internal/utils"
+	"axis/internal/webauthn"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -19,13 +26,10 @@ func (s *Server) RegisterRoutes() http.Handler {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:5173"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", utils.CSRFHeader, utils.SessionModeHeader},
 		AllowCredentials: true,
 	}))
 
-	r.GET("/", s.HelloWorldHandler)
-	r.GET("/health", s.healthHandler)
-
 	// Access the bun.DB from the database service
 	bunDB := s.db.GetDB()
 
@@ -36,21 +40,63 @@ func (s *Server) RegisterRoutes() http.Handler {
 	messageRepo := repositories.NewMessageRepo(bunDB, s.log)
 	meetingRepo := repositories.NewMeetingRepo(bunDB, s.log)
 	reactionRepo := repositories.NewReactionRepo(bunDB, s.log)
+	sessionRepo := repositories.NewSessionRepo(bunDB, s.log)
+	apiTokenRepo := repositories.NewAPITokenRepo(bunDB, s.log)
+	loginAttemptStore := repositories.NewLoginAttemptStore(bunDB, s.log)
+	securityEventRepo := repositories.NewSecurityEventRepo(bunDB, s.log)
+	passwordResetRepo := repositories.NewPasswordResetRepo(bunDB, s.log)
+	loginLinkRepo := repositories.NewLoginLinkRepo(bunDB, s.log)
+	dataExportRepo := repositories.NewDataExportRepo(bunDB, s.log)
+	erasureRepo := repositories.NewErasureRepo(bunDB, s.log)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepo(bunDB, s.log)
+	webAuthnRepo := repositories.NewWebAuthnRepo(bunDB, s.log)
+	ssoRepo := repositories.NewSSORepo(bunDB, s.log)
 	userRepo := repositories.NewUserRepo(bunDB, s.log)
+	avatarRepo := repositories.NewAvatarRepo(bunDB, s.log)
+	statusRepo := repositories.NewStatusRepo(bunDB, s.log)
 	workspaceMemberRepo := repositories.NewWorkspaceMemberRepo(bunDB, s.log)
 	workspaceRepo := repositories.NewWorkspaceRepo(bunDB, s.log)
+	invitationRepo := repositories.NewInvitationRepo(bunDB, s.log)
+	joinRequestRepo := repositories.NewJoinRequestRepo(bunDB, s.log)
+	usageRepo := repositories.NewUsageRepo(bunDB, s.log)
+	roleRepo := repositories.NewRoleRepo(bunDB, s.log)
+	auditRepo := repositories.NewAuditRepo(bunDB, s.log)
+
+	// --- Mailer ---
+	mail := mailer.New(s.log)
+
+	passwordPolicy, err := services.LoadPasswordPolicy()
+	if err != nil {
+		s.log.Fatal().Err(err).Msg("Invalid password policy")
+	}
 
 	// --- Services ---
-	attachmentService := services.NewAttachmentService(attachmentRepo, s.log)
-	channelMemberService := services.NewChannelMemberService(channelMemberRepo, s.log)
-	channelService := services.NewChannelService(channelRepo, channelMemberRepo, workspaceMemberRepo, s.log)
-	messageService := services.NewMessageService(messageRepo, meetingRepo, s.log)
-	reactionService := services.NewReactionService(reactionRepo, s.log)
-	userService := services.NewUserService(userRepo, s.log)
-	meetingService := services.NewMeetingService(meetingRepo, channelRepo, userRepo, channelMemberRepo, s.log)
-	workspaceMemberService := services.NewWorkspaceMemberService(workspaceMemberRepo, workspaceRepo, s.log)
-	workspaceService := services.NewWorkspaceService(workspaceRepo, workspaceMemberRepo, s.log)
-	meetingChatService := services.NewMeetingChatService(meetingRepo, messageRepo, userRepo, attachmentRepo, reactionRepo, s.log) // Initialize MeetingChatService
+	authorizer := services.NewAuthorizer(workspaceRepo, workspaceMemberRepo, roleRepo, messageRepo, meetingRepo, channelRepo, attachmentRepo, s.log)
+	quotaService := services.NewQuotaService(usageRepo, workspaceRepo, authorizer, messageRepo, meetingRepo, channelRepo, s.log)
+	attachmentService := services.NewAttachmentService(attachmentRepo, quotaService, s.log)
+	channelMemberService := services.NewChannelMemberService(channelMemberRepo, channelRepo, workspaceMemberRepo, authorizer, s.log)
+	channelService := services.NewChannelService(channelRepo, channelMemberRepo, workspaceMemberRepo, authorizer, s.log)
+	messageService := services.NewMessageService(messageRepo, meetingRepo, quotaService, authorizer, s.log)
+	reactionService := services.NewReactionService(reactionRepo, authorizer, s.log)
+	sessionService := services.NewSessionService(sessionRepo, s.log)
+	apiTokenService := services.NewAPITokenService(apiTokenRepo, workspaceMemberRepo, channelRepo, meetingRepo, messageRepo, s.log)
+	loginAttemptService := services.NewLoginAttemptService(loginAttemptStore, securityEventRepo, mail, s.log)
+	userService := services.NewUserService(userRepo, sessionRepo, passwordResetRepo, loginLinkRepo, loginAttemptService, passwordPolicy, mail, s.log)
+	avatarService := services.NewAvatarService(userRepo, avatarRepo, s.log)
+	eventService := services.NewEventService(workspaceMemberRepo, s.log)
+	auditService := services.NewAuditService(auditRepo, authorizer, s.log)
+	presenceService := services.NewPresenceService(workspaceMemberRepo, eventService, s.log)
+	statusService := services.NewStatusService(userRepo, statusRepo, workspaceMemberRepo, eventService, s.log)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, loginAttemptService, s.log)
+	accountService := services.NewAccountService(userRepo, sessionRepo, apiTokenRepo, dataExportRepo, erasureRepo, workspaceMemberRepo, channelMemberRepo, messageRepo, reactionRepo, attachmentRepo, authorizer, twoFactorService, loginAttemptService, mail, os.Getenv("DATA_EXPORT_DIR"), s.log)
+	webAuthnService := services.NewWebAuthnService(webAuthnRepo, userRepo, webauthn.NewRelyingParty(), s.log)
+	ssoService := services.NewSSOService(ssoRepo, userRepo, workspaceRepo, workspaceMemberRepo, quotaService, authorizer, oidc.NewClient(nil), s.log)
+	meetingService := services.NewMeetingService(meetingRepo, channelRepo, userRepo, channelMemberRepo, quotaService, authorizer, s.log)
+	workspaceMemberService := services.NewWorkspaceMemberService(workspaceMemberRepo, workspaceRepo, userRepo, invitationRepo, joinRequestRepo, userService, quotaService, authorizer, auditService, eventService, mail, s.log)
+	invitationService := services.NewInvitationService(invitationRepo, authorizer, userRepo, workspaceMemberService, mail, s.log)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, workspaceMemberRepo, userRepo, authorizer, auditService, s.log)
+	roleService := services.NewRoleService(roleRepo, workspaceMemberRepo, authorizer, auditService, s.log)
+	meetingChatService := services.NewMeetingChatService(meetingRepo, messageRepo, userRepo, attachmentRepo, reactionRepo, quotaService, s.log) // Initialize MeetingChatService
 
 	// --- Handlers ---
 	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, s.log)
@@ -58,11 +104,36 @@ func (s *Server) RegisterRoutes() http.Handler {
 	channelHandler := handlers.NewChannelHandler(channelService, s.log)
 	messageHandler := handlers.NewMessageHandler(messageService, s.log)
 	reactionHandler := handlers.NewReactionHandler(reactionService, s.log)
-	userHandler := handlers.NewUserHandler(userService, s.log)
+	userHandler := handlers.NewUserHandler(userService, sessionService, s.log)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService, s.log)
+	avatarHandler := handlers.NewAvatarHandler(avatarService, s.log)
+	statusHandler := handlers.NewStatusHandler(statusService, s.log)
+	eventHandler := handlers.NewEventHandler(eventService, presenceService, s.log)
+	presenceHandler := handlers.NewPresenceHandler(presenceService, s.log)
+	accountHandler := handlers.NewAccountHandler(accountService, s.log)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService, sessionService, s.log)
+	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService, sessionService, s.log)
+	ssoHandler := handlers.NewSSOHandler(ssoService, sessionService, s.log)
 	meetingHandler := handlers.NewMeetingHandler(meetingService, s.log)
 	workspaceMemberHandler := handlers.NewWorkspaceMemberHandler(workspaceMemberService, s.log)
 	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService, s.log)
-	chatHandler := handlers.NewChatHandler(meetingChatService, s.log) // Initialize ChatHandler
+	quotaHandler := handlers.NewQuotaHandler(quotaService, s.log)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, s.log)
+	roleHandler := handlers.NewRoleHandler(roleService, s.log)
+	auditHandler := handlers.NewAuditHandler(auditService, s.log)
+	chatHandler := handlers.NewChatHandler(meetingChatService, userService, presenceService, s.log) // Initialize ChatHandler
+
+	// --- Background Workers ---
+	s.workers = append(s.workers, func(ctx context.Context) { statusService.RunExpiryWorker(ctx, time.Minute) })
+	s.workers = append(s.workers, func(ctx context.Context) { presenceService.RunIdleWorker(ctx, 30*time.Second) })
+
+	// Every route registered below is checked against its entry in routePolicies; routes without
+	// one are refused.
+	r.Use(middlewares.Authorize(s.log, routePolicies, sessionService, apiTokenService, authorizer))
+
+	r.GET("/", s.HelloWorldHandler)
+	r.GET("/health", s.healthHandler)
+	r.GET("/.well-known/jwks.json", s.jwksHandler)
 
 	// --- API Routes ---
 	api := r.Group("/api")
@@ -70,31 +141,135 @@ func (s *Server) RegisterRoutes() http.Handler {
 		// User Routes
 		api.POST("/register", userHandler.Register)
 		api.POST("/login", userHandler.Login)
-		api.GET("/users", middlewares.JWTAuth(s.log), userHandler.GetUserByID)
+		api.POST("/login/link", userHandler.RequestLoginLink)
+		api.POST("/login/link/verify", userHandler.LoginWithLink)
+		api.POST("/login/2fa", twoFactorHandler.CompleteLogin)
+		api.POST("/token/refresh", userHandler.RefreshToken)
+		api.POST("/logout", userHandler.Logout)
+		api.POST("/verify-email", userHandler.VerifyEmail)
+		api.POST("/verify-email/resend", userHandler.ResendVerificationEmail)
+		api.POST("/password/forgot", userHandler.ForgotPassword)
+		api.POST("/password/reset", userHandler.ResetPassword)
+		api.GET("/password/policy", userHandler.GetPasswordPolicy)
+		api.PUT("/password", userHandler.ChangePassword)
+		api.GET("/users", userHandler.GetUserByID)
 		api.GET("/users/by-email", userHandler.GetUserByEmail)       // Query param: ?email=
 		api.GET("/users/by-username", userHandler.GetUserByUsername) // Query param: ?username=
-		api.PUT("/users", middlewares.JWTAuth(s.log), userHandler.UpdateUser)
-		api.DELETE("/users", middlewares.JWTAuth(s.log), userHandler.DeleteUser)
+		api.PUT("/users", userHandler.UpdateUser)
+		api.DELETE("/users", accountHandler.Erase)
+		api.PUT("/users/avatar", avatarHandler.UploadAvatar)
+		api.DELETE("/users/avatar", avatarHandler.RemoveAvatar)
+		api.GET("/users/:userID/avatar", avatarHandler.GetAvatar) // Query params: ?size=&v=
+
+		// Status Routes
+		api.GET("/users/:userID/status", statusHandler.GetStatus)
+		api.PUT("/users/status", statusHandler.SetStatus)
+		api.DELETE("/users/status", statusHandler.ClearStatus)
+		api.PUT("/users/dnd", statusHandler.SetDoNotDisturb)
+		api.DELETE("/users/dnd", statusHandler.ClearDoNotDisturb)
+		api.PUT("/users/dnd/schedule", statusHandler.SetDoNotDisturbSchedule)
+		api.DELETE("/users/dnd/schedule", statusHandler.ClearDoNotDisturbSchedule)
+
+		// Presence Routes
+		api.GET("/presence", presenceHandler.GetPresence) // Query param: ?user_ids=1,2,3
+
+		// Account Lifecycle Routes
+		api.POST("/account/deactivate", accountHandler.Deactivate)
+		api.POST("/account/reactivate", accountHandler.Reactivate)
+		api.GET("/account/exports", accountHandler.ListExports)
+		api.POST("/account/exports", accountHandler.RequestExport)
+		api.GET("/account/exports/:exportID/download", accountHandler.DownloadExport)
+
+		// Session Routes
+		api.GET("/sessions", userHandler.ListSessions)
+		api.DELETE("/sessions", userHandler.RevokeOtherSessions)
+		api.DELETE("/sessions/:sessionID", userHandler.RevokeSession)
+
+		// Personal Access Token Routes
+		api.GET("/tokens", apiTokenHandler.ListTokens)
+		api.POST("/tokens", apiTokenHandler.CreateToken)
+		api.DELETE("/tokens/:tokenID", apiTokenHandler.RevokeToken)
+		api.GET("/tokens/scopes", apiTokenHandler.ListScopes)
+
+		// Two-Factor Authentication Routes
+		api.POST("/2fa/setup", twoFactorHandler.BeginSetup)
+		api.POST("/2fa/enable", twoFactorHandler.Enable)
+		api.POST("/2fa/disable", twoFactorHandler.Disable)
+		api.POST("/2fa/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+		// WebAuthn Routes
+		api.POST("/webauthn/register/begin", webAuthnHandler.BeginRegistration)
+		api.POST("/webauthn/register/finish", webAuthnHandler.FinishRegistration)
+		api.POST("/webauthn/login/begin", webAuthnHandler.BeginLogin)
+		api.POST("/webauthn/login/finish", webAuthnHandler.FinishLogin)
+		api.GET("/webauthn/credentials", webAuthnHandler.ListCredentials)
+		api.DELETE("/webauthn/credentials/:credentialID", webAuthnHandler.DeleteCredential)
 
 		// Workspace Routes
-		api.POST("/workspaces", middlewares.JWTAuth(s.log), workspaceHandler.CreateWorkspace)
+		api.POST("/workspaces", workspaceHandler.CreateWorkspace)
 		api.GET("/workspaces/:workspaceID", workspaceHandler.GetWorkspaceByID)
-		api.PUT("/workspaces/:workspaceID", middlewares.JWTAuth(s.log), workspaceHandler.UpdateWorkspace)
-		api.DELETE("/workspaces/:workspaceID", middlewares.JWTAuth(s.log), workspaceHandler.DeleteWorkspace)
-		api.GET("/workspaces", middlewares.JWTAuth(s.log), workspaceHandler.GetWorkspacesForUser)
+		api.PUT("/workspaces/:workspaceID", workspaceHandler.UpdateWorkspace)
+		api.DELETE("/workspaces/:workspaceID", workspaceHandler.DeleteWorkspace)
+		api.GET("/workspaces", workspaceHandler.GetWorkspacesForUser)
+		api.GET("/workspaces/by-slug/:slug", workspaceHandler.GetWorkspaceBySlug)
+		api.GET("/directory", workspaceHandler.SearchDirectory) // Query params: ?q=&limit=&offset=
+		api.POST("/workspaces/:workspaceID/transfer-ownership", workspaceHandler.TransferOwnership)
+		api.GET("/workspaces/:workspaceID/audit-log", auditHandler.GetAuditLog) // Query params: ?limit=&offset=
+
+		// Single Sign-On Routes
+		api.GET("/workspaces/:workspaceID/sso/oidc", ssoHandler.GetOIDCConfig)
+		api.PUT("/workspaces/:workspaceID/sso/oidc", ssoHandler.ConfigureOIDC)
+		api.DELETE("/workspaces/:workspaceID/sso/oidc", ssoHandler.DeleteOIDCConfig)
+		api.POST("/workspaces/:workspaceID/sso/oidc/start", ssoHandler.StartOIDCLogin)
+		api.POST("/workspaces/:workspaceID/sso/oidc/link", ssoHandler.StartOIDCLink)
+		api.POST("/sso/oidc/callback", ssoHandler.OIDCCallback)
+		api.GET("/workspaces/:workspaceID/sso/saml", ssoHandler.GetSAMLConfig)
+		api.PUT("/workspaces/:workspaceID/sso/saml", ssoHandler.ConfigureSAML)
+		api.DELETE("/workspaces/:workspaceID/sso/saml", ssoHandler.DeleteSAMLConfig)
+		api.POST("/workspaces/:workspaceID/sso/saml/start", ssoHandler.StartSAMLLogin)
+		api.POST("/workspaces/:workspaceID/sso/saml/link", ssoHandler.StartSAMLLink)
+		api.GET("/sso/saml/:workspaceID/metadata", ssoHandler.SAMLMetadata)
+		api.POST("/sso/saml/:workspaceID/acs", ssoHandler.SAMLACS)
+		api.POST("/sso/exchange", ssoHandler.ExchangeCode)
 
 		// Workspace Member Routes
 		api.POST("/workspaces/:workspaceID/members", workspaceMemberHandler.AddMemberToWorkspace)
 		api.DELETE("/workspaces/:workspaceID/members/:userID", workspaceMemberHandler.RemoveMemberFromWorkspace)
 		api.GET("/workspaces/:workspaceID/members", workspaceMemberHandler.GetWorkspaceMembers)
-		api.POST("/workspaces/:workspaceID/join", middlewares.JWTAuth(s.log), workspaceMemberHandler.JoinWorkspace)
+		api.POST("/workspaces/:workspaceID/join", workspaceMemberHandler.JoinWorkspace)
+		api.POST("/workspaces/:workspaceID/members/:userID/unlock", workspaceMemberHandler.UnlockMember)
+
+		api.POST("/workspaces/:workspaceID/join-requests", workspaceMemberHandler.RequestToJoin)
+		api.GET("/workspaces/:workspaceID/join-requests", workspaceMemberHandler.GetJoinRequests)
+		api.POST("/workspaces/:workspaceID/join-requests/:requestID/approve", workspaceMemberHandler.ApproveJoinRequest)
+		api.POST("/workspaces/:workspaceID/join-requests/:requestID/deny", workspaceMemberHandler.DenyJoinRequest)
+
+		api.GET("/workspaces/:workspaceID/usage", quotaHandler.GetUsage)
+
+		// Role and Permission Routes
+		api.GET("/permissions", roleHandler.GetPermissionMatrix)
+		api.GET("/workspaces/:workspaceID/permissions", roleHandler.GetPermissions)
+		api.GET("/workspaces/:workspaceID/roles", roleHandler.GetRoles)
+		api.POST("/workspaces/:workspaceID/roles", roleHandler.CreateRole)
+		api.PUT("/workspaces/:workspaceID/roles/:roleID", roleHandler.UpdateRole)
+		api.DELETE("/workspaces/:workspaceID/roles/:roleID", roleHandler.DeleteRole)
+		api.PUT("/workspaces/:workspaceID/members/:userID/role", roleHandler.AssignRole)
+		api.POST("/workspaces/:workspaceID/members/:userID/promote", roleHandler.PromoteMember)
+		api.POST("/workspaces/:workspaceID/members/:userID/demote", roleHandler.DemoteMember)
+
+		// Invitation Routes
+		api.POST("/workspaces/:workspaceID/invitations", invitationHandler.CreateInvitation)
+		api.GET("/workspaces/:workspaceID/invitations", invitationHandler.ListInvitations)
+		api.DELETE("/workspaces/:workspaceID/invitations/:invitationID", invitationHandler.RevokeInvitation)
+		api.POST("/invitations/preview", invitationHandler.PreviewInvitation)
+		api.POST("/invitations/accept", invitationHandler.AcceptInvitation)
 
 		// Channel Routes
-		api.POST("/channels", middlewares.JWTAuth(s.log), channelHandler.CreateChannel)
-		api.GET("/channels/:channelID", middlewares.JWTAuth(s.log), channelHandler.GetChannelByID)
-		api.PUT("/channels/:channelID", middlewares.JWTAuth(s.log), channelHandler.UpdateChannel)
-		api.DELETE("/channels/:channelID", middlewares.JWTAuth(s.log), channelHandler.DeleteChannel)
-		api.GET("/workspaces/:workspaceID/channels", middlewares.JWTAuth(s.log), channelHandler.GetChannelsForWorkspace)
+		api.POST("/channels", channelHandler.CreateChannel)
+		api.GET("/channels/:channelID", channelHandler.GetChannelByID)
+		api.PUT("/channels/:channelID", channelHandler.UpdateChannel)
+		api.DELETE("/channels/:channelID", channelHandler.DeleteChannel)
+		api.GET("/workspaces/:workspaceID/channels", channelHandler.GetChannelsForWorkspace)
 
 		// Channel Member Routes
 		api.POST("/channels/:channelID/members", channelMemberHandler.AddMemberToChannel)
@@ -102,20 +277,20 @@ func (s *Server) RegisterRoutes() http.Handler {
 		api.GET("/channels/:channelID/members", channelMemberHandler.GetChannelMembers)
 
 		// Message Routes
-		api.POST("/messages", middlewares.JWTAuth(s.log), messageHandler.CreateMessage)
+		api.POST("/messages", messageHandler.CreateMessage)
 		api.GET("/messages/:messageID", messageHandler.GetMessageByID)
-		api.PUT("/messages/:messageID", middlewares.JWTAuth(s.log), messageHandler.UpdateMessage)
-		api.DELETE("/messages/:messageID", middlewares.JWTAuth(s.log), messageHandler.DeleteMessage)
+		api.PUT("/messages/:messageID", messageHandler.UpdateMessage)
+		api.DELETE("/messages/:messageID", messageHandler.DeleteMessage)
 		api.GET("/meetings/:meetingID/messages", messageHandler.GetMessagesInMeeting)
 
 		// Meeting Routes
-		api.POST("/meetings", middlewares.JWTAuth(s.log), meetingHandler.CreateMeeting)
+		api.POST("/meetings", meetingHandler.CreateMeeting)
 		api.GET("/meetings/:meetingID", meetingHandler.GetMeetingByID)
-		api.PUT("/meetings/:meetingID", middlewares.JWTAuth(s.log), meetingHandler.UpdateMeeting)
-		api.DELETE("/meetings/:meetingID", middlewares.JWTAuth(s.log), meetingHandler.DeleteMeeting)
+		api.PUT("/meetings/:meetingID", meetingHandler.UpdateMeeting)
+		api.DELETE("/meetings/:meetingID", meetingHandler.DeleteMeeting)
 		api.GET("/channels/:channelID/meetings", meetingHandler.GetMeetingsByChannelID)
-		api.POST("/meetings/:meetingID/participants", middlewares.JWTAuth(s.log), meetingHandler.AddParticipant)
-		api.DELETE("/meetings/:meetingID/participants/:participantID", middlewares.JWTAuth(s.log), meetingHandler.RemoveParticipant)
+		api.POST("/meetings/:meetingID/participants", meetingHandler.AddParticipant)
+		api.DELETE("/meetings/:meetingID/participants/:participantID", meetingHandler.RemoveParticipant)
 
 		// Attachment Routes
 		api.POST("/attachments", attachmentHandler.CreateAttachment)
@@ -130,9 +305,9 @@ func (s *Server) RegisterRoutes() http.Handler {
 
 	// --- WebSocket Routes ---
 	wsGroup := r.Group("/ws")
-	wsGroup.Use(middlewares.JWTAuth(s.log))
 	{
 		wsGroup.GET("/meeting/:meeting_id/chat", chatHandler.ServeMeetingChatWs)
+		wsGroup.GET("/events", eventHandler.ServeEventsWs)
 	}
 
 	return r
@@ -153,3 +328,27 @@ func (s *Server) healthHandler(c *gin.Context) {
 	s.log.Info().Interface("health_status", healthStatus).Msg("Database health status retrieved")
 	c.JSON(http.StatusOK, healthStatus)
 }
+
+// jwksHandler publishes the keys access tokens are verified with, so other services can check
+// Axis tokens without sharing a secret.
+func (s *Server) jwksHandler(c *gin.Context) {
+	config := utils.CurrentJWTConfig()
+	if config == nil {
+		s.log.Error().Msg("JWKS requested before signing keys were loaded")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "signing keys are not loaded"})
+		return
+	}
+
+	keys := make([]oidc.JSONWebKey, 0)
+	for _, k := range config.PublicKeys() {
+		jwk, err := oidc.NewJSONWebKey(k.ID, k.Algorithm, k.Key)
+		if err != nil {
+			s.log.Error().Err(err).Str("kid", k.ID).Msg("Failed to encode signing key")
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 
 	"axis/internal/database"
+	"axis/internal/utils"
 	"github.com/rs/zerolog"
 )
 
@@ -17,10 +19,36 @@ type Server struct {
 	port int
 	db   database.Service
 	log  zerolog.Logger
+	// workers are the background jobs RegisterRoutes sets up for the services it builds. They run
+	// until the context they are started with is done.
+	workers []func(ctx context.Context)
 }
 
-func NewServer(logger zerolog.Logger) *http.Server {
+// NewServer builds the HTTP server and starts its background workers, which stop once ctx is done.
+func NewServer(ctx context.Context, logger zerolog.Logger) *http.Server {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
+
+	jwtConfig, err := utils.LoadJWTConfig()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to load JWT signing keys")
+	}
+	if jwtConfig.Ephemeral {
+		logger.Warn().Msg("JWT_KEYS_DIR is not set, signing tokens with a temporary key that is lost on restart")
+	}
+	utils.SetJWTConfig(jwtConfig)
+
+	passwordHashConfig, err := utils.LoadPasswordHashConfig()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid password hashing configuration")
+	}
+	utils.SetPasswordHashConfig(passwordHashConfig)
+
+	sessionCookieConfig, err := utils.LoadSessionCookieConfig()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Invalid session cookie configuration")
+	}
+	utils.SetSessionCookieConfig(sessionCookieConfig)
+
 	NewServer := &Server{
 		port: port,
 		db:   database.New(logger),
@@ -35,6 +63,9 @@ func NewServer(logger zerolog.Logger) *http.Server {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
+	for _, worker := range NewServer.workers {
+		go worker(ctx)
+	}
 
 	return server
 }
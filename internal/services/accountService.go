@@ -0,0 +1,463 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"axis/internal/mailer"
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	dataExportTTL = 7 * 24 * time.Hour
+	// dataExportInterval limits how often a user can start an export.
+	dataExportInterval = 24 * time.Hour
+	// dataExportTimeout is how long an export may stay pending before it is taken to have died
+	// with the process that was building it.
+	dataExportTimeout = time.Hour
+	// erasedMessageContent replaces the text of an erased user's messages. The messages stay so
+	// replies and reactions of other users keep their context.
+	erasedMessageContent = "This message was deleted."
+)
+
+// AccountService covers the account lifecycle beyond sign-in: deactivation, data export and
+// erasure.
+type AccountService interface {
+	Deactivate(ctx context.Context, userID int) error
+	Reactivate(ctx context.Context, form models.ReactivateAccountModel, ipAddress string) error
+	RequestExport(ctx context.Context, userID int) (*models.DataExport, error)
+	ListExports(ctx context.Context, userID int) ([]models.DataExport, error)
+	// GetExportFile returns a completed, unexpired export of the user and the path of its archive.
+	GetExportFile(ctx context.Context, userID, exportID int) (*models.DataExport, string, error)
+	Erase(ctx context.Context, userID int, form models.EraseAccountModel, ipAddress string) error
+}
+
+type accountService struct {
+	userRepo            repositories.UserRepo
+	sessionRepo         repositories.SessionRepo
+	apiTokenRepo        repositories.APITokenRepo
+	dataExportRepo      repositories.DataExportRepo
+	erasureRepo         repositories.ErasureRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	channelMemberRepo   repositories.ChannelMemberRepo
+	messageRepo         repositories.MessageRepo
+	reactionRepo        repositories.ReactionRepo
+	attachmentRepo      repositories.AttachmentRepo
+	authorizer          Authorizer
+	twoFactorService    TwoFactorService
+	loginAttemptService LoginAttemptService
+	mailer              mailer.Mailer
+	exportDir           string
+	log                 zerolog.Logger
+}
+
+// NewAccountService builds the service. Export archives are written to exportDir, or to a
+// directory under the system temp dir when it is empty.
+func NewAccountService(
+	userRepo repositories.UserRepo,
+	sessionRepo repositories.SessionRepo,
+	apiTokenRepo repositories.APITokenRepo,
+	dataExportRepo repositories.DataExportRepo,
+	erasureRepo repositories.ErasureRepo,
+	wmr repositories.WorkspaceMemberRepo,
+	cmr repositories.ChannelMemberRepo,
+	messageRepo repositories.MessageRepo,
+	reactionRepo repositories.ReactionRepo,
+	attachmentRepo repositories.AttachmentRepo,
+	authorizer Authorizer,
+	tfs TwoFactorService,
+	las LoginAttemptService,
+	m mailer.Mailer,
+	exportDir string,
+	logger zerolog.Logger,
+) AccountService {
+	if exportDir == "" {
+		exportDir = filepath.Join(os.TempDir(), "axis-exports")
+	}
+	return &accountService{
+		userRepo:            userRepo,
+		sessionRepo:         sessionRepo,
+		apiTokenRepo:        apiTokenRepo,
+		dataExportRepo:      dataExportRepo,
+		erasureRepo:         erasureRepo,
+		workspaceMemberRepo: wmr,
+		channelMemberRepo:   cmr,
+		messageRepo:         messageRepo,
+		reactionRepo:        reactionRepo,
+		attachmentRepo:      attachmentRepo,
+		authorizer:          authorizer,
+		twoFactorService:    tfs,
+		loginAttemptService: las,
+		mailer:              m,
+		exportDir:           exportDir,
+		log:                 logger,
+	}
+}
+
+// Deactivate signs the user out everywhere and keeps them from signing in until they reactivate.
+// Their data and memberships stay as they are.
+func (s *accountService) Deactivate(ctx context.Context, userID int) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.DeactivatedAt != nil {
+		return &ConflictError{Message: "Account is already deactivated"}
+	}
+
+	now := time.Now()
+	user.DeactivatedAt = &now
+	user.UpdatedAt = now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to deactivate account.")
+		return err
+	}
+	if err := s.sessionRepo.RevokeSessionsForUser(ctx, userID, 0); err != nil {
+		return err
+	}
+	if err := s.apiTokenRepo.RevokeTokensForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("Account deactivated.")
+	return nil
+}
+
+// Reactivate lifts a deactivation. It takes the password rather than a session, since a
+// deactivated account has none, and is throttled like a login.
+func (s *accountService) Reactivate(ctx context.Context, form models.ReactivateAccountModel, ipAddress string) error {
+	if err := s.loginAttemptService.Check(ctx, form.Email, ipAddress); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, form.Email)
+	if err != nil && err != sql.ErrNoRows {
+		s.log.Error().Err(err).Msg("Failed to fetch user for reactivation.")
+		return err
+	}
+	if user == nil || !utils.CheckPassword(user.Password, form.Password) {
+		if err := s.loginAttemptService.RecordFailure(ctx, form.Email, ipAddress, user); err != nil {
+			s.log.Error().Err(err).Msg("Failed to record failed reactivation attempt")
+		}
+		return NewUnauthorizedError("Invalid credentials")
+	}
+	if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear failed login attempts")
+	}
+	if user.ErasedAt != nil || user.DeactivatedAt == nil {
+		return &ConflictError{Message: "Account is not deactivated"}
+	}
+
+	user.DeactivatedAt = nil
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to reactivate account.")
+		return err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Account reactivated.")
+	return nil
+}
+
+// RequestExport queues an export of the user's data. The archive is built in the background and
+// the user gets an email once it can be downloaded.
+func (s *accountService) RequestExport(ctx context.Context, userID int) (*models.DataExport, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exports, err := s.dataExportRepo.GetExportsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, e := range exports {
+		if e.Status == models.DataExportPending {
+			if now.Sub(e.CreatedAt) < dataExportTimeout {
+				return nil, &ConflictError{Message: "An export is already being prepared"}
+			}
+			s.log.Warn().Int("user_id", userID).Int("export_id", e.ID).Msg("Data export stayed pending for too long.")
+			s.failExport(ctx, e)
+			continue
+		}
+		if wait := e.CreatedAt.Add(dataExportInterval).Sub(now); wait > 0 && e.Status == models.DataExportCompleted {
+			return nil, NewTooManyRequestsError("An export was already created in the last 24 hours", wait)
+		}
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.DataExportPending, CreatedAt: now}
+	if err := s.dataExportRepo.CreateExport(ctx, export); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Int("export_id", export.ID).Msg("Data export requested.")
+	go s.runExport(context.WithoutCancel(ctx), user, *export)
+	return export, nil
+}
+
+func (s *accountService) ListExports(ctx context.Context, userID int) ([]models.DataExport, error) {
+	return s.dataExportRepo.GetExportsForUser(ctx, userID)
+}
+
+func (s *accountService) GetExportFile(ctx context.Context, userID, exportID int) (*models.DataExport, string, error) {
+	export, err := s.dataExportRepo.GetExport(ctx, exportID)
+	if err != nil {
+		return nil, "", err
+	}
+	if export == nil || export.UserID != userID {
+		return nil, "", NewNotFoundError("Export not found")
+	}
+	if export.Status != models.DataExportCompleted {
+		return nil, "", &ConflictError{Message: "Export is not ready"}
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		s.removeExportFile(export)
+		return nil, "", NewNotFoundError("Export has expired")
+	}
+	return export, export.FilePath, nil
+}
+
+// Erase removes the user's personal data for good. Rows that only belong to the user are deleted;
+// the user row itself is anonymized rather than deleted, so messages, channels and workspaces they
+// created keep a valid author, shown as a deleted user. The text of their messages is replaced.
+func (s *accountService) Erase(ctx context.Context, userID int, form models.EraseAccountModel, ipAddress string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(strings.TrimSpace(form.ConfirmEmail), user.Email) {
+		return NewValidationError("Confirm the erasure by entering the account's email address")
+	}
+	if err := s.twoFactorService.Reauthenticate(ctx, user, form.Password, form.Code, ipAddress); err != nil {
+		return err
+	}
+	if err := s.checkNoWorkspaceLeftBehind(ctx, userID); err != nil {
+		return err
+	}
+
+	exports, err := s.dataExportRepo.GetExportsForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Nobody can sign in as an erased user: the email is replaced and the password is random.
+	password, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	anonymized := &models.User{
+		ID:            user.ID,
+		Name:          "Deleted user",
+		Username:      fmt.Sprintf("deleted-%d", user.ID),
+		Email:         fmt.Sprintf("deleted-%d@invalid", user.ID),
+		Password:      hashedPassword,
+		Status:        user.Status,
+		Locale:        "",
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     now,
+		DeactivatedAt: &now,
+		ErasedAt:      &now,
+	}
+	if err := s.erasureRepo.EraseUser(ctx, anonymized, user.Email, erasedMessageContent); err != nil {
+		return err
+	}
+	for i := range exports {
+		s.removeExportFile(&exports[i])
+	}
+	if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+		s.log.Warn().Err(err).Int("user_id", userID).Msg("Failed to clear failed login attempts")
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("Account erased.")
+	return nil
+}
+
+// checkNoWorkspaceLeftBehind returns a ConflictError while the user owns a workspace or is the
+// last member able to manage one, since erasing them would leave it without anyone in charge.
+func (s *accountService) checkNoWorkspaceLeftBehind(ctx context.Context, userID int) error {
+	memberships, err := s.workspaceMemberRepo.GetWorkspacesForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range memberships {
+		workspace := memberships[i].Workspace
+		if workspace == nil {
+			continue
+		}
+		if isOwner(workspace, &memberships[i]) {
+			return &ConflictError{Message: fmt.Sprintf("You own the workspace %q. Transfer its ownership to another member before erasing your account", workspace.Name)}
+		}
+		if err := checkAdminsRemain(ctx, s.authorizer, workspace.ID, userID); err != nil {
+			if _, ok := err.(*ConflictError); ok {
+				return &ConflictError{Message: fmt.Sprintf("You are the last admin of the workspace %q. Make another member an admin before erasing your account", workspace.Name)}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *accountService) getUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to fetch user.")
+		return nil, err
+	}
+	return user, nil
+}
+
+// runExport builds the archive of a pending export and records the outcome.
+func (s *accountService) runExport(ctx context.Context, user *models.User, export models.DataExport) {
+	path, size, err := s.writeExportArchive(ctx, user, export.ID)
+	now := time.Now()
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Int("export_id", export.ID).Msg("Failed to build data export.")
+		s.failExport(ctx, export)
+		return
+	}
+
+	expiresAt := now.Add(dataExportTTL)
+	export.Status = models.DataExportCompleted
+	export.FilePath = path
+	export.FileSize = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err := s.dataExportRepo.UpdateExport(ctx, &export); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Int("export_id", export.ID).Msg("Failed to record completed data export.")
+		os.Remove(path)
+		s.failExport(ctx, export)
+		return
+	}
+	s.log.Info().Int("user_id", user.ID).Int("export_id", export.ID).Int64("size", size).Msg("Data export completed.")
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Axis data export is ready",
+		Body: fmt.Sprintf("Hi %s,\n\nThe copy of your Axis data you asked for is ready. Download it here:\n\n%s\n\nThe download is available until %s.\n",
+			user.Name, utils.AppURL("/settings/account/exports", nil), expiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send data export email.")
+	}
+}
+
+// failExport marks an export that will never complete as failed, so that the user can ask for a
+// new one.
+func (s *accountService) failExport(ctx context.Context, export models.DataExport) {
+	now := time.Now()
+	export.Status = models.DataExportFailed
+	export.FilePath = ""
+	export.FileSize = 0
+	export.CompletedAt = &now
+	export.ExpiresAt = nil
+	if err := s.dataExportRepo.UpdateExport(ctx, &export); err != nil {
+		s.log.Error().Err(err).Int("export_id", export.ID).Msg("Failed to mark data export as failed.")
+	}
+}
+
+// writeExportArchive writes a zip archive with one JSON document per kind of data. Attachments
+// are stored as links, so files.json lists them with their URLs.
+func (s *accountService) writeExportArchive(ctx context.Context, user *models.User, exportID int) (string, int64, error) {
+	sessions, err := s.sessionRepo.GetActiveSessionsForUser(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	workspaces, err := s.workspaceMemberRepo.GetWorkspacesForUser(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	channels, err := s.channelMemberRepo.GetChannelsForUser(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	messages, err := s.messageRepo.GetMessagesBySender(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	reactions, err := s.reactionRepo.GetReactionsByUser(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+	files, err := s.attachmentRepo.GetAttachmentsByUser(ctx, user.ID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(s.exportDir, 0o700); err != nil {
+		return "", 0, err
+	}
+	f, err := os.CreateTemp(s.exportDir, fmt.Sprintf("export-%d-*.zip", exportID))
+	if err != nil {
+		return "", 0, err
+	}
+	path := f.Name()
+	fail := func(err error) (string, int64, error) {
+		f.Close()
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	archive := zip.NewWriter(f)
+	for _, doc := range []struct {
+		name string
+		data any
+	}{
+		{"profile.json", user},
+		{"sessions.json", sessions},
+		{"workspaces.json", workspaces},
+		{"channels.json", channels},
+		{"messages.json", messages},
+		{"reactions.json", reactions},
+		{"files.json", files},
+	} {
+		w, err := archive.Create(doc.name)
+		if err != nil {
+			return fail(err)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc.data); err != nil {
+			return fail(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fail(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+func (s *accountService) removeExportFile(export *models.DataExport) {
+	if export.FilePath == "" {
+		return
+	}
+	if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+		s.log.Warn().Err(err).Int("export_id", export.ID).Msg("Failed to remove data export archive.")
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// APITokenPrefix starts every personal access token so the auth middleware can tell them
+	// apart from access tokens, and so leaked tokens are easy to scan for.
+	APITokenPrefix       = "axis_pat_"
+	apiTokenDisplayChars = 4
+	maxAPITokenLifetime  = 366
+)
+
+type APITokenService interface {
+	CreateToken(ctx context.Context, userID int, form models.CreateAPITokenModel) (*models.CreatedAPIToken, error)
+	ListTokens(ctx context.Context, userID int) ([]models.APIToken, error)
+	RevokeToken(ctx context.Context, userID, tokenID int) error
+	Authenticate(ctx context.Context, token string) (*models.APIToken, error)
+	ResourceWorkspaceID(ctx context.Context, resource models.ResourceType, id int) (int, error)
+}
+
+type apiTokenService struct {
+	apiTokenRepo        repositories.APITokenRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	channelRepo         repositories.ChannelRepo
+	meetingRepo         repositories.MeetingRepo
+	messageRepo         repositories.MessageRepo
+	log                 zerolog.Logger
+}
+
+func NewAPITokenService(atr repositories.APITokenRepo, wmr repositories.WorkspaceMemberRepo, cr repositories.ChannelRepo, mr repositories.MeetingRepo, msr repositories.MessageRepo, logger zerolog.Logger) APITokenService {
+	return &apiTokenService{
+		apiTokenRepo:        atr,
+		workspaceMemberRepo: wmr,
+		channelRepo:         cr,
+		meetingRepo:         mr,
+		messageRepo:         msr,
+		log:                 logger,
+	}
+}
+
+func (s *apiTokenService) CreateToken(ctx context.Context, userID int, form models.CreateAPITokenModel) (*models.CreatedAPIToken, error) {
+	name := strings.TrimSpace(form.Name)
+	if name == "" {
+		return nil, NewValidationError("Token name is required")
+	}
+	if len(form.Scopes) == 0 {
+		return nil, NewValidationError("At least one scope is required")
+	}
+	scopes := make([]string, 0, len(form.Scopes))
+	for _, scope := range form.Scopes {
+		if !validAPIScope(scope) {
+			return nil, NewValidationError("Unknown scope: " + scope)
+		}
+		scopes = append(scopes, scope)
+	}
+
+	if form.WorkspaceID != nil {
+		isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, *form.WorkspaceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, &ForbiddenError{Message: "You are not a member of this workspace"}
+		}
+	}
+
+	var expiresAt *time.Time
+	if form.ExpiresInDays != nil {
+		if *form.ExpiresInDays < 1 || *form.ExpiresInDays > maxAPITokenLifetime {
+			return nil, NewValidationError("expires_in_days must be between 1 and 366")
+		}
+		t := time.Now().AddDate(0, 0, *form.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to generate API token")
+		return nil, err
+	}
+	raw := APITokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID:      userID,
+		Name:        name,
+		TokenHash:   utils.HashToken(raw),
+		Prefix:      raw[:len(APITokenPrefix)+apiTokenDisplayChars],
+		Scopes:      scopes,
+		WorkspaceID: form.WorkspaceID,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.apiTokenRepo.CreateToken(ctx, token); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Int("token_id", token.ID).Strs("scopes", scopes).Msg("API token created")
+	return &models.CreatedAPIToken{APIToken: *token, Token: raw}, nil
+}
+
+func (s *apiTokenService) ListTokens(ctx context.Context, userID int) ([]models.APIToken, error) {
+	return s.apiTokenRepo.GetTokensForUser(ctx, userID)
+}
+
+func (s *apiTokenService) RevokeToken(ctx context.Context, userID, tokenID int) error {
+	revoked, err := s.apiTokenRepo.RevokeToken(ctx, userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return NewNotFoundError("API token not found")
+	}
+	s.log.Info().Int("user_id", userID).Int("token_id", tokenID).Msg("API token revoked")
+	return nil
+}
+
+// Authenticate looks up an active token. It returns nil without an error when the token is
+// unknown, revoked or expired.
+func (s *apiTokenService) Authenticate(ctx context.Context, token string) (*models.APIToken, error) {
+	apiToken, err := s.apiTokenRepo.GetTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiToken == nil || !apiToken.IsActive(now) {
+		return nil, nil
+	}
+
+	if apiToken.LastUsedAt == nil || now.Sub(*apiToken.LastUsedAt) > lastSeenResolution {
+		if err := s.apiTokenRepo.TouchToken(ctx, apiToken.ID, now); err != nil {
+			s.log.Warn().Err(err).Int("token_id", apiToken.ID).Msg("Failed to record API token use")
+		}
+	}
+	return apiToken, nil
+}
+
+// ResourceWorkspaceID returns the workspace a channel, meeting or message belongs to, so that
+// workspace-restricted tokens can be checked against it.
+func (s *apiTokenService) ResourceWorkspaceID(ctx context.Context, resource models.ResourceType, id int) (int, error) {
+	return resourceWorkspaceID(ctx, s.messageRepo, s.meetingRepo, s.channelRepo, resource, id)
+}
+
+// resourceWorkspaceID walks up from a message, meeting or channel to the workspace it belongs to.
+func resourceWorkspaceID(ctx context.Context, messageRepo repositories.MessageRepo, meetingRepo repositories.MeetingRepo, channelRepo repositories.ChannelRepo, resource models.ResourceType, id int) (int, error) {
+	switch resource {
+	case models.ResourceWorkspace:
+		return id, nil
+	case models.ResourceMessage:
+		message, err := messageRepo.GetMessageByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if message == nil {
+			return 0, NewNotFoundError("Message not found")
+		}
+		return resourceWorkspaceID(ctx, messageRepo, meetingRepo, channelRepo, models.ResourceMeeting, message.MeetingID)
+	case models.ResourceMeeting:
+		meeting, err := meetingRepo.GetMeetingByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if meeting == nil {
+			return 0, NewNotFoundError("Meeting not found")
+		}
+		return resourceWorkspaceID(ctx, messageRepo, meetingRepo, channelRepo, models.ResourceChannel, meeting.ChannelID)
+	case models.ResourceChannel:
+		channel, err := channelRepo.GetChannelByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if channel == nil {
+			return 0, NewNotFoundError("Channel not found")
+		}
+		return channel.WorkspaceID, nil
+	}
+	return 0, NewValidationError("Unknown resource type: " + string(resource))
+}
+
+func validAPIScope(scope string) bool {
+	for _, s := range models.APIScopes {
+		if string(s) == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -17,18 +17,28 @@ type AttachmentService interface {
 
 type attachmentService struct {
 	attachmentRepo repositories.AttachmentRepo
+	quotaService   QuotaService
 	log            zerolog.Logger
 }
 
-func NewAttachmentService(ar repositories.AttachmentRepo, logger zerolog.Logger) AttachmentService {
+func NewAttachmentService(ar repositories.AttachmentRepo, qs QuotaService, logger zerolog.Logger) AttachmentService {
 	return &attachmentService{
 		attachmentRepo: ar,
+		quotaService:   qs,
 		log:            logger,
 	}
 }
 
 func (s *attachmentService) CreateAttachment(ctx context.Context, attachment *models.Attachment) (*models.Attachment, error) {
-	err := s.attachmentRepo.CreateAttachment(ctx, attachment)
+	workspaceID, err := s.quotaService.WorkspaceIDOf(ctx, models.ResourceMessage, attachment.MessageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotaService.CheckStorage(ctx, workspaceID, attachment.FileSize); err != nil {
+		return nil, err
+	}
+
+	err = s.attachmentRepo.CreateAttachment(ctx, attachment)
 	if err != nil {
 		s.log.Error().Err(err).Str("filename", attachment.FileName).Msg("Failed to create attachment")
 		return nil, err
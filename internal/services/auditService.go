@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+// AuditService keeps each workspace's log of changes to its members and ownership.
+type AuditService interface {
+	// Record adds an entry to the workspace's audit log. The change it describes has already
+	// happened, so errors are only logged.
+	Record(ctx context.Context, entry *models.WorkspaceAuditEntry)
+	// GetAuditLog lists the workspace's audit log, newest first. It needs workspace.audit.
+	GetAuditLog(ctx context.Context, userID, workspaceID, limit, offset int) ([]models.WorkspaceAuditEntry, error)
+}
+
+type auditService struct {
+	auditRepo  repositories.AuditRepo
+	authorizer Authorizer
+	log        zerolog.Logger
+}
+
+func NewAuditService(ar repositories.AuditRepo, authorizer Authorizer, logger zerolog.Logger) AuditService {
+	return &auditService{
+		auditRepo:  ar,
+		authorizer: authorizer,
+		log:        logger,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, entry *models.WorkspaceAuditEntry) {
+	if err := s.auditRepo.CreateEntry(ctx, entry); err != nil {
+		s.log.Error().Err(err).Int("workspace_id", entry.WorkspaceID).Int("actor_id", entry.ActorID).Str("action", string(entry.Action)).Msg("Failed to record audit entry")
+	}
+}
+
+func (s *auditService) GetAuditLog(ctx context.Context, userID, workspaceID, limit, offset int) ([]models.WorkspaceAuditEntry, error) {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceAudit); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	if limit > maxAuditLogLimit {
+		limit = maxAuditLogLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, err := s.auditRepo.GetEntriesForWorkspace(ctx, workspaceID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []models.WorkspaceAuditEntry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+// Authorizer decides what members may do in a workspace. Services ask it instead of looking at
+// member roles themselves, so built-in and custom roles are honoured the same way everywhere.
+type Authorizer interface {
+	// Authorize loads the workspace and returns a ForbiddenError unless userID holds permission in it.
+	Authorize(ctx context.Context, userID, workspaceID int, permission models.Permission) (*models.Workspace, error)
+	// AuthorizeResource does the same in the workspace a channel, meeting, message or attachment
+	// belongs to.
+	AuthorizeResource(ctx context.Context, userID int, resource models.ResourceType, id int, permission models.Permission) error
+	// AuthorizeMember returns a ForbiddenError unless userID is a member of the workspace the resource
+	// belongs to.
+	AuthorizeMember(ctx context.Context, userID int, resource models.ResourceType, id int) error
+	// Permissions returns what userID may do in the workspace, and whether they are a member of it.
+	Permissions(ctx context.Context, userID, workspaceID int) ([]models.Permission, bool, error)
+	// MembersWith returns the members of the workspace who hold permission.
+	MembersWith(ctx context.Context, workspaceID int, permission models.Permission) ([]models.WorkspaceMember, error)
+	// AuthorizeGrant returns a ForbiddenError unless granterID may give someone role, or the custom
+	// role with customRoleID when it is not nil, in the workspace.
+	AuthorizeGrant(ctx context.Context, granterID, workspaceID int, role models.UserRole, customRoleID *int) error
+}
+
+type authorizer struct {
+	workspaceRepo       repositories.WorkspaceRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	roleRepo            repositories.RoleRepo
+	messageRepo         repositories.MessageRepo
+	meetingRepo         repositories.MeetingRepo
+	channelRepo         repositories.ChannelRepo
+	attachmentRepo      repositories.AttachmentRepo
+	log                 zerolog.Logger
+}
+
+func NewAuthorizer(wr repositories.WorkspaceRepo, wmr repositories.WorkspaceMemberRepo, rr repositories.RoleRepo, msr repositories.MessageRepo, mr repositories.MeetingRepo, cr repositories.ChannelRepo, ar repositories.AttachmentRepo, logger zerolog.Logger) Authorizer {
+	return &authorizer{
+		workspaceRepo:       wr,
+		workspaceMemberRepo: wmr,
+		roleRepo:            rr,
+		messageRepo:         msr,
+		meetingRepo:         mr,
+		channelRepo:         cr,
+		attachmentRepo:      ar,
+		log:                 logger,
+	}
+}
+
+func (a *authorizer) Authorize(ctx context.Context, userID, workspaceID int, permission models.Permission) (*models.Workspace, error) {
+	workspace, err := a.workspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := a.permissions(ctx, workspace, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission(permissions, permission) {
+		a.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Str("permission", string(permission)).Msg("Permission denied")
+		return nil, &ForbiddenError{Message: fmt.Sprintf("User does not have the %s permission in this workspace", permission)}
+	}
+	return workspace, nil
+}
+
+func (a *authorizer) AuthorizeResource(ctx context.Context, userID int, resource models.ResourceType, id int, permission models.Permission) error {
+	workspaceID, err := a.workspaceIDOf(ctx, resource, id)
+	if err != nil {
+		return err
+	}
+	_, err = a.Authorize(ctx, userID, workspaceID, permission)
+	return err
+}
+
+func (a *authorizer) AuthorizeMember(ctx context.Context, userID int, resource models.ResourceType, id int) error {
+	workspaceID, err := a.workspaceIDOf(ctx, resource, id)
+	if err != nil {
+		return err
+	}
+	if _, err := a.workspace(ctx, workspaceID); err != nil {
+		return err
+	}
+	member, err := a.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		a.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Str("resource", string(resource)).Int("resource_id", id).Msg("Access by non-member denied")
+		return &ForbiddenError{Message: "User is not a member of this workspace"}
+	}
+	return nil
+}
+
+func (a *authorizer) Permissions(ctx context.Context, userID, workspaceID int) ([]models.Permission, bool, error) {
+	workspace, err := a.workspace(ctx, workspaceID)
+	if err != nil {
+		return nil, false, err
+	}
+	member, err := a.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if member == nil {
+		return nil, false, nil
+	}
+	permissions, err := a.memberPermissions(ctx, workspace, member)
+	if err != nil {
+		return nil, false, err
+	}
+	return permissions, true, nil
+}
+
+func (a *authorizer) MembersWith(ctx context.Context, workspaceID int, permission models.Permission) ([]models.WorkspaceMember, error) {
+	workspace, err := a.workspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := a.workspaceMemberRepo.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := a.roleRepo.GetRolesForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	customRoles := make(map[int]*models.WorkspaceRole, len(roles))
+	for i := range roles {
+		customRoles[roles[i].ID] = &roles[i]
+	}
+
+	var holders []models.WorkspaceMember
+	for i := range members {
+		var customRole *models.WorkspaceRole
+		if members[i].CustomRoleID != nil {
+			customRole = customRoles[*members[i].CustomRoleID]
+		}
+		if hasPermission(resolvePermissions(workspace, &members[i], customRole), permission) {
+			holders = append(holders, members[i])
+		}
+	}
+	return holders, nil
+}
+
+func (a *authorizer) AuthorizeGrant(ctx context.Context, granterID, workspaceID int, role models.UserRole, customRoleID *int) error {
+	workspace, err := a.workspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	granted := models.RolePermissions[role]
+	if customRoleID != nil {
+		customRole, err := a.roleRepo.GetRole(ctx, *customRoleID)
+		if err != nil {
+			return err
+		}
+		if customRole == nil || customRole.WorkspaceID != workspaceID {
+			return NewNotFoundError("Role not found")
+		}
+		granted = permissionsOf(customRole)
+	}
+
+	permissions, err := a.permissions(ctx, workspace, granterID)
+	if err != nil {
+		return err
+	}
+	if err := checkGrant(permissions, role, customRoleID != nil, granted); err != nil {
+		a.log.Warn().Int("workspace_id", workspaceID).Int("user_id", granterID).Str("role", role.String()).Msg("Role grant denied")
+		return err
+	}
+	return nil
+}
+
+// workspaceIDOf finds the workspace a resource belongs to. Attachments are reached through the
+// message they are attached to.
+func (a *authorizer) workspaceIDOf(ctx context.Context, resource models.ResourceType, id int) (int, error) {
+	if resource == models.ResourceAttachment {
+		attachment, err := a.attachmentRepo.GetAttachmentByID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		if attachment == nil {
+			return 0, NewNotFoundError("Attachment not found")
+		}
+		resource, id = models.ResourceMessage, attachment.MessageID
+	}
+	return resourceWorkspaceID(ctx, a.messageRepo, a.meetingRepo, a.channelRepo, resource, id)
+}
+
+func (a *authorizer) workspace(ctx context.Context, workspaceID int) (*models.Workspace, error) {
+	workspace, err := a.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	return workspace, nil
+}
+
+// permissions returns what userID may do in the workspace; nothing if they are not a member.
+func (a *authorizer) permissions(ctx context.Context, workspace *models.Workspace, userID int) ([]models.Permission, error) {
+	member, err := a.workspaceMemberRepo.GetWorkspaceMember(ctx, workspace.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, nil
+	}
+	return a.memberPermissions(ctx, workspace, member)
+}
+
+func (a *authorizer) memberPermissions(ctx context.Context, workspace *models.Workspace, member *models.WorkspaceMember) ([]models.Permission, error) {
+	var customRole *models.WorkspaceRole
+	if member.CustomRoleID != nil {
+		var err error
+		customRole, err = a.roleRepo.GetRole(ctx, *member.CustomRoleID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolvePermissions(workspace, member, customRole), nil
+}
+
+// resolvePermissions works out what member may do in workspace. A custom role replaces the
+// permissions of the member's built-in role; if it has gone missing, the built-in role applies
+// again.
+func resolvePermissions(workspace *models.Workspace, member *models.WorkspaceMember, customRole *models.WorkspaceRole) []models.Permission {
+	if isOwner(workspace, member) {
+		return models.Permissions
+	}
+	if member.CustomRoleID != nil && customRole != nil && customRole.ID == *member.CustomRoleID && customRole.WorkspaceID == workspace.ID {
+		return permissionsOf(customRole)
+	}
+	return models.RolePermissions[member.Role]
+}
+
+// isOwner reports whether member owns workspace. Workspaces created before there were owners are
+// owned by their creator, whose role is still admin.
+func isOwner(workspace *models.Workspace, member *models.WorkspaceMember) bool {
+	return member.Role == models.Owner || member.UserID == workspace.CreatorID
+}
+
+// permissionsOf returns the known permissions of a custom role, skipping any that were since
+// dropped from the catalogue.
+func permissionsOf(role *models.WorkspaceRole) []models.Permission {
+	permissions := make([]models.Permission, 0, len(role.Permissions))
+	for _, name := range role.Permissions {
+		if validPermission(name) {
+			permissions = append(permissions, models.Permission(name))
+		}
+	}
+	return permissions
+}
+
+// checkGrant decides whether someone holding permissions may give another member role, or a
+// custom role granting granted. Ownership cannot be granted, anything beyond the member and guest
+// roles needs member.roles, and nobody can hand out a permission they do not have themselves.
+func checkGrant(permissions []models.Permission, role models.UserRole, custom bool, granted []models.Permission) error {
+	if role == models.Owner {
+		return &ForbiddenError{Message: "The owner role cannot be granted"}
+	}
+	if custom || (role != models.Member && role != models.Guest) {
+		if !hasPermission(permissions, models.PermMemberRoles) {
+			return &ForbiddenError{Message: fmt.Sprintf("User does not have the %s permission in this workspace", models.PermMemberRoles)}
+		}
+	}
+	if missing, ok := covers(permissions, granted); !ok {
+		return &ForbiddenError{Message: fmt.Sprintf("User cannot grant the %s permission without having it", missing)}
+	}
+	return nil
+}
+
+// checkAdminsRemain returns a ConflictError when userID is the only member who can manage the
+// workspace's members, so that demoting or removing them would leave nobody able to. Only members
+// still on the workspace whose accounts can sign in are counted.
+func checkAdminsRemain(ctx context.Context, authorizer Authorizer, workspaceID, userID int) error {
+	admins, err := authorizer.MembersWith(ctx, workspaceID, models.PermMemberRoles)
+	if err != nil {
+		return err
+	}
+	if lastAdmin(admins, userID) {
+		return &ConflictError{Message: "The workspace must keep at least one admin"}
+	}
+	return nil
+}
+
+// lastAdmin reports whether userID is one of admins and no other of them has a live account.
+func lastAdmin(admins []models.WorkspaceMember, userID int) bool {
+	isAdmin := false
+	for i := range admins {
+		switch {
+		case admins[i].UserID == userID:
+			isAdmin = true
+		case liveAccount(admins[i].User):
+			return false
+		}
+	}
+	return isAdmin
+}
+
+// liveAccount reports whether user can still sign in, that is, it is neither deactivated nor
+// erased. A member loaded without their user is taken to be live.
+func liveAccount(user *models.User) bool {
+	return user == nil || (user.DeactivatedAt == nil && user.ErasedAt == nil)
+}
+
+// validAssignableRole reports whether role is a built-in role members can be given.
+func validAssignableRole(role models.UserRole) bool {
+	return role == models.Admin || role == models.Member || role == models.Guest
+}
+
+func hasPermission(permissions []models.Permission, permission models.Permission) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func validPermission(name string) bool {
+	for _, p := range models.Permissions {
+		if string(p) == name {
+			return true
+		}
+	}
+	return false
+}
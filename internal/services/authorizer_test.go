@@ -0,0 +1,99 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"axis/internal/models"
+)
+
+func TestResolvePermissions(t *testing.T) {
+	workspace := &models.Workspace{ID: 1, CreatorID: 10}
+	moderatorID, otherID := 5, 6
+	moderator := &models.WorkspaceRole{ID: moderatorID, WorkspaceID: 1, Permissions: []string{"message.delete", "meeting.delete", "no.such"}}
+	foreign := &models.WorkspaceRole{ID: otherID, WorkspaceID: 2, Permissions: []string{"workspace.delete"}}
+
+	tests := []struct {
+		name       string
+		member     models.WorkspaceMember
+		customRole *models.WorkspaceRole
+		want       []models.Permission
+	}{
+		{name: "owner", member: models.WorkspaceMember{UserID: 11, Role: models.Owner}, want: models.Permissions},
+		{name: "creator from before owners", member: models.WorkspaceMember{UserID: 10, Role: models.Admin}, want: models.Permissions},
+		{name: "admin", member: models.WorkspaceMember{UserID: 11, Role: models.Admin}, want: models.RolePermissions[models.Admin]},
+		{name: "member", member: models.WorkspaceMember{UserID: 11, Role: models.Member}, want: []models.Permission{models.PermChannelCreate, models.PermMeetingCreate}},
+		{name: "guest", member: models.WorkspaceMember{UserID: 11, Role: models.Guest}, want: nil},
+		{name: "custom role", member: models.WorkspaceMember{UserID: 11, Role: models.Member, CustomRoleID: &moderatorID}, customRole: moderator, want: []models.Permission{models.PermMessageDelete, models.PermMeetingDelete}},
+		{name: "deleted custom role", member: models.WorkspaceMember{UserID: 11, Role: models.Guest, CustomRoleID: &moderatorID}, want: nil},
+		{name: "another workspace's role", member: models.WorkspaceMember{UserID: 11, Role: models.Member, CustomRoleID: &otherID}, customRole: foreign, want: []models.Permission{models.PermChannelCreate, models.PermMeetingCreate}},
+	}
+
+	for _, tt := range tests {
+		got := resolvePermissions(workspace, &tt.member, tt.customRole)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: resolvePermissions() = %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: resolvePermissions() = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCheckGrant(t *testing.T) {
+	admin := models.RolePermissions[models.Admin]
+	inviter := []models.Permission{models.PermMemberInvite, models.PermChannelCreate, models.PermMeetingCreate}
+
+	tests := []struct {
+		name        string
+		permissions []models.Permission
+		role        models.UserRole
+		custom      bool
+		granted     []models.Permission
+		wantErr     bool
+	}{
+		{name: "admin grants admin", permissions: admin, role: models.Admin, granted: admin},
+		{name: "nobody grants owner", permissions: models.Permissions, role: models.Owner, granted: models.Permissions, wantErr: true},
+		{name: "inviter grants member", permissions: inviter, role: models.Member, granted: models.RolePermissions[models.Member]},
+		{name: "inviter grants guest", permissions: inviter, role: models.Guest},
+		{name: "inviter grants admin", permissions: inviter, role: models.Admin, granted: admin, wantErr: true},
+		{name: "inviter grants custom role", permissions: inviter, role: models.Member, custom: true, granted: []models.Permission{models.PermChannelCreate}, wantErr: true},
+		{name: "admin grants custom role", permissions: admin, role: models.Member, custom: true, granted: []models.Permission{models.PermMessageDelete}},
+		{name: "admin grants workspace.delete", permissions: admin, role: models.Member, custom: true, granted: []models.Permission{models.PermWorkspaceDelete}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := checkGrant(tt.permissions, tt.role, tt.custom, tt.granted)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkGrant() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLastAdmin(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		admins []models.WorkspaceMember
+		userID int
+		want   bool
+	}{
+		{name: "only admin", admins: []models.WorkspaceMember{{UserID: 1}}, userID: 1, want: true},
+		{name: "another admin remains", admins: []models.WorkspaceMember{{UserID: 1}, {UserID: 2}}, userID: 1},
+		{name: "not an admin", admins: []models.WorkspaceMember{{UserID: 2}}, userID: 1},
+		{name: "no admins", userID: 1},
+		{name: "other admin deactivated", admins: []models.WorkspaceMember{{UserID: 1}, {UserID: 2, User: &models.User{DeactivatedAt: &now}}}, userID: 1, want: true},
+		{name: "other admin erased", admins: []models.WorkspaceMember{{UserID: 1}, {UserID: 2, User: &models.User{ErasedAt: &now}}}, userID: 1, want: true},
+		{name: "other admin active", admins: []models.WorkspaceMember{{UserID: 1}, {UserID: 2, User: &models.User{}}}, userID: 1},
+	}
+
+	for _, tt := range tests {
+		if got := lastAdmin(tt.admins, tt.userID); got != tt.want {
+			t.Errorf("%s: lastAdmin() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// MaxAvatarUploadSize is the largest avatar image accepted, in bytes.
+const MaxAvatarUploadSize = 5 << 20
+
+// Avatar is an image ready to be served.
+type Avatar struct {
+	ContentType string
+	Data        []byte
+	ModTime     time.Time
+}
+
+// AvatarService stores uploaded avatars in the standard sizes and falls back to a generated
+// identicon for users without one.
+type AvatarService interface {
+	SetAvatar(ctx context.Context, userID int, data []byte) (*models.User, error)
+	RemoveAvatar(ctx context.Context, userID int) (*models.User, error)
+	// GetAvatar returns the user's avatar in the standard size closest to size.
+	GetAvatar(ctx context.Context, userID, size int) (*Avatar, error)
+}
+
+type avatarService struct {
+	userRepo   repositories.UserRepo
+	avatarRepo repositories.AvatarRepo
+	log        zerolog.Logger
+}
+
+func NewAvatarService(userRepo repositories.UserRepo, avatarRepo repositories.AvatarRepo, logger zerolog.Logger) AvatarService {
+	return &avatarService{
+		userRepo:   userRepo,
+		avatarRepo: avatarRepo,
+		log:        logger,
+	}
+}
+
+func (s *avatarService) SetAvatar(ctx context.Context, userID int, data []byte) (*models.User, error) {
+	if len(data) > MaxAvatarUploadSize {
+		return nil, NewValidationError(fmt.Sprintf("Avatar must be at most %d MiB", MaxAvatarUploadSize>>20))
+	}
+	img, err := utils.DecodeAvatar(data)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnsupportedImage) {
+			return nil, NewValidationError(fmt.Sprintf("Avatar must be a PNG, JPEG or GIF image of at most %d pixels per side", utils.MaxAvatarDimension))
+		}
+		return nil, err
+	}
+
+	avatars := make([]models.UserAvatar, 0, len(utils.AvatarSizes))
+	for _, size := range utils.AvatarSizes {
+		var buf bytes.Buffer
+		if err := utils.EncodePNG(&buf, utils.ResizeSquare(img, size)); err != nil {
+			s.log.Error().Err(err).Int("user_id", userID).Int("size", size).Msg("Failed to encode avatar.")
+			return nil, err
+		}
+		avatars = append(avatars, models.UserAvatar{
+			UserID:      userID,
+			Size:        size,
+			ContentType: "image/png",
+			Data:        buf.Bytes(),
+		})
+	}
+
+	if err := s.avatarRepo.ReplaceAvatars(ctx, userID, avatars, time.Now()); err != nil {
+		return nil, err
+	}
+	s.log.Info().Int("user_id", userID).Msg("Avatar updated.")
+	return s.getUser(ctx, userID)
+}
+
+func (s *avatarService) RemoveAvatar(ctx context.Context, userID int) (*models.User, error) {
+	if err := s.avatarRepo.DeleteAvatars(ctx, userID); err != nil {
+		return nil, err
+	}
+	s.log.Info().Int("user_id", userID).Msg("Avatar removed.")
+	return s.getUser(ctx, userID)
+}
+
+func (s *avatarService) GetAvatar(ctx context.Context, userID, size int) (*Avatar, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	size = utils.AvatarSize(size)
+
+	if user.AvatarUpdatedAt != nil {
+		stored, err := s.avatarRepo.GetAvatar(ctx, userID, size)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			return &Avatar{ContentType: stored.ContentType, Data: stored.Data, ModTime: *user.AvatarUpdatedAt}, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := utils.EncodePNG(&buf, utils.Identicon(strconv.Itoa(user.ID), size)); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to encode identicon.")
+		return nil, err
+	}
+	return &Avatar{ContentType: "image/png", Data: buf.Bytes(), ModTime: user.CreatedAt}, nil
+}
+
+func (s *avatarService) getUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewNotFoundError("User not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
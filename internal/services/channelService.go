@@ -22,19 +22,25 @@ type channelService struct {
 	channelRepo         repositories.ChannelRepo
 	channelMemberRepo   repositories.ChannelMemberRepo
 	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	authorizer          Authorizer
 	log                 zerolog.Logger
 }
 
-func NewChannelService(cr repositories.ChannelRepo, cmr repositories.ChannelMemberRepo, wmr repositories.WorkspaceMemberRepo, logger zerolog.Logger) ChannelService {
+func NewChannelService(cr repositories.ChannelRepo, cmr repositories.ChannelMemberRepo, wmr repositories.WorkspaceMemberRepo, authorizer Authorizer, logger zerolog.Logger) ChannelService {
 	return &channelService{
 		channelRepo:         cr,
 		channelMemberRepo:   cmr,
 		workspaceMemberRepo: wmr,
+		authorizer:          authorizer,
 		log:                 logger,
 	}
 }
 
 func (s *channelService) CreateChannel(ctx context.Context, channel *models.Channel) (*models.Channel, error) {
+	if _, err := s.authorizer.Authorize(ctx, channel.CreatorID, channel.WorkspaceID, models.PermChannelCreate); err != nil {
+		return nil, err
+	}
+
 	err := s.channelRepo.CreateChannel(ctx, channel)
 	if err != nil {
 		s.log.Error().Err(err).Str("channel_name", channel.Name).Msg("Failed to create channel")
@@ -123,29 +129,19 @@ func (s *channelService) UpdateChannel(ctx context.Context, userID int, channel
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.log.Info().Int("channel_id", channel.ID).Msg("Channel not found for update")
-			return nil, nil
+			return nil, NewNotFoundError("Channel not found")
 		}
 		s.log.Error().Err(err).Int("channel_id", channel.ID).Msg("Failed to get channel for update")
 		return nil, err
 	}
+	if existingChannel == nil {
+		return nil, NewNotFoundError("Channel not found")
+	}
 
 	if existingChannel.CreatorID != int(userID) {
-		isWorkspaceAdmin, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, existingChannel.WorkspaceID, int(userID))
-		if err != nil {
-			s.log.Error().Err(err).Int("workspace_id", existingChannel.WorkspaceID).Int("user_id", int(userID)).Msg("Failed to check workspace membership for channel update")
-			return nil, err
-		}
-		if !isWorkspaceAdmin {
-			return nil, &ForbiddenError{Message: "User not authorized to update this channel"}
-		}
-		member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, existingChannel.WorkspaceID, int(userID))
-		if err != nil && err != sql.ErrNoRows {
-			s.log.Error().Err(err).Int("workspace_id", existingChannel.WorkspaceID).Int("user_id", int(userID)).Msg("Failed to get workspace member role for channel update")
+		if _, err := s.authorizer.Authorize(ctx, userID, existingChannel.WorkspaceID, models.PermChannelUpdate); err != nil {
 			return nil, err
 		}
-		if member == nil || member.Role != models.Admin {
-			return nil, &ForbiddenError{Message: "User not authorized to update this channel"}
-		}
 	}
 
 	existingChannel.Name = channel.Name
@@ -165,29 +161,19 @@ func (s *channelService) DeleteChannel(ctx context.Context, userID int, id int)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.log.Info().Int("channel_id", id).Msg("Channel not found for deletion")
-			return nil
+			return NewNotFoundError("Channel not found")
 		}
 		s.log.Error().Err(err).Int("channel_id", id).Msg("Failed to get channel for deletion")
 		return err
 	}
+	if existingChannel == nil {
+		return NewNotFoundError("Channel not found")
+	}
 
 	if existingChannel.CreatorID != int(userID) {
-		isWorkspaceAdmin, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, existingChannel.WorkspaceID, int(userID))
-		if err != nil {
-			s.log.Error().Err(err).Int("workspace_id", existingChannel.WorkspaceID).Int("user_id", int(userID)).Msg("Failed to check workspace membership for channel deletion")
+		if _, err := s.authorizer.Authorize(ctx, userID, existingChannel.WorkspaceID, models.PermChannelDelete); err != nil {
 			return err
 		}
-		if !isWorkspaceAdmin {
-			return &ForbiddenError{Message: "User not authorized to delete this channel"}
-		}
-		member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, existingChannel.WorkspaceID, int(userID))
-		if err != nil && err != sql.ErrNoRows {
-			s.log.Error().Err(err).Int("workspace_id", existingChannel.WorkspaceID).Int("user_id", int(userID)).Msg("Failed to get workspace member role for channel deletion")
-			return err
-		}
-		if member == nil || member.Role != models.Admin {
-			return &ForbiddenError{Message: "User not authorized to delete this channel"}
-		}
 	}
 
 	err = s.channelRepo.DeleteChannel(ctx, id)
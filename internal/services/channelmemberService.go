@@ -9,25 +9,50 @@ import (
 )
 
 type ChannelMemberService interface {
-	AddMemberToChannel(ctx context.Context, channelID, userID int) (*models.ChannelMember, error)
-	RemoveMemberFromChannel(ctx context.Context, channelID, userID int) error
+	// AddMemberToChannel adds a member of the channel's workspace to it. Only the channel's creator
+	// and holders of channel.update can.
+	AddMemberToChannel(ctx context.Context, actorID, channelID, userID int) (*models.ChannelMember, error)
+	// RemoveMemberFromChannel removes userID from the channel. Anyone can leave; removing someone else
+	// is limited like adding them, and the channel's creator can only leave.
+	RemoveMemberFromChannel(ctx context.Context, actorID, channelID, userID int) error
 	GetChannelMembers(ctx context.Context, channelID int) ([]models.ChannelMember, error)
 }
 
 type channelMemberService struct {
-	channelMemberRepo repositories.ChannelMemberRepo
-	log               zerolog.Logger
+	channelMemberRepo   repositories.ChannelMemberRepo
+	channelRepo         repositories.ChannelRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	authorizer          Authorizer
+	log                 zerolog.Logger
 }
 
-func NewChannelMemberService(cmr repositories.ChannelMemberRepo, logger zerolog.Logger) ChannelMemberService {
+func NewChannelMemberService(cmr repositories.ChannelMemberRepo, cr repositories.ChannelRepo, wmr repositories.WorkspaceMemberRepo, authorizer Authorizer, logger zerolog.Logger) ChannelMemberService {
 	return &channelMemberService{
-		channelMemberRepo: cmr,
-		log:               logger,
+		channelMemberRepo:   cmr,
+		channelRepo:         cr,
+		workspaceMemberRepo: wmr,
+		authorizer:          authorizer,
+		log:                 logger,
 	}
 }
 
-func (s *channelMemberService) AddMemberToChannel(ctx context.Context, channelID, userID int) (*models.ChannelMember, error) {
-	err := s.channelMemberRepo.AddMemberToChannel(ctx, channelID, userID)
+func (s *channelMemberService) AddMemberToChannel(ctx context.Context, actorID, channelID, userID int) (*models.ChannelMember, error) {
+	channel, err := s.getChannel(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeManage(ctx, actorID, channel); err != nil {
+		return nil, err
+	}
+	isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, channel.WorkspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, NewValidationError("User is not a member of this channel's workspace")
+	}
+
+	err = s.channelMemberRepo.AddMemberToChannel(ctx, channelID, userID)
 	if err != nil {
 		s.log.Error().Err(err).Int("channel_id", channelID).Int("user_id", userID).Msg("Failed to add member to channel")
 		return nil, err
@@ -40,8 +65,21 @@ func (s *channelMemberService) AddMemberToChannel(ctx context.Context, channelID
 	return channelMember, nil
 }
 
-func (s *channelMemberService) RemoveMemberFromChannel(ctx context.Context, channelID, userID int) error {
-	err := s.channelMemberRepo.RemoveMemberFromChannel(ctx, channelID, userID)
+func (s *channelMemberService) RemoveMemberFromChannel(ctx context.Context, actorID, channelID, userID int) error {
+	channel, err := s.getChannel(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if actorID != userID {
+		if userID == channel.CreatorID {
+			return &ForbiddenError{Message: "The channel's creator cannot be removed"}
+		}
+		if err := s.authorizeManage(ctx, actorID, channel); err != nil {
+			return err
+		}
+	}
+
+	err = s.channelMemberRepo.RemoveMemberFromChannel(ctx, channelID, userID)
 	if err != nil {
 		s.log.Error().Err(err).Int("channel_id", channelID).Int("user_id", userID).Msg("Failed to remove member from channel")
 		return err
@@ -60,3 +98,24 @@ func (s *channelMemberService) GetChannelMembers(ctx context.Context, channelID
 	s.log.Debug().Int("channel_id", channelID).Int("members_count", len(members)).Msg("Received channel members from repo")
 	return members, nil
 }
+
+func (s *channelMemberService) getChannel(ctx context.Context, channelID int) (*models.Channel, error) {
+	channel, err := s.channelRepo.GetChannelByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, NewNotFoundError("Channel not found")
+	}
+	return channel, nil
+}
+
+// authorizeManage returns a ForbiddenError unless actorID may manage the channel's members: its
+// creator, or someone who may edit other people's channels.
+func (s *channelMemberService) authorizeManage(ctx context.Context, actorID int, channel *models.Channel) error {
+	if channel.CreatorID == actorID {
+		return nil
+	}
+	_, err := s.authorizer.Authorize(ctx, actorID, channel.WorkspaceID, models.PermChannelUpdate)
+	return err
+}
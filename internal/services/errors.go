@@ -1,6 +1,9 @@
 package services
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // NotFoundError is returned when a requested resource is not found.
 type NotFoundError struct {
@@ -46,3 +49,40 @@ func NewUnauthorizedError(message string) *UnauthorizedError {
 	return &UnauthorizedError{Message: message}
 }
 
+// ValidationError is returned when the request is well-formed but its content is rejected.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation failed: %s", e.Message)
+}
+
+func NewValidationError(message string) *ValidationError {
+	return &ValidationError{Message: message}
+}
+
+// TooManyRequestsError is returned when an action is throttled.
+type TooManyRequestsError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return fmt.Sprintf("too many requests: %s", e.Message)
+}
+
+func NewTooManyRequestsError(message string, retryAfter time.Duration) *TooManyRequestsError {
+	return &TooManyRequestsError{Message: message, RetryAfter: retryAfter}
+}
+
+// QuotaExceededError is returned when an action would take a workspace over a limit of its plan.
+type QuotaExceededError struct {
+	Quota   string
+	Limit   int64
+	Message string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: %s", e.Message)
+}
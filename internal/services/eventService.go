@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+// EventService pushes account-level events, such as status changes, to the WebSocket connections
+// of the users they concern. Each user may be connected from several devices at once.
+type EventService interface {
+	Register(client *utils.Client)
+	Unregister(client *utils.Client)
+	PublishToUsers(userIDs []int, eventType string, data interface{})
+	// PublishToWorkspacePeers sends the event to everyone sharing a workspace with userID.
+	PublishToWorkspacePeers(ctx context.Context, userID int, eventType string, data interface{}) error
+}
+
+type eventService struct {
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	log                 zerolog.Logger
+	clients             map[int]map[*utils.Client]struct{}
+	mu                  sync.Mutex
+}
+
+func NewEventService(wmr repositories.WorkspaceMemberRepo, logger zerolog.Logger) EventService {
+	return &eventService{
+		workspaceMemberRepo: wmr,
+		log:                 logger,
+		clients:             make(map[int]map[*utils.Client]struct{}),
+	}
+}
+
+func (s *eventService) Register(client *utils.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.clients[client.ID] == nil {
+		s.clients[client.ID] = make(map[*utils.Client]struct{})
+	}
+	s.clients[client.ID][client] = struct{}{}
+}
+
+// Unregister forgets client and closes its message channel. Calling it again is harmless.
+func (s *eventService) Unregister(client *utils.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(client)
+}
+
+func (s *eventService) remove(client *utils.Client) {
+	conns := s.clients[client.ID]
+	if _, ok := conns[client]; !ok {
+		return
+	}
+	delete(conns, client)
+	if len(conns) == 0 {
+		delete(s.clients, client.ID)
+	}
+	close(client.Message)
+}
+
+func (s *eventService) PublishToUsers(userIDs []int, eventType string, data interface{}) {
+	message, err := json.Marshal(models.WSMessage{Type: eventType, Data: data})
+	if err != nil {
+		s.log.Error().Err(err).Str("event_type", eventType).Msg("Failed to encode event")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		for client := range s.clients[userID] {
+			select {
+			case client.Message <- message:
+			default:
+				// A client this far behind is dropped, as the chat hubs do.
+				s.log.Warn().Int("user_id", userID).Msg("Dropping slow event client")
+				s.remove(client)
+			}
+		}
+	}
+}
+
+func (s *eventService) PublishToWorkspacePeers(ctx context.Context, userID int, eventType string, data interface{}) error {
+	peerIDs, err := s.workspaceMemberRepo.GetWorkspacePeerIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// The user's own devices are told too, even outside any workspace.
+	peerIDs = append(peerIDs, userID)
+	s.PublishToUsers(peerIDs, eventType, data)
+	return nil
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"axis/internal/mailer"
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultInvitationTTL = 7 * 24 * time.Hour
+	maxInvitationTTL     = 30 * 24 * time.Hour
+)
+
+type InvitationService interface {
+	CreateInvitation(ctx context.Context, adminID, workspaceID int, form models.CreateInvitationModel) (*models.WorkspaceInvitation, error)
+	ListInvitations(ctx context.Context, adminID, workspaceID int) ([]models.WorkspaceInvitation, error)
+	RevokeInvitation(ctx context.Context, adminID, workspaceID, invitationID int) error
+	PreviewInvitation(ctx context.Context, token string) (*models.InvitationPreview, error)
+	AcceptInvitation(ctx context.Context, userID int, token string) (*models.WorkspaceMember, error)
+}
+
+type invitationService struct {
+	invitationRepo         repositories.InvitationRepo
+	authorizer             Authorizer
+	userRepo               repositories.UserRepo
+	workspaceMemberService WorkspaceMemberService
+	mailer                 mailer.Mailer
+	log                    zerolog.Logger
+}
+
+func NewInvitationService(ir repositories.InvitationRepo, authorizer Authorizer, ur repositories.UserRepo, wms WorkspaceMemberService, m mailer.Mailer, logger zerolog.Logger) InvitationService {
+	return &invitationService{
+		invitationRepo:         ir,
+		authorizer:             authorizer,
+		userRepo:               ur,
+		workspaceMemberService: wms,
+		mailer:                 m,
+		log:                    logger,
+	}
+}
+
+func (s *invitationService) CreateInvitation(ctx context.Context, adminID, workspaceID int, form models.CreateInvitationModel) (*models.WorkspaceInvitation, error) {
+	workspace, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberInvite)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := newInvitation(form, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizer.AuthorizeGrant(ctx, adminID, workspaceID, invitation.Role, nil); err != nil {
+		return nil, err
+	}
+	invitation.WorkspaceID = workspaceID
+	invitation.CreatedByID = adminID
+
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to generate invitation token")
+		return nil, err
+	}
+	invitation.TokenHash = utils.HashToken(token)
+	if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+	invitation.Token = token
+	invitation.Link = utils.AppURL("/invitations/accept", url.Values{"token": {token}})
+
+	if invitation.Email != "" {
+		if err := s.sendInvitation(ctx, workspace, adminID, invitation); err != nil {
+			// An invitation nobody received is of no use; take it back so the admin can retry.
+			if err := s.invitationRepo.RevokeInvitation(ctx, invitation.ID); err != nil {
+				s.log.Error().Err(err).Int("invitation_id", invitation.ID).Msg("Failed to revoke unsent invitation")
+			}
+			return nil, err
+		}
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Int("invitation_id", invitation.ID).Int("admin_id", adminID).Msg("Workspace invitation created")
+	return invitation, nil
+}
+
+// newInvitation validates form and applies the defaults: invitations expire after a week, and
+// ones sent to an email address can be used once.
+func newInvitation(form models.CreateInvitationModel, now time.Time) (*models.WorkspaceInvitation, error) {
+	invitation := &models.WorkspaceInvitation{
+		Role:      models.Member,
+		MaxUses:   form.MaxUses,
+		ExpiresAt: now.Add(defaultInvitationTTL),
+	}
+	if form.Role != nil {
+		invitation.Role = *form.Role
+	}
+	if !validAssignableRole(invitation.Role) {
+		return nil, NewValidationError("role must be 0 (admin), 1 (member) or 3 (guest)")
+	}
+	if form.MaxUses != nil && *form.MaxUses < 1 {
+		return nil, NewValidationError("max_uses must be at least 1")
+	}
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(now) {
+			return nil, NewValidationError("expires_at must be in the future")
+		}
+		if form.ExpiresAt.Sub(now) > maxInvitationTTL {
+			return nil, NewValidationError("Invitations can be valid for at most 30 days")
+		}
+		invitation.ExpiresAt = *form.ExpiresAt
+	}
+
+	if email := strings.TrimSpace(form.Email); email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return nil, NewValidationError("Invalid email address")
+		}
+		invitation.Email = strings.ToLower(email)
+		single := 1
+		invitation.MaxUses = &single
+	}
+	return invitation, nil
+}
+
+func (s *invitationService) sendInvitation(ctx context.Context, workspace *models.Workspace, adminID int, invitation *models.WorkspaceInvitation) error {
+	admin, err := s.userRepo.GetUserByID(ctx, adminID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", adminID).Msg("Failed to get inviting user")
+		return err
+	}
+
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("%s invited you to %s on Axis", admin.Name, workspace.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join the %s workspace on Axis. Open the link below to accept:\n\n%s\n\nThe invitation expires on %s and only works for this email address.\n",
+			admin.Name, workspace.Name, invitation.Link, invitation.ExpiresAt.UTC().Format("January 2, 2006 at 15:04 MST")),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("invitation_id", invitation.ID).Msg("Failed to send invitation email")
+		return err
+	}
+	return nil
+}
+
+func (s *invitationService) ListInvitations(ctx context.Context, adminID, workspaceID int) ([]models.WorkspaceInvitation, error) {
+	if _, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberInvite); err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.GetInvitationsForWorkspace(ctx, workspaceID)
+}
+
+func (s *invitationService) RevokeInvitation(ctx context.Context, adminID, workspaceID, invitationID int) error {
+	if _, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberInvite); err != nil {
+		return err
+	}
+
+	invitation, err := s.invitationRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation == nil || invitation.WorkspaceID != workspaceID {
+		return NewNotFoundError("Invitation not found")
+	}
+	if err := s.invitationRepo.RevokeInvitation(ctx, invitationID); err != nil {
+		return err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Int("invitation_id", invitationID).Int("admin_id", adminID).Msg("Workspace invitation revoked")
+	return nil
+}
+
+func (s *invitationService) PreviewInvitation(ctx context.Context, token string) (*models.InvitationPreview, error) {
+	invitation, err := s.usableInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return &models.InvitationPreview{
+		WorkspaceID:   invitation.WorkspaceID,
+		WorkspaceName: invitation.Workspace.Name,
+		Role:          invitation.Role,
+		Email:         invitation.Email,
+		ExpiresAt:     invitation.ExpiresAt,
+	}, nil
+}
+
+func (s *invitationService) AcceptInvitation(ctx context.Context, userID int, token string) (*models.WorkspaceMember, error) {
+	invitation, err := s.usableInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.workspaceMemberService.JoinWorkspace(ctx, invitation.WorkspaceID, userID, token)
+}
+
+func (s *invitationService) usableInvitation(ctx context.Context, token string) (*models.WorkspaceInvitation, error) {
+	if token == "" {
+		return nil, NewValidationError("token is required")
+	}
+	invitation, err := s.invitationRepo.GetInvitationByTokenHash(ctx, utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.Workspace == nil || !invitation.Usable(time.Now()) {
+		return nil, NewNotFoundError("The invitation is invalid or has expired")
+	}
+	return invitation, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"axis/internal/models"
+)
+
+func TestNewInvitation(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	admin, member, owner, unknown := models.Admin, models.Member, models.Owner, models.UserRole(7)
+	zero, one, five := 0, 1, 5
+	tomorrow, soon, late := now.Add(24*time.Hour), now.Add(-time.Minute), now.Add(31*24*time.Hour)
+
+	tests := []struct {
+		name        string
+		form        models.CreateInvitationModel
+		wantErr     bool
+		wantRole    models.UserRole
+		wantMaxUses *int
+		wantExpires time.Time
+	}{
+		{name: "defaults", form: models.CreateInvitationModel{}, wantRole: models.Member, wantExpires: now.Add(defaultInvitationTTL)},
+		{name: "admin link", form: models.CreateInvitationModel{Role: &admin, MaxUses: &five, ExpiresAt: &tomorrow}, wantRole: models.Admin, wantMaxUses: &five, wantExpires: tomorrow},
+		{name: "email is single use", form: models.CreateInvitationModel{Email: "Ada@Example.com", Role: &member, MaxUses: &five}, wantRole: models.Member, wantMaxUses: &one, wantExpires: now.Add(defaultInvitationTTL)},
+		{name: "unknown role", form: models.CreateInvitationModel{Role: &unknown}, wantErr: true},
+		{name: "owner role", form: models.CreateInvitationModel{Role: &owner}, wantErr: true},
+		{name: "no uses", form: models.CreateInvitationModel{MaxUses: &zero}, wantErr: true},
+		{name: "expired", form: models.CreateInvitationModel{ExpiresAt: &soon}, wantErr: true},
+		{name: "too long", form: models.CreateInvitationModel{ExpiresAt: &late}, wantErr: true},
+		{name: "bad email", form: models.CreateInvitationModel{Email: "Ada <ada@example.com>"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		invitation, err := newInvitation(tt.form, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: newInvitation() = %+v, want an error", tt.name, invitation)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: newInvitation() error = %v", tt.name, err)
+			continue
+		}
+		if invitation.Role != tt.wantRole || !invitation.ExpiresAt.Equal(tt.wantExpires) {
+			t.Errorf("%s: role %v expiring %v, want %v expiring %v", tt.name, invitation.Role, invitation.ExpiresAt, tt.wantRole, tt.wantExpires)
+		}
+		if (invitation.MaxUses == nil) != (tt.wantMaxUses == nil) || (tt.wantMaxUses != nil && *invitation.MaxUses != *tt.wantMaxUses) {
+			t.Errorf("%s: max uses %v, want %v", tt.name, invitation.MaxUses, tt.wantMaxUses)
+		}
+	}
+}
+
+func TestInvitationUsable(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	one := 1
+
+	tests := []struct {
+		name       string
+		invitation models.WorkspaceInvitation
+		want       bool
+	}{
+		{"fresh", models.WorkspaceInvitation{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", models.WorkspaceInvitation{ExpiresAt: now}, false},
+		{"revoked", models.WorkspaceInvitation{ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, false},
+		{"used up", models.WorkspaceInvitation{ExpiresAt: now.Add(time.Hour), MaxUses: &one, Uses: 1}, false},
+		{"uses left", models.WorkspaceInvitation{ExpiresAt: now.Add(time.Hour), MaxUses: &one}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.invitation.Usable(now); got != tt.want {
+			t.Errorf("%s: Usable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"axis/internal/mailer"
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// loginFailureWindow is how long failed attempts are remembered after the last one.
+	loginFailureWindow = 24 * time.Hour
+	loginBackoffBase   = time.Second
+	loginBackoffMax    = 5 * time.Minute
+	loginLockout       = 15 * time.Minute
+	loginLockoutMax    = 24 * time.Hour
+)
+
+// loginLimit describes when failed attempts for a key start to slow down and then lock out logins.
+// Past backoffAfter failures every attempt has to wait twice as long as the previous one; from
+// lockoutAfter failures the key is locked, again for twice as long with every further failure.
+type loginLimit struct {
+	kind         string
+	backoffAfter int
+	lockoutAfter int
+}
+
+var (
+	accountLoginLimit = loginLimit{kind: "account", backoffAfter: 3, lockoutAfter: 10}
+	// Many users can share an address behind a NAT, so the per-IP limits are looser.
+	ipLoginLimit = loginLimit{kind: "ip", backoffAfter: 20, lockoutAfter: 100}
+)
+
+// LoginAttemptService protects password and second factor checks against guessing by tracking
+// failures per account and per client IP.
+type LoginAttemptService interface {
+	// Check returns a TooManyRequestsError if a login for email from ipAddress must not be tried yet.
+	Check(ctx context.Context, email, ipAddress string) error
+	// RecordFailure counts a failed attempt. user is nil when the email belongs to no account.
+	RecordFailure(ctx context.Context, email, ipAddress string, user *models.User) error
+	// RecordSuccess clears the account's failures once the user has proven who they are.
+	RecordSuccess(ctx context.Context, email string) error
+}
+
+type loginAttemptService struct {
+	store             repositories.LoginAttemptStore
+	securityEventRepo repositories.SecurityEventRepo
+	mailer            mailer.Mailer
+	log               zerolog.Logger
+}
+
+func NewLoginAttemptService(store repositories.LoginAttemptStore, ser repositories.SecurityEventRepo, m mailer.Mailer, logger zerolog.Logger) LoginAttemptService {
+	return &loginAttemptService{
+		store:             store,
+		securityEventRepo: ser,
+		mailer:            m,
+		log:               logger,
+	}
+}
+
+func (s *loginAttemptService) Check(ctx context.Context, email, ipAddress string) error {
+	now := time.Now()
+	for _, k := range []struct {
+		key   string
+		limit loginLimit
+	}{
+		{accountAttemptKey(email), accountLoginLimit},
+		{ipAttemptKey(ipAddress), ipLoginLimit},
+	} {
+		attempt, err := s.store.GetAttempt(ctx, k.key)
+		if err != nil {
+			return err
+		}
+		if attempt == nil {
+			continue
+		}
+		if wait := k.limit.blockedUntil(attempt).Sub(now); wait > 0 {
+			s.log.Warn().Str("limit", k.limit.kind).Int("failures", attempt.Failures).Dur("retry_after", wait).Msg("Login attempt blocked.")
+			return NewTooManyRequestsError("Too many failed login attempts, try again later", wait)
+		}
+	}
+	return nil
+}
+
+func (s *loginAttemptService) RecordFailure(ctx context.Context, email, ipAddress string, user *models.User) error {
+	now := time.Now()
+
+	attempt, err := s.store.RecordFailure(ctx, accountAttemptKey(email), now, loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if until, locked := accountLoginLimit.lockUntil(attempt, now); locked {
+		if err := s.store.SetLockedUntil(ctx, attempt.Key, until); err != nil {
+			return err
+		}
+		s.accountLocked(ctx, user, ipAddress, attempt.Failures, until)
+	}
+
+	attempt, err = s.store.RecordFailure(ctx, ipAttemptKey(ipAddress), now, loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if until, locked := ipLoginLimit.lockUntil(attempt, now); locked {
+		if err := s.store.SetLockedUntil(ctx, attempt.Key, until); err != nil {
+			return err
+		}
+		s.log.Warn().Str("ip_address", ipAddress).Int("failures", attempt.Failures).Time("locked_until", until).Msg("Client IP locked out after failed logins.")
+		s.recordEvent(ctx, &models.SecurityEvent{
+			Type:      models.SecurityEventIPLocked,
+			IPAddress: ipAddress,
+			Details:   map[string]any{"failures": attempt.Failures, "locked_until": until},
+		})
+	}
+	return nil
+}
+
+func (s *loginAttemptService) RecordSuccess(ctx context.Context, email string) error {
+	return s.store.Reset(ctx, accountAttemptKey(email))
+}
+
+// accountLocked records the lockout and tells the account owner, who may not be the one guessing.
+func (s *loginAttemptService) accountLocked(ctx context.Context, user *models.User, ipAddress string, failures int, until time.Time) {
+	event := &models.SecurityEvent{
+		Type:      models.SecurityEventAccountLocked,
+		IPAddress: ipAddress,
+		Details:   map[string]any{"failures": failures, "locked_until": until},
+	}
+	if user == nil {
+		s.log.Warn().Int("failures", failures).Time("locked_until", until).Msg("Login locked for an unknown email after failed attempts.")
+		s.recordEvent(ctx, event)
+		return
+	}
+
+	event.UserID = &user.ID
+	s.log.Warn().Int("user_id", user.ID).Int("failures", failures).Time("locked_until", until).Msg("Account locked out after failed logins.")
+	s.recordEvent(ctx, event)
+
+	body := fmt.Sprintf("Hi %s,\n\nThere were %d failed attempts to sign in to your Axis account, most recently from %s. "+
+		"Sign-in has been locked until %s.\n\nIf this was not you, consider changing your password:\n\n%s\n",
+		user.Name, failures, ipAddress, until.UTC().Format(time.RFC1123), utils.AppURL("/forgot-password", nil))
+	err := s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Axis account was locked",
+		Body:    body,
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send lockout notice.")
+	}
+}
+
+// recordEvent stores a security event. Failing to store it must not turn away the request that
+// caused it, so errors are only logged.
+func (s *loginAttemptService) recordEvent(ctx context.Context, event *models.SecurityEvent) {
+	if err := s.securityEventRepo.CreateEvent(ctx, event); err != nil {
+		s.log.Error().Err(err).Str("type", string(event.Type)).Msg("Failed to record security event.")
+	}
+}
+
+// blockedUntil returns the earliest time the next attempt may be made.
+func (l loginLimit) blockedUntil(attempt *models.LoginAttempt) time.Time {
+	var until time.Time
+	if attempt.LockedUntil != nil {
+		until = *attempt.LockedUntil
+	}
+	if attempt.Failures >= l.backoffAfter {
+		backoff := doubled(loginBackoffBase, attempt.Failures-l.backoffAfter, loginBackoffMax)
+		if t := attempt.LastFailureAt.Add(backoff); t.After(until) {
+			until = t
+		}
+	}
+	return until
+}
+
+// lockUntil reports whether attempt has reached the lockout threshold and for how long it locks.
+func (l loginLimit) lockUntil(attempt *models.LoginAttempt, now time.Time) (time.Time, bool) {
+	if attempt.Failures < l.lockoutAfter {
+		return time.Time{}, false
+	}
+	return now.Add(doubled(loginLockout, attempt.Failures-l.lockoutAfter, loginLockoutMax)), true
+}
+
+// doubled returns base doubled n times, capped at max.
+func doubled(base time.Duration, n int, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// accountAttemptKey identifies an account by a hash of its email, so unknown addresses are
+// throttled exactly like real ones and the store holds no plain addresses.
+func accountAttemptKey(email string) string {
+	return "account:" + utils.HashToken(strings.ToLower(strings.TrimSpace(email)))
+}
+
+func ipAttemptKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"axis/internal/mailer"
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+type fakeSecurityEventRepo struct {
+	events []models.SecurityEvent
+}
+
+func (r *fakeSecurityEventRepo) CreateEvent(ctx context.Context, event *models.SecurityEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func TestLoginAttemptServiceLocksAccount(t *testing.T) {
+	ctx := context.Background()
+	store := repositories.NewMemoryLoginAttemptStore()
+	events := &fakeSecurityEventRepo{}
+	mail := mailer.NewMemoryMailer()
+	s := NewLoginAttemptService(store, events, mail, zerolog.Nop())
+	user := &models.User{ID: 7, Name: "Jane", Email: "jane@example.com"}
+
+	for i := 0; i < accountLoginLimit.lockoutAfter; i++ {
+		if err := s.RecordFailure(ctx, "Jane@example.com", "203.0.113.7", user); err != nil {
+			t.Fatalf("RecordFailure returned error: %v", err)
+		}
+	}
+
+	err := s.Check(ctx, "jane@example.com", "198.51.100.1")
+	tooMany, ok := err.(*TooManyRequestsError)
+	if !ok {
+		t.Fatalf("Check after %d failures = %v, want TooManyRequestsError", accountLoginLimit.lockoutAfter, err)
+	}
+	if tooMany.RetryAfter < loginLockout-time.Minute {
+		t.Errorf("RetryAfter = %s, want about %s", tooMany.RetryAfter, loginLockout)
+	}
+	if len(events.events) != 1 || events.events[0].Type != models.SecurityEventAccountLocked {
+		t.Errorf("security events = %+v, want one account_locked event", events.events)
+	}
+	if len(mail.Messages()) != 1 || mail.Messages()[0].To != user.Email {
+		t.Errorf("expected a lockout notice to %s, got %+v", user.Email, mail.Messages())
+	}
+
+	if err := s.RecordSuccess(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("RecordSuccess returned error: %v", err)
+	}
+	if err := s.Check(ctx, "jane@example.com", "198.51.100.1"); err != nil {
+		t.Errorf("Check after a successful sign-in = %v, want nil", err)
+	}
+}
+
+func TestLoginAttemptServiceBacksOff(t *testing.T) {
+	ctx := context.Background()
+	s := NewLoginAttemptService(repositories.NewMemoryLoginAttemptStore(), &fakeSecurityEventRepo{}, mailer.NewMemoryMailer(), zerolog.Nop())
+
+	for i := 0; i < accountLoginLimit.backoffAfter-1; i++ {
+		s.RecordFailure(ctx, "nobody@example.com", "203.0.113.7", nil)
+		if err := s.Check(ctx, "nobody@example.com", "203.0.113.7"); err != nil {
+			t.Fatalf("Check after %d failures = %v, want nil", i+1, err)
+		}
+	}
+
+	s.RecordFailure(ctx, "nobody@example.com", "203.0.113.7", nil)
+	if _, ok := s.Check(ctx, "nobody@example.com", "203.0.113.7").(*TooManyRequestsError); !ok {
+		t.Errorf("expected backoff after %d failures", accountLoginLimit.backoffAfter)
+	}
+	if err := s.Check(ctx, "other@example.com", "198.51.100.1"); err != nil {
+		t.Errorf("Check for another account and IP = %v, want nil", err)
+	}
+}
+
+func TestLoginLimitDelaysDouble(t *testing.T) {
+	now := time.Now()
+	limit := loginLimit{backoffAfter: 3, lockoutAfter: 10}
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{6, 8 * time.Second},
+		{30, loginBackoffMax},
+	}
+	for _, tt := range tests {
+		got := limit.blockedUntil(&models.LoginAttempt{Failures: tt.failures, LastFailureAt: now})
+		if tt.want == 0 {
+			if !got.IsZero() {
+				t.Errorf("%d failures: blocked until %s, want not blocked", tt.failures, got)
+			}
+			continue
+		}
+		if d := got.Sub(now); d != tt.want {
+			t.Errorf("%d failures: backoff %s, want %s", tt.failures, d, tt.want)
+		}
+	}
+
+	if until, locked := limit.lockUntil(&models.LoginAttempt{Failures: 11}, now); !locked || until.Sub(now) != 2*loginLockout {
+		t.Errorf("11 failures: lock until %s (%v), want %s", until.Sub(now), locked, 2*loginLockout)
+	}
+}
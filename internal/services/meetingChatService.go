@@ -17,8 +17,9 @@ type MeetingChatService interface {
 	JoinMeetingChat(ctx context.Context, meetingID, userID int) error
 	LeaveMeetingChat(ctx context.Context, meetingID, userID int) error
 	SendMessage(ctx context.Context, meetingID, senderID int, parentMessageID *int, content string, messageType models.MessageType, attachments []models.SendAttachmentDetails) (*models.Message, error)
-	AddReaction(ctx context.Context, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error)
-	RemoveReaction(ctx context.Context, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error)
+	// AddReaction and RemoveReaction only accept messages of meetingID, from its participants.
+	AddReaction(ctx context.Context, meetingID, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error)
+	RemoveReaction(ctx context.Context, meetingID, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error)
 	GetMeetingMessages(ctx context.Context, meetingID int, limit, offset int) ([]models.Message, error)
 	GetOrCreateHubForMeeting(meetingID int) *utils.Hub
 	BroadcastMessage(meetingID int, message []byte)
@@ -32,18 +33,20 @@ type meetingChatService struct {
 	userRepo       repositories.UserRepo
 	attachmentRepo repositories.AttachmentRepo
 	reactionRepo   repositories.ReactionRepo
+	quotaService   QuotaService
 	log            zerolog.Logger
 	hubs           map[int]*utils.Hub
 	mu             sync.Mutex
 }
 
-func NewMeetingChatService(mr repositories.MeetingRepo, msgRepo repositories.MessageRepo, ur repositories.UserRepo, ar repositories.AttachmentRepo, rr repositories.ReactionRepo, logger zerolog.Logger) MeetingChatService {
+func NewMeetingChatService(mr repositories.MeetingRepo, msgRepo repositories.MessageRepo, ur repositories.UserRepo, ar repositories.AttachmentRepo, rr repositories.ReactionRepo, qs QuotaService, logger zerolog.Logger) MeetingChatService {
 	return &meetingChatService{
 		meetingRepo:    mr,
 		messageRepo:    msgRepo,
 		userRepo:       ur,
 		attachmentRepo: ar,
 		reactionRepo:   rr,
+		quotaService:   qs,
 		log:            logger,
 		hubs:           make(map[int]*utils.Hub),
 	}
@@ -118,6 +121,20 @@ func (s *meetingChatService) SendMessage(ctx context.Context, meetingID, senderI
 		return nil, NewUnauthorizedError("sender is not a participant in this chat")
 	}
 
+	var attachmentBytes int64
+	for _, attachment := range attachments {
+		attachmentBytes += attachment.FileSize
+	}
+	if attachmentBytes > 0 {
+		workspaceID, err := s.quotaService.WorkspaceIDOf(ctx, models.ResourceMeeting, meetingID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.quotaService.CheckStorage(ctx, workspaceID, attachmentBytes); err != nil {
+			return nil, err
+		}
+	}
+
 	message := &models.Message{
 		MeetingID:       meetingID,
 		SenderID:        senderID,
@@ -169,7 +186,11 @@ func (s *meetingChatService) SendMessage(ctx context.Context, meetingID, senderI
 }
 
 func (s *meetingChatService) GetMeetingMessages(ctx context.Context, meetingID int, limit, offset int) ([]models.Message, error) {
-	messages, err := s.messageRepo.GetMessagesByMeetingID(ctx, meetingID, limit, offset)
+	since, err := meetingHistoryCutoff(ctx, s.quotaService, meetingID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.messageRepo.GetMessagesByMeetingID(ctx, meetingID, since, limit, offset)
 	if err != nil {
 		s.log.Error().Err(err).Int("meeting_id", meetingID).Msg("Failed to retrieve messages for meeting")
 		return nil, fmt.Errorf("failed to retrieve messages: %w", err)
@@ -179,14 +200,9 @@ func (s *meetingChatService) GetMeetingMessages(ctx context.Context, meetingID i
 	return messages, nil
 }
 
-func (s *meetingChatService) AddReaction(ctx context.Context, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error) {
-	_, err := s.messageRepo.GetMessageByID(ctx, messageID)
-	if err != nil {
-		s.log.Error().Err(err).Int("message_id", messageID).Msg("Message not found for reaction")
-		if err == sql.ErrNoRows {
-			return nil, NewNotFoundError(fmt.Sprintf("Message with ID %d not found", messageID))
-		}
-		return nil, fmt.Errorf("database error: %w", err)
+func (s *meetingChatService) AddReaction(ctx context.Context, meetingID, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error) {
+	if err := s.checkReaction(ctx, meetingID, messageID, userID); err != nil {
+		return nil, err
 	}
 
 	reaction := &models.Reaction{
@@ -196,7 +212,7 @@ func (s *meetingChatService) AddReaction(ctx context.Context, messageID, userID
 		CreatedAt: time.Now(),
 	}
 
-	err = s.reactionRepo.CreateReaction(ctx, reaction)
+	err := s.reactionRepo.CreateReaction(ctx, reaction)
 	if err != nil {
 		s.log.Error().Err(err).Int("message_id", messageID).Int("user_id", userID).Str("emoji", emoji).Msg("Failed to add reaction to message")
 		return nil, fmt.Errorf("failed to add reaction: %w", err)
@@ -212,25 +228,12 @@ func (s *meetingChatService) AddReaction(ctx context.Context, messageID, userID
 	}, nil
 }
 
-func (s *meetingChatService) RemoveReaction(ctx context.Context, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error) {
-	// Check if message exists (optional, could just try to delete)
-	_, err := s.messageRepo.GetMessageByID(ctx, messageID)
-	if err != nil {
-		s.log.Error().Err(err).Int("message_id", messageID).Msg("Message not found for removing reaction")
-		if err == sql.ErrNoRows {
-			// If message not found, we can consider it successfully "removed" if that's the desired behavior,
-			// or return an error if we strictly need the message to exist. For now, return nil,nil payload.
-			return &models.ReactionBroadcastPayload{
-				MessageID: messageID,
-				UserID:    userID,
-				Emoji:     emoji,
-				Action:    "removed",
-			}, nil
-		}
-		return nil, fmt.Errorf("database error: %w", err)
+func (s *meetingChatService) RemoveReaction(ctx context.Context, meetingID, messageID, userID int, emoji string) (*models.ReactionBroadcastPayload, error) {
+	if err := s.checkReaction(ctx, meetingID, messageID, userID); err != nil {
+		return nil, err
 	}
 
-	err = s.reactionRepo.DeleteReaction(ctx, messageID, userID, emoji)
+	err := s.reactionRepo.DeleteReaction(ctx, messageID, userID, emoji)
 	if err != nil {
 		s.log.Error().Err(err).Int("message_id", messageID).Int("user_id", userID).Str("emoji", emoji).Msg("Failed to remove reaction from message")
 		return nil, fmt.Errorf("failed to remove reaction: %w", err)
@@ -245,6 +248,31 @@ func (s *meetingChatService) RemoveReaction(ctx context.Context, messageID, user
 	}, nil
 }
 
+// checkReaction makes sure a reaction sent over a meeting's chat is for a message in that meeting,
+// and comes from one of its participants.
+func (s *meetingChatService) checkReaction(ctx context.Context, meetingID, messageID, userID int) error {
+	message, err := s.messageRepo.GetMessageByID(ctx, messageID)
+	if err != nil {
+		s.log.Error().Err(err).Int("message_id", messageID).Msg("Failed to get message for reaction")
+		return fmt.Errorf("database error: %w", err)
+	}
+	if message == nil || message.MeetingID != meetingID {
+		s.log.Warn().Int("message_id", messageID).Int("meeting_id", meetingID).Msg("Reaction to a message outside the meeting")
+		return NewNotFoundError(fmt.Sprintf("Message with ID %d not found", messageID))
+	}
+
+	isParticipant, err := s.meetingRepo.IsParticipantInMeeting(ctx, meetingID, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("meeting_id", meetingID).Int("user_id", userID).Msg("Failed to check if user is participant before reacting")
+		return fmt.Errorf("failed to verify participant status: %w", err)
+	}
+	if !isParticipant {
+		s.log.Warn().Int("meeting_id", meetingID).Int("user_id", userID).Msg("User is not a participant in the meeting chat")
+		return NewUnauthorizedError("user is not a participant in this chat")
+	}
+	return nil
+}
+
 func (s *meetingChatService) GetOrCreateHubForMeeting(meetingID int) *utils.Hub {
 	s.mu.Lock()
 	defer s.mu.Unlock()
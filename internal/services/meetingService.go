@@ -26,15 +26,19 @@ type meetingService struct {
 	channelRepo       repositories.ChannelRepo
 	userRepo          repositories.UserRepo
 	channelMemberRepo repositories.ChannelMemberRepo
+	quotaService      QuotaService
+	authorizer        Authorizer
 	log               zerolog.Logger
 }
 
-func NewMeetingService(mr repositories.MeetingRepo, cr repositories.ChannelRepo, ur repositories.UserRepo, cmr repositories.ChannelMemberRepo, logger zerolog.Logger) MeetingService {
+func NewMeetingService(mr repositories.MeetingRepo, cr repositories.ChannelRepo, ur repositories.UserRepo, cmr repositories.ChannelMemberRepo, qs QuotaService, authorizer Authorizer, logger zerolog.Logger) MeetingService {
 	return &meetingService{
 		meetingRepo:       mr,
 		channelRepo:       cr,
 		userRepo:          ur,
 		channelMemberRepo: cmr,
+		quotaService:      qs,
+		authorizer:        authorizer,
 		log:               logger,
 	}
 }
@@ -49,6 +53,12 @@ func (s *meetingService) CreateMeeting(ctx context.Context, meeting *models.Meet
 		s.log.Warn().Int("channel_id", meeting.ChannelID).Msg("Channel not found for meeting creation")
 		return nil, errors.New("channel not found")
 	}
+	if _, err := s.authorizer.Authorize(ctx, creatorID, channel.WorkspaceID, models.PermMeetingCreate); err != nil {
+		return nil, err
+	}
+	if err := s.quotaService.CheckMeetings(ctx, channel.WorkspaceID); err != nil {
+		return nil, err
+	}
 
 	meeting.CreatorID = creatorID
 	err = s.meetingRepo.CreateMeeting(ctx, meeting)
@@ -155,9 +165,11 @@ func (s *meetingService) UpdateMeeting(ctx context.Context, meeting *models.Meet
 		return nil, err
 	}
 
-	if existingMeeting.CreatorID != userID {
-		s.log.Warn().Int("meeting_id", meeting.ID).Int("user_id", userID).Msg("User not authorized to update this meeting")
-		return nil, &ForbiddenError{Message: "User not authorized to update this meeting"}
+	if existingMeeting == nil {
+		return nil, NewNotFoundError("Meeting not found")
+	}
+	if err := s.authorizeMeeting(ctx, existingMeeting, userID, models.PermMeetingUpdate); err != nil {
+		return nil, err
 	}
 
 	existingMeeting.Name = meeting.Name
@@ -186,9 +198,11 @@ func (s *meetingService) DeleteMeeting(ctx context.Context, id int, userID int)
 		return err
 	}
 
-	if existingMeeting.CreatorID != userID {
-		s.log.Warn().Int("meeting_id", id).Int("user_id", userID).Msg("User not authorized to delete this meeting")
-		return &ForbiddenError{Message: "User not authorized to delete this meeting"}
+	if existingMeeting == nil {
+		return NewNotFoundError("Meeting not found")
+	}
+	if err := s.authorizeMeeting(ctx, existingMeeting, userID, models.PermMeetingDelete); err != nil {
+		return err
 	}
 
 	err = s.meetingRepo.DeleteMeeting(ctx, id)
@@ -210,9 +224,11 @@ func (s *meetingService) AddParticipant(ctx context.Context, meetingID, userID,
 		s.log.Error().Err(err).Int("meeting_id", meetingID).Msg("Failed to get meeting for adding participant")
 		return err
 	}
-	if existingMeeting.CreatorID != userID {
-		s.log.Warn().Int("meeting_id", meetingID).Int("user_id", userID).Int("participant_id", participantID).Msg("User not authorized to add participants to this meeting")
-		return &ForbiddenError{Message: "User not authorized to add participants to this meeting"}
+	if existingMeeting == nil {
+		return NewNotFoundError("Meeting not found")
+	}
+	if err := s.authorizeMeeting(ctx, existingMeeting, userID, models.PermMeetingUpdate); err != nil {
+		return err
 	}
 
 	participant, err := s.userRepo.GetUserByID(ctx, participantID)
@@ -244,9 +260,11 @@ func (s *meetingService) RemoveParticipant(ctx context.Context, meetingID, userI
 		s.log.Error().Err(err).Int("meeting_id", meetingID).Msg("Failed to get meeting for removing participant")
 		return err
 	}
-	if existingMeeting.CreatorID != userID {
-		s.log.Warn().Int("meeting_id", meetingID).Int("user_id", userID).Int("participant_id", participantID).Msg("User not authorized to remove participants from this meeting")
-		return &ForbiddenError{Message: "User not authorized to remove participants from this meeting"}
+	if existingMeeting == nil {
+		return NewNotFoundError("Meeting not found")
+	}
+	if err := s.authorizeMeeting(ctx, existingMeeting, userID, models.PermMeetingUpdate); err != nil {
+		return err
 	}
 
 	err = s.meetingRepo.RemoveParticipantFromMeeting(ctx, meetingID, participantID)
@@ -257,3 +275,12 @@ func (s *meetingService) RemoveParticipant(ctx context.Context, meetingID, userI
 	}
 	return err
 }
+
+// authorizeMeeting lets the meeting's creator manage it, and otherwise whoever holds permission
+// in its workspace.
+func (s *meetingService) authorizeMeeting(ctx context.Context, meeting *models.Meeting, userID int, permission models.Permission) error {
+	if meeting.CreatorID == userID {
+		return nil
+	}
+	return s.authorizer.AuthorizeResource(ctx, userID, models.ResourceChannel, meeting.ChannelID, permission)
+}
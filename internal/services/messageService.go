@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"axis/internal/models"
 	"axis/internal/repositories"
@@ -19,16 +20,20 @@ type MessageService interface {
 }
 
 type messageService struct {
-	messageRepo repositories.MessageRepo
-	meetingRepo repositories.MeetingRepo
-	log         zerolog.Logger
+	messageRepo  repositories.MessageRepo
+	meetingRepo  repositories.MeetingRepo
+	quotaService QuotaService
+	authorizer   Authorizer
+	log          zerolog.Logger
 }
 
-func NewMessageService(mr repositories.MessageRepo, metR repositories.MeetingRepo, logger zerolog.Logger) MessageService {
+func NewMessageService(mr repositories.MessageRepo, metR repositories.MeetingRepo, qs QuotaService, authorizer Authorizer, logger zerolog.Logger) MessageService {
 	return &messageService{
-		messageRepo: mr,
-		meetingRepo: metR,
-		log:         logger,
+		messageRepo:  mr,
+		meetingRepo:  metR,
+		quotaService: qs,
+		authorizer:   authorizer,
+		log:          logger,
 	}
 }
 
@@ -94,7 +99,11 @@ func (s *messageService) GetMessagesInMeeting(ctx context.Context, meetingID int
 		return nil, errors.New("meeting not found")
 	}
 
-	messages, err := s.messageRepo.GetMessagesByMeetingID(ctx, meetingID, limit, offset)
+	since, err := meetingHistoryCutoff(ctx, s.quotaService, meetingID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.messageRepo.GetMessagesByMeetingID(ctx, meetingID, since, limit, offset)
 	if err != nil {
 		s.log.Error().Err(err).Int("meeting_id", meetingID).Msg("Failed to get messages for meeting")
 		return nil, err
@@ -142,10 +151,15 @@ func (s *messageService) DeleteMessage(ctx context.Context, userID int, id int)
 		return err
 	}
 
-	// Authorization check: Only the sender can delete their message
+	if existingMessage == nil {
+		return nil
+	}
+
+	// Authorization check: The sender can delete their message, moderators anyone's
 	if existingMessage.SenderID != int(userID) {
-		s.log.Warn().Int("message_id", id).Int("user_id", userID).Msg("User not authorized to delete this message")
-		return &ForbiddenError{Message: "User not authorized to delete this message"}
+		if err := s.authorizer.AuthorizeResource(ctx, userID, models.ResourceMeeting, existingMessage.MeetingID, models.PermMessageDelete); err != nil {
+			return err
+		}
 	}
 
 	err = s.messageRepo.DeleteMessage(ctx, id)
@@ -160,3 +174,13 @@ func (s *messageService) DeleteMessage(ctx context.Context, userID int, id int)
 	s.log.Info().Int("message_id", id).Msg("Message deleted successfully")
 	return nil
 }
+
+// meetingHistoryCutoff returns the time before which the meeting's messages are hidden by its
+// workspace's message history limit, or nil if there is none.
+func meetingHistoryCutoff(ctx context.Context, quotaService QuotaService, meetingID int) (*time.Time, error) {
+	workspaceID, err := quotaService.WorkspaceIDOf(ctx, models.ResourceMeeting, meetingID)
+	if err != nil {
+		return nil, err
+	}
+	return quotaService.HistoryCutoff(ctx, workspaceID)
+}
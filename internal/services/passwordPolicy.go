@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed commonPasswords.txt
+var commonPasswords string
+
+// PasswordPolicy decides which new passwords are accepted. Length and the deny-list carry most of
+// the weight; character class requirements are off unless configured.
+type PasswordPolicy struct {
+	MinLength        int  `json:"min_length"`
+	MaxLength        int  `json:"max_length"`
+	RequireUppercase bool `json:"require_uppercase"`
+	RequireLowercase bool `json:"require_lowercase"`
+	RequireDigit     bool `json:"require_digit"`
+	RequireSymbol    bool `json:"require_symbol"`
+
+	denied map[string]struct{}
+}
+
+// NewPasswordPolicy returns a policy with the bundled deny-list and no class requirements.
+func NewPasswordPolicy(minLength, maxLength int) *PasswordPolicy {
+	p := &PasswordPolicy{MinLength: minLength, MaxLength: maxLength, denied: make(map[string]struct{})}
+	p.deny(commonPasswords)
+	return p
+}
+
+// LoadPasswordPolicy reads the policy from the environment:
+//
+//	PASSWORD_MIN_LENGTH     minimum length in characters (default 10)
+//	PASSWORD_MAX_LENGTH     maximum length in characters (default 128)
+//	PASSWORD_REQUIRE        comma separated character classes: upper, lower, digit, symbol
+//	PASSWORD_DENYLIST_FILE  file of further denied passwords, one per line
+func LoadPasswordPolicy() (*PasswordPolicy, error) {
+	minLength, err := policyEnvInt("PASSWORD_MIN_LENGTH", 10)
+	if err != nil {
+		return nil, err
+	}
+	maxLength, err := policyEnvInt("PASSWORD_MAX_LENGTH", 128)
+	if err != nil {
+		return nil, err
+	}
+	if minLength < 1 || maxLength < minLength {
+		return nil, fmt.Errorf("PASSWORD_MIN_LENGTH must be at least 1 and not above PASSWORD_MAX_LENGTH")
+	}
+	p := NewPasswordPolicy(minLength, maxLength)
+
+	if require := os.Getenv("PASSWORD_REQUIRE"); require != "" {
+		for _, class := range strings.Split(require, ",") {
+			switch strings.TrimSpace(class) {
+			case "upper":
+				p.RequireUppercase = true
+			case "lower":
+				p.RequireLowercase = true
+			case "digit":
+				p.RequireDigit = true
+			case "symbol":
+				p.RequireSymbol = true
+			default:
+				return nil, fmt.Errorf("PASSWORD_REQUIRE: unknown character class %q", class)
+			}
+		}
+	}
+
+	if path := os.Getenv("PASSWORD_DENYLIST_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("PASSWORD_DENYLIST_FILE: %w", err)
+		}
+		p.deny(string(data))
+	}
+	return p, nil
+}
+
+// Validate returns a ValidationError describing the first rule password breaks. personal holds
+// the user's own details, such as name and email, which must not be the password either.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return NewValidationError(fmt.Sprintf("Password must be at least %d characters long", p.MinLength))
+	}
+	if length > p.MaxLength {
+		return NewValidationError(fmt.Sprintf("Password must be at most %d characters long", p.MaxLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUppercase && !upper:
+		return NewValidationError("Password must contain an uppercase letter")
+	case p.RequireLowercase && !lower:
+		return NewValidationError("Password must contain a lowercase letter")
+	case p.RequireDigit && !digit:
+		return NewValidationError("Password must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return NewValidationError("Password must contain a symbol")
+	}
+
+	normalized := strings.ToLower(password)
+	if p.isDenied(normalized) {
+		return NewValidationError("Password is too common")
+	}
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if local, _, ok := strings.Cut(value, "@"); ok && normalized == local {
+			return NewValidationError("Password must not be your name, username or email")
+		}
+		if normalized == value {
+			return NewValidationError("Password must not be your name, username or email")
+		}
+	}
+	return nil
+}
+
+// isDenied also catches the usual decorations of a common password, like "password123!".
+func (p *PasswordPolicy) isDenied(password string) bool {
+	if _, ok := p.denied[password]; ok {
+		return true
+	}
+	base := strings.TrimRightFunc(password, func(r rune) bool {
+		return unicode.IsDigit(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+	_, ok := p.denied[base]
+	return ok && base != ""
+}
+
+func (p *PasswordPolicy) deny(list string) {
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.denied[strings.ToLower(line)] = struct{}{}
+	}
+}
+
+func policyEnvInt(name string, fallback int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return n, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import "testing"
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := NewPasswordPolicy(10, 64)
+	policy.RequireDigit = true
+
+	tests := []struct {
+		password string
+		valid    bool
+	}{
+		{"", false},
+		{"short1", false},
+		{"long enough but no digits", false},
+		{"password123", false},
+		{"Qwerty123456!", false},
+		{"janedoe2024", true},
+		{"jane@example.com1", true},
+		{"plum-orbit-7-lantern", true},
+		{"x1234567890123456789012345678901234567890123456789012345678901234", false},
+	}
+	for _, tt := range tests {
+		err := policy.Validate(tt.password, "Jane Doe", "janedoe", "jane@example.com")
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%q) = %v, want nil", tt.password, err)
+		}
+		if !tt.valid {
+			if _, ok := err.(*ValidationError); !ok {
+				t.Errorf("Validate(%q) = %v, want ValidationError", tt.password, err)
+			}
+		}
+	}
+
+	if err := policy.Validate("janedoe123", "Jane Doe", "janedoe123", "jane@example.com"); err == nil {
+		t.Error("password equal to the username was accepted")
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// PresenceEvent is the WebSocket event type announcing a presence change.
+	PresenceEvent = "presence"
+	// presenceIdleAfter is how long a connection may go without activity before it counts as idle.
+	// Clients send a heartbeat about every minute, so a few missed ones are tolerated.
+	presenceIdleAfter = 5 * time.Minute
+	// maxPresenceQuery bounds the number of users in one presence query.
+	maxPresenceQuery = 200
+)
+
+// PresenceService tracks who is online from their live WebSocket connections, on any meeting
+// chat or the event stream. A user is online while any of their devices is active, away while all
+// of them are idle, and offline once the last one disconnects.
+type PresenceService interface {
+	Connect(client *utils.Client)
+	Disconnect(client *utils.Client)
+	// Heartbeat records that the connection is alive and whether its user is idle. Any other
+	// message from the client counts as activity too.
+	Heartbeat(client *utils.Client, idle bool)
+	// GetPresence returns the presence of those of userIDs the viewer may see: themselves and
+	// people sharing a workspace with them.
+	GetPresence(ctx context.Context, viewerID int, userIDs []int) ([]models.UserPresence, error)
+	// RunIdleWorker marks users away once their devices have been idle for long enough, checking
+	// every interval until ctx is done.
+	RunIdleWorker(ctx context.Context, interval time.Duration)
+}
+
+type connectionActivity struct {
+	lastActive time.Time
+	idle       bool
+}
+
+type presenceService struct {
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	eventService        EventService
+	log                 zerolog.Logger
+
+	mu          sync.Mutex
+	connections map[int]map[*utils.Client]*connectionActivity
+	announced   map[int]models.Presence
+	lastActive  map[int]time.Time
+}
+
+func NewPresenceService(wmr repositories.WorkspaceMemberRepo, es EventService, logger zerolog.Logger) PresenceService {
+	return &presenceService{
+		workspaceMemberRepo: wmr,
+		eventService:        es,
+		log:                 logger,
+		connections:         make(map[int]map[*utils.Client]*connectionActivity),
+		announced:           make(map[int]models.Presence),
+		lastActive:          make(map[int]time.Time),
+	}
+}
+
+func (s *presenceService) Connect(client *utils.Client) {
+	s.connect(client, time.Now())
+}
+
+func (s *presenceService) Disconnect(client *utils.Client) {
+	s.disconnect(client, time.Now())
+}
+
+func (s *presenceService) Heartbeat(client *utils.Client, idle bool) {
+	s.heartbeat(client, idle, time.Now())
+}
+
+func (s *presenceService) GetPresence(ctx context.Context, viewerID int, userIDs []int) ([]models.UserPresence, error) {
+	if len(userIDs) > maxPresenceQuery {
+		return nil, NewValidationError("Presence can be queried for at most 200 users at once")
+	}
+	visible, err := s.workspaceMemberRepo.GetWorkspacePeerIDs(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	visible = append(visible, viewerID)
+
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	presences := make([]models.UserPresence, 0, len(userIDs))
+	seen := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] || !slices.Contains(visible, userID) {
+			continue
+		}
+		seen[userID] = true
+		presences = append(presences, s.presenceOf(userID, now))
+	}
+	return presences, nil
+}
+
+func (s *presenceService) RunIdleWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.sweep(now)
+		}
+	}
+}
+
+func (s *presenceService) connect(client *utils.Client, now time.Time) {
+	s.mu.Lock()
+	if s.connections[client.ID] == nil {
+		s.connections[client.ID] = make(map[*utils.Client]*connectionActivity)
+	}
+	s.connections[client.ID][client] = &connectionActivity{lastActive: now}
+	s.lastActive[client.ID] = now
+	changed := s.refresh(client.ID, now)
+	s.mu.Unlock()
+	s.announce(changed)
+}
+
+func (s *presenceService) disconnect(client *utils.Client, now time.Time) {
+	s.mu.Lock()
+	conns := s.connections[client.ID]
+	if _, ok := conns[client]; !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(conns, client)
+	if len(conns) == 0 {
+		delete(s.connections, client.ID)
+	}
+	changed := s.refresh(client.ID, now)
+	s.mu.Unlock()
+	s.announce(changed)
+}
+
+func (s *presenceService) heartbeat(client *utils.Client, idle bool, now time.Time) {
+	s.mu.Lock()
+	activity, ok := s.connections[client.ID][client]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	activity.idle = idle
+	if !idle {
+		activity.lastActive = now
+		s.lastActive[client.ID] = now
+	}
+	changed := s.refresh(client.ID, now)
+	s.mu.Unlock()
+	s.announce(changed)
+}
+
+// sweep catches users whose devices went quiet without saying so.
+func (s *presenceService) sweep(now time.Time) {
+	s.mu.Lock()
+	var changed []models.UserPresence
+	for userID := range s.connections {
+		changed = append(changed, s.refresh(userID, now)...)
+	}
+	s.mu.Unlock()
+	s.announce(changed)
+}
+
+// refresh recomputes the user's presence and returns it if it differs from the last one
+// announced. The caller holds s.mu.
+func (s *presenceService) refresh(userID int, now time.Time) []models.UserPresence {
+	presence := s.presenceOf(userID, now)
+	previous, ok := s.announced[userID]
+	if !ok {
+		previous = models.PresenceOffline
+	}
+	if presence.Presence == previous {
+		return nil
+	}
+	if presence.Presence == models.PresenceOffline {
+		delete(s.announced, userID)
+	} else {
+		s.announced[userID] = presence.Presence
+	}
+	return []models.UserPresence{presence}
+}
+
+// presenceOf derives the user's presence from their connections. The caller holds s.mu.
+func (s *presenceService) presenceOf(userID int, now time.Time) models.UserPresence {
+	presence := models.UserPresence{UserID: userID, Presence: models.PresenceOffline}
+	if lastActive, ok := s.lastActive[userID]; ok {
+		presence.LastActiveAt = &lastActive
+	}
+	for _, activity := range s.connections[userID] {
+		if !activity.idle && now.Sub(activity.lastActive) < presenceIdleAfter {
+			presence.Presence = models.PresenceOnline
+			break
+		}
+		presence.Presence = models.PresenceAway
+	}
+	return presence
+}
+
+func (s *presenceService) announce(changes []models.UserPresence) {
+	for _, presence := range changes {
+		if err := s.eventService.PublishToWorkspacePeers(context.Background(), presence.UserID, PresenceEvent, presence); err != nil {
+			s.log.Warn().Err(err).Int("user_id", presence.UserID).Msg("Failed to announce presence change")
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+type fakeEventService struct {
+	presences []models.UserPresence
+}
+
+func (f *fakeEventService) Register(client *utils.Client)                                    {}
+func (f *fakeEventService) Unregister(client *utils.Client)                                  {}
+func (f *fakeEventService) PublishToUsers(userIDs []int, eventType string, data interface{}) {}
+
+func (f *fakeEventService) PublishToWorkspacePeers(ctx context.Context, userID int, eventType string, data interface{}) error {
+	if presence, ok := data.(models.UserPresence); ok && eventType == PresenceEvent {
+		f.presences = append(f.presences, presence)
+	}
+	return nil
+}
+
+func (f *fakeEventService) last() models.Presence {
+	if len(f.presences) == 0 {
+		return ""
+	}
+	return f.presences[len(f.presences)-1].Presence
+}
+
+func TestPresenceAcrossDevices(t *testing.T) {
+	events := &fakeEventService{}
+	s := NewPresenceService(nil, events, zerolog.Nop()).(*presenceService)
+	start := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	laptop := &utils.Client{ID: 7}
+	phone := &utils.Client{ID: 7}
+
+	s.connect(laptop, start)
+	if events.last() != models.PresenceOnline {
+		t.Fatalf("after connecting: %v, want online", events.presences)
+	}
+	s.connect(phone, start)
+	s.heartbeat(laptop, true, start.Add(time.Minute))
+	if len(events.presences) != 1 {
+		t.Fatalf("presence changed while the phone is still active: %v", events.presences)
+	}
+
+	// The phone goes quiet without saying so; the sweep notices.
+	s.sweep(start.Add(presenceIdleAfter + time.Second))
+	if events.last() != models.PresenceAway {
+		t.Fatalf("after idling: %v, want away", events.presences)
+	}
+
+	s.heartbeat(phone, false, start.Add(presenceIdleAfter+2*time.Second))
+	if events.last() != models.PresenceOnline {
+		t.Fatalf("after activity: %v, want online", events.presences)
+	}
+
+	s.disconnect(phone, start.Add(10*time.Minute))
+	if events.last() != models.PresenceAway {
+		t.Fatalf("with only the idle laptop left: %v, want away", events.presences)
+	}
+	s.disconnect(laptop, start.Add(11*time.Minute))
+	s.disconnect(laptop, start.Add(11*time.Minute))
+	if events.last() != models.PresenceOffline || len(events.presences) != 5 {
+		t.Fatalf("after disconnecting: %v, want a single offline event", events.presences)
+	}
+
+	presence := s.presenceOf(7, start.Add(12*time.Minute))
+	if presence.LastActiveAt == nil || !presence.LastActiveAt.Equal(start.Add(presenceIdleAfter+2*time.Second)) {
+		t.Errorf("LastActiveAt = %v, want the phone's last activity", presence.LastActiveAt)
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+// QuotaService enforces the limits of each workspace's plan. Checks count current usage and are not
+// atomic with the change that follows, so concurrent requests may go slightly over a limit.
+type QuotaService interface {
+	// CheckSeats returns a QuotaExceededError unless the workspace has room for another member.
+	CheckSeats(ctx context.Context, workspaceID int) error
+	// CheckStorage returns a QuotaExceededError if adding bytes of attachments would go over the limit.
+	CheckStorage(ctx context.Context, workspaceID int, bytes int64) error
+	CheckMeetings(ctx context.Context, workspaceID int) error
+	// HistoryCutoff returns the time before which the workspace's messages are no longer listed,
+	// or nil when its history is unlimited.
+	HistoryCutoff(ctx context.Context, workspaceID int) (*time.Time, error)
+	// WorkspaceIDOf returns the workspace a channel, meeting or message belongs to.
+	WorkspaceIDOf(ctx context.Context, resource models.ResourceType, id int) (int, error)
+	GetUsage(ctx context.Context, adminID, workspaceID int) (*models.WorkspaceUsage, error)
+}
+
+type quotaService struct {
+	usageRepo     repositories.UsageRepo
+	workspaceRepo repositories.WorkspaceRepo
+	authorizer    Authorizer
+	messageRepo   repositories.MessageRepo
+	meetingRepo   repositories.MeetingRepo
+	channelRepo   repositories.ChannelRepo
+	log           zerolog.Logger
+}
+
+func NewQuotaService(usr repositories.UsageRepo, wr repositories.WorkspaceRepo, authorizer Authorizer, msr repositories.MessageRepo, mr repositories.MeetingRepo, cr repositories.ChannelRepo, logger zerolog.Logger) QuotaService {
+	return &quotaService{
+		usageRepo:     usr,
+		workspaceRepo: wr,
+		authorizer:    authorizer,
+		messageRepo:   msr,
+		meetingRepo:   mr,
+		channelRepo:   cr,
+		log:           logger,
+	}
+}
+
+func (s *quotaService) CheckSeats(ctx context.Context, workspaceID int) error {
+	return s.check(ctx, workspaceID, models.QuotaSeats, 1, s.usageRepo.CountMembers)
+}
+
+func (s *quotaService) CheckStorage(ctx context.Context, workspaceID int, bytes int64) error {
+	if bytes <= 0 {
+		return nil
+	}
+	return s.check(ctx, workspaceID, models.QuotaStorage, bytes, s.usageRepo.StorageBytes)
+}
+
+func (s *quotaService) CheckMeetings(ctx context.Context, workspaceID int) error {
+	return s.check(ctx, workspaceID, models.QuotaMeetings, 1, s.usageRepo.CountMeetings)
+}
+
+func (s *quotaService) check(ctx context.Context, workspaceID int, quota models.Quota, adding int64, used func(context.Context, int) (int64, error)) error {
+	workspace, err := s.workspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	limit, limited := workspaceLimit(workspace, quota)
+	if !limited {
+		return nil
+	}
+	usage, err := used(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if err := checkQuota(quota, usage, adding, limit); err != nil {
+		s.log.Warn().Int("workspace_id", workspaceID).Str("quota", string(quota)).Int64("used", usage).Int64("limit", limit).Msg("Workspace quota exceeded")
+		return err
+	}
+	return nil
+}
+
+func (s *quotaService) HistoryCutoff(ctx context.Context, workspaceID int) (*time.Time, error) {
+	workspace, err := s.workspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	days, limited := workspaceLimit(workspace, models.QuotaMessageHistory)
+	if !limited {
+		return nil, nil
+	}
+	cutoff := time.Now().AddDate(0, 0, -int(days))
+	return &cutoff, nil
+}
+
+func (s *quotaService) WorkspaceIDOf(ctx context.Context, resource models.ResourceType, id int) (int, error) {
+	return resourceWorkspaceID(ctx, s.messageRepo, s.meetingRepo, s.channelRepo, resource, id)
+}
+
+func (s *quotaService) GetUsage(ctx context.Context, adminID, workspaceID int) (*models.WorkspaceUsage, error) {
+	workspace, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermWorkspaceUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.WorkspaceUsage{WorkspaceID: workspaceID, Plan: workspace.Plan}
+	for _, quota := range models.Quotas {
+		var used int64
+		switch quota {
+		case models.QuotaSeats:
+			used, err = s.usageRepo.CountMembers(ctx, workspaceID)
+		case models.QuotaStorage:
+			used, err = s.usageRepo.StorageBytes(ctx, workspaceID)
+		case models.QuotaMeetings:
+			used, err = s.usageRepo.CountMeetings(ctx, workspaceID)
+		case models.QuotaMessageHistory:
+			var oldest *time.Time
+			oldest, err = s.usageRepo.OldestMessageAt(ctx, workspaceID)
+			if oldest != nil {
+				used = int64(time.Since(*oldest) / (24 * time.Hour))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		quotaUsage := models.QuotaUsage{Quota: quota, Used: used}
+		if limit, limited := workspaceLimit(workspace, quota); limited {
+			quotaUsage.Limit = &limit
+		}
+		usage.Quotas = append(usage.Quotas, quotaUsage)
+	}
+	return usage, nil
+}
+
+func (s *quotaService) workspace(ctx context.Context, workspaceID int) (*models.Workspace, error) {
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	return workspace, nil
+}
+
+// workspaceLimit returns the workspace's limit for quota and whether there is one. A workspace's
+// own MaxMembers can lower its plan's seat limit, never raise it. Unknown plans get the free limits.
+func workspaceLimit(workspace *models.Workspace, quota models.Quota) (int64, bool) {
+	limits, ok := models.Plans[workspace.Plan]
+	if !ok {
+		limits = models.Plans[models.PlanFree]
+	}
+	limit, limited := limits[quota]
+	if quota == models.QuotaSeats && workspace.MaxMembers != nil {
+		if maxMembers := int64(*workspace.MaxMembers); !limited || maxMembers < limit {
+			return maxMembers, true
+		}
+	}
+	return limit, limited
+}
+
+func checkQuota(quota models.Quota, used, adding, limit int64) error {
+	if used+adding <= limit {
+		return nil
+	}
+	var message string
+	switch quota {
+	case models.QuotaSeats:
+		message = fmt.Sprintf("This workspace has used all of its %d seats", limit)
+	case models.QuotaStorage:
+		message = fmt.Sprintf("This workspace has used %d of its %d bytes of storage", used, limit)
+	case models.QuotaMeetings:
+		message = fmt.Sprintf("This workspace has reached its limit of %d meetings", limit)
+	default:
+		message = fmt.Sprintf("This workspace has reached its %s limit of %d", quota, limit)
+	}
+	return &QuotaExceededError{Quota: string(quota), Limit: limit, Message: message}
+}
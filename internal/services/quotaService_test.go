@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+
+	"axis/internal/models"
+)
+
+func TestWorkspaceLimit(t *testing.T) {
+	five, fifty := 5, 50
+
+	tests := []struct {
+		name        string
+		workspace   models.Workspace
+		quota       models.Quota
+		wantLimit   int64
+		wantLimited bool
+	}{
+		{"free seats", models.Workspace{Plan: models.PlanFree}, models.QuotaSeats, 10, true},
+		{"free history", models.Workspace{Plan: models.PlanFree}, models.QuotaMessageHistory, 90, true},
+		{"team history", models.Workspace{Plan: models.PlanTeam}, models.QuotaMessageHistory, 0, false},
+		{"unknown plan", models.Workspace{Plan: "gold"}, models.QuotaMeetings, 100, true},
+		{"max members lowers seats", models.Workspace{Plan: models.PlanFree, MaxMembers: &five}, models.QuotaSeats, 5, true},
+		{"max members cannot raise seats", models.Workspace{Plan: models.PlanFree, MaxMembers: &fifty}, models.QuotaSeats, 10, true},
+		{"max members limits enterprise", models.Workspace{Plan: models.PlanEnterprise, MaxMembers: &fifty}, models.QuotaSeats, 50, true},
+		{"max members is only about seats", models.Workspace{Plan: models.PlanEnterprise, MaxMembers: &five}, models.QuotaStorage, 0, false},
+	}
+	for _, tt := range tests {
+		limit, limited := workspaceLimit(&tt.workspace, tt.quota)
+		if limit != tt.wantLimit || limited != tt.wantLimited {
+			t.Errorf("%s: workspaceLimit() = %d, %v, want %d, %v", tt.name, limit, limited, tt.wantLimit, tt.wantLimited)
+		}
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	tests := []struct {
+		used, adding, limit int64
+		wantErr             bool
+	}{
+		{used: 9, adding: 1, limit: 10},
+		{used: 10, adding: 1, limit: 10, wantErr: true},
+		{used: 100, adding: 924, limit: 1024},
+		{used: 100, adding: 925, limit: 1024, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := checkQuota(models.QuotaStorage, tt.used, tt.adding, tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkQuota(%d, %d, %d) = %v, want error %v", tt.used, tt.adding, tt.limit, err, tt.wantErr)
+			continue
+		}
+		if quotaErr, ok := err.(*QuotaExceededError); err != nil && (!ok || quotaErr.Quota != string(models.QuotaStorage) || quotaErr.Limit != tt.limit) {
+			t.Errorf("checkQuota(%d, %d, %d) = %#v, want a storage QuotaExceededError", tt.used, tt.adding, tt.limit, err)
+		}
+	}
+}
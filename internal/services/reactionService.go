@@ -11,18 +11,22 @@ import (
 
 type ReactionService interface {
 	AddReaction(ctx context.Context, reaction *models.Reaction) (*models.Reaction, error)
-	RemoveReaction(ctx context.Context, messageID, userID int, emoji string) error
+	// RemoveReaction removes userID's reaction. Removing someone else's reaction needs the
+	// message.delete permission.
+	RemoveReaction(ctx context.Context, actorID, messageID, userID int, emoji string) error
 	GetReactionsForMessage(ctx context.Context, messageID int) ([]models.Reaction, error)
 }
 
 type reactionService struct {
 	reactionRepo repositories.ReactionRepo
+	authorizer   Authorizer
 	log          zerolog.Logger
 }
 
-func NewReactionService(rr repositories.ReactionRepo, logger zerolog.Logger) ReactionService {
+func NewReactionService(rr repositories.ReactionRepo, authorizer Authorizer, logger zerolog.Logger) ReactionService {
 	return &reactionService{
 		reactionRepo: rr,
+		authorizer:   authorizer,
 		log:          logger,
 	}
 }
@@ -47,7 +51,13 @@ func (s *reactionService) AddReaction(ctx context.Context, reaction *models.Reac
 	return reaction, nil
 }
 
-func (s *reactionService) RemoveReaction(ctx context.Context, messageID, userID int, emoji string) error {
+func (s *reactionService) RemoveReaction(ctx context.Context, actorID, messageID, userID int, emoji string) error {
+	if actorID != userID {
+		if err := s.authorizer.AuthorizeResource(ctx, actorID, models.ResourceMessage, messageID, models.PermMessageDelete); err != nil {
+			return err
+		}
+	}
+
 	reaction, err := s.reactionRepo.GetReactionByMessageUserEmoji(ctx, messageID, userID, emoji)
 	if err != nil {
 		if err == sql.ErrNoRows {
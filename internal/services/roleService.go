@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+const maxRoleName = 50
+
+// RoleService manages a workspace's custom roles and who has which role.
+type RoleService interface {
+	PermissionMatrix() models.PermissionMatrix
+	GetRoles(ctx context.Context, userID, workspaceID int) ([]models.WorkspaceRole, error)
+	CreateRole(ctx context.Context, userID, workspaceID int, form models.RoleModel) (*models.WorkspaceRole, error)
+	UpdateRole(ctx context.Context, userID, workspaceID, roleID int, form models.RoleModel) (*models.WorkspaceRole, error)
+	// DeleteRole deletes a custom role; the members who had it go back to their built-in role.
+	DeleteRole(ctx context.Context, userID, workspaceID, roleID int) error
+	// AssignRole gives a member a built-in or custom role. Nobody can change the owner's role, nor
+	// the role of someone holding permissions they do not hold themselves, and the last admin
+	// cannot lose member.roles. Each change is recorded in the audit log.
+	AssignRole(ctx context.Context, userID, workspaceID, memberID int, form models.AssignRoleModel) (*models.WorkspaceMember, error)
+	// GetPermissions returns what the user may do in the workspace.
+	GetPermissions(ctx context.Context, userID, workspaceID int) ([]models.Permission, error)
+}
+
+type roleService struct {
+	roleRepo            repositories.RoleRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	authorizer          Authorizer
+	auditService        AuditService
+	log                 zerolog.Logger
+}
+
+func NewRoleService(rr repositories.RoleRepo, wmr repositories.WorkspaceMemberRepo, authorizer Authorizer, as AuditService, logger zerolog.Logger) RoleService {
+	return &roleService{
+		roleRepo:            rr,
+		workspaceMemberRepo: wmr,
+		authorizer:          authorizer,
+		auditService:        as,
+		log:                 logger,
+	}
+}
+
+func (s *roleService) PermissionMatrix() models.PermissionMatrix {
+	roles := make(map[string][]models.Permission, len(models.RolePermissions))
+	for role, permissions := range models.RolePermissions {
+		roles[role.String()] = permissions
+	}
+	return models.PermissionMatrix{Permissions: models.Permissions, Roles: roles}
+}
+
+func (s *roleService) GetRoles(ctx context.Context, userID, workspaceID int) ([]models.WorkspaceRole, error) {
+	if _, err := s.GetPermissions(ctx, userID, workspaceID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.GetRolesForWorkspace(ctx, workspaceID)
+}
+
+func (s *roleService) CreateRole(ctx context.Context, userID, workspaceID int, form models.RoleModel) (*models.WorkspaceRole, error) {
+	role, err := newRole(form)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRoleChange(ctx, userID, workspaceID, role); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(ctx, workspaceID, 0, role.Name); err != nil {
+		return nil, err
+	}
+
+	role.WorkspaceID = workspaceID
+	if err := s.roleRepo.CreateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Int("role_id", role.ID).Int("user_id", userID).Msg("Workspace role created")
+	return role, nil
+}
+
+func (s *roleService) UpdateRole(ctx context.Context, userID, workspaceID, roleID int, form models.RoleModel) (*models.WorkspaceRole, error) {
+	updated, err := newRole(form)
+	if err != nil {
+		return nil, err
+	}
+	role, err := s.role(ctx, workspaceID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	// Editing a role both takes away what it had and grants what it gets.
+	if err := s.authorizeRoleChange(ctx, userID, workspaceID, role); err != nil {
+		return nil, err
+	}
+	if err := s.authorizeRoleChange(ctx, userID, workspaceID, updated); err != nil {
+		return nil, err
+	}
+	if err := s.checkNameFree(ctx, workspaceID, roleID, updated.Name); err != nil {
+		return nil, err
+	}
+
+	role.Name = updated.Name
+	role.Permissions = updated.Permissions
+	if err := s.roleRepo.UpdateRole(ctx, role); err != nil {
+		return nil, err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Int("role_id", roleID).Int("user_id", userID).Msg("Workspace role updated")
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(ctx context.Context, userID, workspaceID, roleID int) error {
+	role, err := s.role(ctx, workspaceID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeRoleChange(ctx, userID, workspaceID, role); err != nil {
+		return err
+	}
+	if err := s.roleRepo.DeleteRole(ctx, roleID); err != nil {
+		return err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Int("role_id", roleID).Int("user_id", userID).Msg("Workspace role deleted")
+	return nil
+}
+
+func (s *roleService) AssignRole(ctx context.Context, userID, workspaceID, memberID int, form models.AssignRoleModel) (*models.WorkspaceMember, error) {
+	if (form.Role == nil) == (form.CustomRoleID == nil) {
+		return nil, NewValidationError("Give either role or custom_role_id")
+	}
+	role := models.Member
+	if form.Role != nil {
+		role = *form.Role
+		if !validAssignableRole(role) {
+			return nil, NewValidationError("role must be 0 (admin), 1 (member) or 3 (guest)")
+		}
+	}
+
+	workspace, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermMemberRoles)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		return nil, NewNotFoundError("User is not a member of this workspace")
+	}
+	if isOwner(workspace, member) {
+		return nil, &ForbiddenError{Message: "The owner's role cannot be changed; transfer ownership instead"}
+	}
+
+	// Taking permissions away is limited like granting them.
+	actorPermissions, _, err := s.authorizer.Permissions(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	memberPermissions, _, err := s.authorizer.Permissions(ctx, memberID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if missing, ok := covers(actorPermissions, memberPermissions); !ok {
+		return nil, &ForbiddenError{Message: fmt.Sprintf("User cannot change the role of a member with the %s permission without having it", missing)}
+	}
+	if err := s.authorizer.AuthorizeGrant(ctx, userID, workspaceID, role, form.CustomRoleID); err != nil {
+		return nil, err
+	}
+	granted := models.RolePermissions[role]
+	if form.CustomRoleID != nil {
+		customRole, err := s.role(ctx, workspaceID, *form.CustomRoleID)
+		if err != nil {
+			return nil, err
+		}
+		granted = permissionsOf(customRole)
+	}
+	if !hasPermission(granted, models.PermMemberRoles) {
+		if err := checkAdminsRemain(ctx, s.authorizer, workspaceID, memberID); err != nil {
+			return nil, err
+		}
+	}
+
+	previous := *member
+	assigned, err := s.roleRepo.AssignRole(ctx, workspaceID, memberID, role, form.CustomRoleID)
+	if err != nil {
+		return nil, err
+	}
+	if !assigned {
+		return nil, NewNotFoundError("User is not a member of this workspace")
+	}
+	member.Role = role
+	member.CustomRoleID = form.CustomRoleID
+	s.auditService.Record(ctx, &models.WorkspaceAuditEntry{
+		WorkspaceID:  workspaceID,
+		ActorID:      userID,
+		Action:       models.AuditMemberRoleChanged,
+		TargetUserID: &memberID,
+		Details: map[string]any{
+			"from_role":           previous.Role.String(),
+			"from_custom_role_id": previous.CustomRoleID,
+			"to_role":             role.String(),
+			"to_custom_role_id":   form.CustomRoleID,
+		},
+	})
+	s.log.Info().Int("workspace_id", workspaceID).Int("member_id", memberID).Int("user_id", userID).Str("role", role.String()).Msg("Workspace member role changed")
+	return member, nil
+}
+
+func (s *roleService) GetPermissions(ctx context.Context, userID, workspaceID int) ([]models.Permission, error) {
+	permissions, isMember, err := s.authorizer.Permissions(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return nil, &ForbiddenError{Message: "User is not a member of this workspace"}
+	}
+	return permissions, nil
+}
+
+func (s *roleService) role(ctx context.Context, workspaceID, roleID int) (*models.WorkspaceRole, error) {
+	role, err := s.roleRepo.GetRole(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil || role.WorkspaceID != workspaceID {
+		return nil, NewNotFoundError("Role not found")
+	}
+	return role, nil
+}
+
+// authorizeRoleChange checks that userID may manage roles and holds every permission of role.
+func (s *roleService) authorizeRoleChange(ctx context.Context, userID, workspaceID int, role *models.WorkspaceRole) error {
+	permissions, _, err := s.authorizer.Permissions(ctx, userID, workspaceID)
+	if err != nil {
+		return err
+	}
+	return checkGrant(permissions, models.Member, true, permissionsOf(role))
+}
+
+func (s *roleService) checkNameFree(ctx context.Context, workspaceID, roleID int, name string) error {
+	roles, err := s.roleRepo.GetRolesForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.ID != roleID && strings.EqualFold(role.Name, name) {
+			return &ConflictError{Message: "A role with this name already exists"}
+		}
+	}
+	return nil
+}
+
+// newRole validates form. Role names must not be taken by a built-in role, and permissions are
+// kept in catalogue order without duplicates.
+func newRole(form models.RoleModel) (*models.WorkspaceRole, error) {
+	name := strings.TrimSpace(form.Name)
+	if name == "" || len(name) > maxRoleName {
+		return nil, NewValidationError("name must be 1 to 50 characters")
+	}
+	for role := range models.RolePermissions {
+		if strings.EqualFold(name, role.String()) {
+			return nil, NewValidationError(fmt.Sprintf("%s is a built-in role", role))
+		}
+	}
+
+	requested := make(map[string]bool, len(form.Permissions))
+	for _, permission := range form.Permissions {
+		if !validPermission(permission) {
+			return nil, NewValidationError("Unknown permission: " + permission)
+		}
+		requested[permission] = true
+	}
+	permissions := make([]string, 0, len(requested))
+	for _, permission := range models.Permissions {
+		if requested[string(permission)] {
+			permissions = append(permissions, string(permission))
+		}
+	}
+	return &models.WorkspaceRole{Name: name, Permissions: permissions}, nil
+}
+
+// covers reports whether have includes every permission in want, and if not, one that is missing.
+func covers(have, want []models.Permission) (models.Permission, bool) {
+	for _, permission := range want {
+		if !hasPermission(have, permission) {
+			return permission, false
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"axis/internal/models"
+)
+
+func TestNewRole(t *testing.T) {
+	tests := []struct {
+		name            string
+		form            models.RoleModel
+		wantErr         bool
+		wantName        string
+		wantPermissions []string
+	}{
+		{name: "catalogue order, no duplicates", form: models.RoleModel{Name: " Moderator ", Permissions: []string{"message.delete", "channel.update", "message.delete"}}, wantName: "Moderator", wantPermissions: []string{"channel.update", "message.delete"}},
+		{name: "no permissions", form: models.RoleModel{Name: "Observer"}, wantName: "Observer", wantPermissions: []string{}},
+		{name: "unknown permission", form: models.RoleModel{Name: "Moderator", Permissions: []string{"message.edit"}}, wantErr: true},
+		{name: "built-in name", form: models.RoleModel{Name: "Admin"}, wantErr: true},
+		{name: "empty name", form: models.RoleModel{Name: "  "}, wantErr: true},
+		{name: "long name", form: models.RoleModel{Name: strings.Repeat("a", maxRoleName+1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		role, err := newRole(tt.form)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: newRole() = %+v, want an error", tt.name, role)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: newRole() error = %v", tt.name, err)
+			continue
+		}
+		if role.Name != tt.wantName || !reflect.DeepEqual(role.Permissions, tt.wantPermissions) {
+			t.Errorf("%s: newRole() = %q %v, want %q %v", tt.name, role.Name, role.Permissions, tt.wantName, tt.wantPermissions)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// RefreshTokenTTL is how long a session lasts without being refreshed.
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	// lastSeenResolution bounds how often an authenticated request writes the session's last seen time.
+	lastSeenResolution = time.Minute
+)
+
+type SessionService interface {
+	CreateSession(ctx context.Context, user *models.User, meta models.SessionMetadata) (*models.TokenPair, error)
+	RefreshSession(ctx context.Context, refreshToken string, meta models.SessionMetadata) (*models.TokenPair, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID int) error
+	ListSessions(ctx context.Context, userID, currentSessionID int) ([]models.Session, error)
+	ValidateSession(ctx context.Context, sessionID int, ipAddress string) (bool, error)
+}
+
+type sessionService struct {
+	sessionRepo repositories.SessionRepo
+	log         zerolog.Logger
+}
+
+func NewSessionService(sr repositories.SessionRepo, logger zerolog.Logger) SessionService {
+	return &sessionService{
+		sessionRepo: sr,
+		log:         logger,
+	}
+}
+
+// CreateSession starts a new server-side session for the user and issues its first token pair.
+// Every way of signing in ends here, so deactivated accounts are turned away here too.
+func (s *sessionService) CreateSession(ctx context.Context, user *models.User, meta models.SessionMetadata) (*models.TokenPair, error) {
+	userID := user.ID
+	if user.DeactivatedAt != nil {
+		s.log.Info().Int("user_id", userID).Msg("Sign-in to a deactivated account refused")
+		return nil, &ForbiddenError{Message: "Account is deactivated"}
+	}
+
+	secret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to generate refresh token")
+		return nil, err
+	}
+
+	session := &models.Session{
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(secret),
+		DeviceName:       meta.DeviceName,
+		IPAddress:        meta.IPAddress,
+		UserAgent:        meta.UserAgent,
+		LastSeenAt:       time.Now(),
+		ExpiresAt:        time.Now().Add(RefreshTokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to create session")
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(userID, session.ID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Int("session_id", session.ID).Msg("Failed to generate access token")
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Int("session_id", session.ID).Msg("Session created successfully")
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: formatRefreshToken(session.ID, secret),
+	}, nil
+}
+
+// RefreshSession rotates the refresh token of a session. Presenting a refresh token that has
+// already been rotated away is treated as token theft and revokes the whole session.
+func (s *sessionService) RefreshSession(ctx context.Context, refreshToken string, meta models.SessionMetadata) (*models.TokenPair, error) {
+	sessionID, secret, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("Malformed refresh token")
+		return nil, NewUnauthorizedError("invalid refresh token")
+	}
+
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		s.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to get session for refresh")
+		return nil, err
+	}
+	if session == nil {
+		s.log.Warn().Int("session_id", sessionID).Msg("Refresh attempted for unknown session")
+		return nil, NewUnauthorizedError("invalid refresh token")
+	}
+	if !session.IsActive(time.Now()) {
+		s.log.Warn().Int("session_id", sessionID).Int("user_id", session.UserID).Msg("Refresh attempted for revoked or expired session")
+		return nil, NewUnauthorizedError("session is no longer active")
+	}
+
+	presentedHash := utils.HashToken(secret)
+	if presentedHash != session.RefreshTokenHash {
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	newSecret, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		s.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to generate refresh token")
+		return nil, err
+	}
+	session.RefreshTokenHash = utils.HashToken(newSecret)
+	session.ExpiresAt = time.Now().Add(RefreshTokenTTL)
+	session.IPAddress = meta.IPAddress
+	session.UserAgent = meta.UserAgent
+	session.LastSeenAt = time.Now()
+	session.UpdatedAt = time.Now()
+	// Two refreshes with the same token can both get past the check above; only the first rotates
+	// the token, and the other counts as reuse.
+	rotated, err := s.sessionRepo.RotateRefreshToken(ctx, session, presentedHash)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.revokeReusedSession(ctx, session)
+	}
+
+	accessToken, err := utils.GenerateAccessToken(session.UserID, session.ID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", session.UserID).Int("session_id", session.ID).Msg("Failed to generate access token")
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", session.UserID).Int("session_id", session.ID).Msg("Session refreshed successfully")
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: formatRefreshToken(session.ID, newSecret),
+	}, nil
+}
+
+// revokeReusedSession revokes a session whose refresh token was presented after it had been
+// rotated, since either the client or whoever copied the token is using a stale one.
+func (s *sessionService) revokeReusedSession(ctx context.Context, session *models.Session) error {
+	s.log.Warn().Int("session_id", session.ID).Int("user_id", session.UserID).Msg("Refresh token reuse detected, revoking session")
+	if err := s.sessionRepo.RevokeSession(ctx, session.ID); err != nil {
+		s.log.Error().Err(err).Int("session_id", session.ID).Msg("Failed to revoke session after refresh token reuse")
+		return err
+	}
+	return NewUnauthorizedError("refresh token reuse detected")
+}
+
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		s.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to get session for revocation")
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		s.log.Warn().Int("session_id", sessionID).Int("user_id", userID).Msg("Session not found for revocation")
+		return NewNotFoundError("Session not found")
+	}
+
+	if err := s.sessionRepo.RevokeSession(ctx, sessionID); err != nil {
+		s.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to revoke session")
+		return err
+	}
+	s.log.Info().Int("session_id", sessionID).Int("user_id", userID).Msg("Session revoked successfully")
+	return nil
+}
+
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID int) error {
+	if err := s.sessionRepo.RevokeSessionsForUser(ctx, userID, currentSessionID); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Int("session_id", currentSessionID).Msg("Failed to revoke other sessions")
+		return err
+	}
+	s.log.Info().Int("user_id", userID).Int("session_id", currentSessionID).Msg("Other sessions revoked successfully")
+	return nil
+}
+
+func (s *sessionService) ListSessions(ctx context.Context, userID, currentSessionID int) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.GetActiveSessionsForUser(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to list sessions")
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// ValidateSession reports whether the session is still active and records that it was seen.
+func (s *sessionService) ValidateSession(ctx context.Context, sessionID int, ipAddress string) (bool, error) {
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		s.log.Error().Err(err).Int("session_id", sessionID).Msg("Failed to get session for validation")
+		return false, err
+	}
+	if session == nil {
+		return false, nil
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return false, nil
+	}
+
+	if now.Sub(session.LastSeenAt) > lastSeenResolution || session.IPAddress != ipAddress {
+		if err := s.sessionRepo.TouchSession(ctx, sessionID, ipAddress, now); err != nil {
+			s.log.Warn().Err(err).Int("session_id", sessionID).Msg("Failed to record session activity")
+		}
+	}
+	return true, nil
+}
+
+// Refresh tokens are "<session id>.<secret>" so the session can be located without
+// storing the secret itself.
+func formatRefreshToken(sessionID int, secret string) string {
+	return fmt.Sprintf("%d.%s", sessionID, secret)
+}
+
+func parseRefreshToken(token string) (int, string, error) {
+	idStr, secret, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return 0, "", fmt.Errorf("refresh token has no secret part")
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, "", fmt.Errorf("refresh token has invalid session part: %w", err)
+	}
+	return id, secret, nil
+}
@@ -0,0 +1,709 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"axis/internal/models"
+	"axis/internal/oidc"
+	"axis/internal/repositories"
+	"axis/internal/saml"
+	"axis/internal/utils"
+)
+
+const (
+	identityProviderOIDC = "oidc"
+	identityProviderSAML = "saml"
+	ssoLoginStateTTL     = 10 * time.Minute
+	ssoLoginCodeTTL      = time.Minute
+)
+
+type SSOService interface {
+	GetOIDCConfig(ctx context.Context, userID, workspaceID int) (*models.WorkspaceOIDCConfig, error)
+	ConfigureOIDC(ctx context.Context, userID, workspaceID int, form models.OIDCConfigModel) (*models.WorkspaceOIDCConfig, error)
+	DeleteOIDCConfig(ctx context.Context, userID, workspaceID int) error
+	StartOIDCLogin(ctx context.Context, workspaceID int) (string, error)
+	// StartOIDCLink starts a login that links the identity it ends with to userID's account. It is
+	// how an existing account starts using single sign-on.
+	StartOIDCLink(ctx context.Context, userID, workspaceID int) (string, error)
+	CompleteOIDCLogin(ctx context.Context, form models.OIDCCallbackModel) (*models.User, error)
+	GetSAMLConfig(ctx context.Context, userID, workspaceID int) (*models.WorkspaceSAMLConfig, error)
+	ConfigureSAML(ctx context.Context, userID, workspaceID int, form models.SAMLConfigModel) (*models.WorkspaceSAMLConfig, error)
+	DeleteSAMLConfig(ctx context.Context, userID, workspaceID int) error
+	SAMLMetadata(ctx context.Context, workspaceID int) ([]byte, error)
+	StartSAMLLogin(ctx context.Context, workspaceID int) (string, error)
+	// StartSAMLLink is StartOIDCLink for SAML.
+	StartSAMLLink(ctx context.Context, userID, workspaceID int) (string, error)
+	CompleteSAMLLogin(ctx context.Context, workspaceID int, samlResponse string) (string, error)
+	ExchangeLoginCode(ctx context.Context, code string) (*models.User, error)
+}
+
+type ssoService struct {
+	ssoRepo             repositories.SSORepo
+	userRepo            repositories.UserRepo
+	workspaceRepo       repositories.WorkspaceRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	quotaService        QuotaService
+	authorizer          Authorizer
+	oidcClient          *oidc.Client
+	log                 zerolog.Logger
+}
+
+func NewSSOService(ssoRepo repositories.SSORepo, userRepo repositories.UserRepo, workspaceRepo repositories.WorkspaceRepo, workspaceMemberRepo repositories.WorkspaceMemberRepo, quotaService QuotaService, authorizer Authorizer, oidcClient *oidc.Client, logger zerolog.Logger) SSOService {
+	return &ssoService{
+		ssoRepo:             ssoRepo,
+		userRepo:            userRepo,
+		workspaceRepo:       workspaceRepo,
+		workspaceMemberRepo: workspaceMemberRepo,
+		quotaService:        quotaService,
+		authorizer:          authorizer,
+		oidcClient:          oidcClient,
+		log:                 logger,
+	}
+}
+
+func (s *ssoService) GetOIDCConfig(ctx context.Context, userID, workspaceID int) (*models.WorkspaceOIDCConfig, error) {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return nil, err
+	}
+
+	config, err := s.ssoRepo.GetOIDCConfig(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, NewNotFoundError("OIDC is not configured for this workspace")
+	}
+	return config, nil
+}
+
+func (s *ssoService) ConfigureOIDC(ctx context.Context, userID, workspaceID int, form models.OIDCConfigModel) (*models.WorkspaceOIDCConfig, error) {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.ssoRepo.GetOIDCConfig(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := strings.TrimRight(strings.TrimSpace(form.Issuer), "/")
+	if issuer == "" || form.ClientID == "" {
+		return nil, NewValidationError("Issuer and client ID are required")
+	}
+	if !strings.HasPrefix(issuer, "https://") && !strings.HasPrefix(issuer, "http://localhost") {
+		return nil, NewValidationError("Issuer must be an https URL")
+	}
+
+	secret := form.ClientSecret
+	if secret == "" && existing != nil {
+		secret = existing.ClientSecret
+	}
+	if secret == "" {
+		return nil, NewValidationError("Client secret is required")
+	}
+
+	enabled := true
+	if form.Enabled != nil {
+		enabled = *form.Enabled
+	}
+
+	config := &models.WorkspaceOIDCConfig{
+		WorkspaceID:    workspaceID,
+		Issuer:         issuer,
+		ClientID:       form.ClientID,
+		ClientSecret:   secret,
+		AllowedDomains: normalizeDomains(form.AllowedDomains),
+		Enabled:        enabled,
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.ssoRepo.SaveOIDCConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Str("issuer", issuer).Msg("OIDC configuration saved.")
+	return config, nil
+}
+
+func (s *ssoService) DeleteOIDCConfig(ctx context.Context, userID, workspaceID int) error {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return err
+	}
+	if err := s.ssoRepo.DeleteOIDCConfig(ctx, workspaceID); err != nil {
+		return err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Msg("OIDC configuration deleted.")
+	return nil
+}
+
+func (s *ssoService) StartOIDCLogin(ctx context.Context, workspaceID int) (string, error) {
+	return s.startOIDCLogin(ctx, workspaceID, nil)
+}
+
+func (s *ssoService) StartOIDCLink(ctx context.Context, userID, workspaceID int) (string, error) {
+	return s.startOIDCLogin(ctx, workspaceID, &userID)
+}
+
+// startOIDCLogin prepares an authorization code request and returns the provider URL to send the
+// browser to. The PKCE verifier and nonce stay on the server.
+func (s *ssoService) startOIDCLogin(ctx context.Context, workspaceID int, linkUserID *int) (string, error) {
+	config, err := s.ssoRepo.GetOIDCConfig(ctx, workspaceID)
+	if err != nil {
+		return "", err
+	}
+	if config == nil || !config.Enabled {
+		return "", NewNotFoundError("OIDC is not enabled for this workspace")
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := s.oidcClient.AuthCodeURL(ctx, oidcClientConfig(config), state, nonce, verifier)
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to build OIDC authorization URL.")
+		return "", err
+	}
+
+	err = s.ssoRepo.CreateLoginState(ctx, &models.SSOLoginState{
+		State:        state,
+		WorkspaceID:  workspaceID,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(ssoLoginStateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Msg("OIDC login started.")
+	return authURL, nil
+}
+
+func (s *ssoService) CompleteOIDCLogin(ctx context.Context, form models.OIDCCallbackModel) (*models.User, error) {
+	loginState, err := s.ssoRepo.ConsumeLoginState(ctx, form.State)
+	if err != nil {
+		return nil, err
+	}
+	if loginState == nil || time.Now().After(loginState.ExpiresAt) || loginState.CodeVerifier == "" {
+		s.log.Info().Msg("Unknown or expired OIDC login state.")
+		return nil, NewUnauthorizedError("Unknown or expired login state")
+	}
+
+	config, err := s.ssoRepo.GetOIDCConfig(ctx, loginState.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil || !config.Enabled {
+		return nil, NewUnauthorizedError("OIDC is not enabled for this workspace")
+	}
+
+	claims, err := s.oidcClient.Exchange(ctx, oidcClientConfig(config), form.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		s.log.Warn().Err(err).Int("workspace_id", config.WorkspaceID).Msg("OIDC code exchange failed.")
+		return nil, NewUnauthorizedError("Identity provider rejected the login")
+	}
+
+	return s.provisionUser(ctx, externalIdentity{
+		provider:       identityProviderOIDC,
+		issuer:         config.Issuer,
+		subject:        claims.Subject,
+		email:          claims.Email,
+		emailVerified:  claims.EmailVerified,
+		name:           claims.Name,
+		username:       claims.PreferredUsername,
+		workspaceID:    config.WorkspaceID,
+		allowedDomains: config.AllowedDomains,
+		linkUserID:     loginState.LinkUserID,
+	})
+}
+
+// externalIdentity is what an identity provider asserted about the person logging in.
+type externalIdentity struct {
+	provider       string
+	issuer         string
+	subject        string
+	email          string
+	emailVerified  bool
+	name           string
+	username       string
+	timezone       string
+	locale         string
+	workspaceID    int
+	allowedDomains []string
+	// linkUserID is set when a signed-in user started the login to link the identity to their account.
+	linkUserID *int
+}
+
+// provisionUser maps an external identity to a local user. Known identities log straight in. New
+// ones are linked to the account of the user who started a linking login, or else get a fresh
+// account as long as the provider vouches for an email nobody has yet. Whoever configures a
+// workspace's identity provider controls what it asserts, so an existing account is never linked
+// by email alone. The user is then made a member of the workspace if needed.
+func (s *ssoService) provisionUser(ctx context.Context, ext externalIdentity) (*models.User, error) {
+	email := strings.ToLower(strings.TrimSpace(ext.email))
+	if !emailDomainAllowed(email, ext.allowedDomains) {
+		s.log.Warn().Int("workspace_id", ext.workspaceID).Str("email", email).Msg("SSO login from a domain that is not allowed.")
+		return nil, &ForbiddenError{Message: "Your email domain is not allowed to sign in to this workspace"}
+	}
+
+	now := time.Now()
+	identity, err := s.ssoRepo.GetIdentity(ctx, ext.provider, ext.issuer, ext.subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	if identity != nil {
+		if ext.linkUserID != nil && *ext.linkUserID != identity.UserID {
+			return nil, &ConflictError{Message: "This identity is already linked to another account"}
+		}
+		user, err = s.userRepo.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			s.log.Error().Err(err).Int("user_id", identity.UserID).Msg("Failed to fetch user for SSO identity.")
+			return nil, err
+		}
+	} else if ext.linkUserID != nil {
+		user, err = s.userRepo.GetUserByID(ctx, *ext.linkUserID)
+		if err != nil {
+			s.log.Error().Err(err).Int("user_id", *ext.linkUserID).Msg("Failed to fetch user to link SSO identity to.")
+			return nil, err
+		}
+		s.log.Info().Int("user_id", user.ID).Str("provider", ext.provider).Msg("Linking SSO identity to the account that asked for it.")
+		identity, err = s.createIdentity(ctx, user.ID, email, ext)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if !strings.Contains(email, "@") || !ext.emailVerified {
+			s.log.Warn().Int("workspace_id", ext.workspaceID).Msg("SSO login without a verified email.")
+			return nil, &ForbiddenError{Message: "The identity provider did not supply a verified email address"}
+		}
+
+		user, err = s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if user == nil {
+			// Check before creating the account, which would otherwise be left outside the workspace.
+			if err := s.quotaService.CheckSeats(ctx, ext.workspaceID); err != nil {
+				return nil, err
+			}
+			user, err = s.createSSOUser(ctx, email, ext)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			s.log.Warn().Int("user_id", user.ID).Int("workspace_id", ext.workspaceID).Msg("Refusing to link SSO identity to an existing account by email.")
+			return nil, &ConflictError{Message: "An account with this email already exists. Sign in with it and link single sign-on from there"}
+		}
+
+		identity, err = s.createIdentity(ctx, user.ID, email, ext)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identity.Email = email
+	identity.LastLoginAt = &now
+	if err := s.ssoRepo.UpdateIdentity(ctx, identity); err != nil {
+		s.log.Warn().Err(err).Int("identity_id", identity.ID).Msg("Failed to record SSO login on identity")
+	}
+
+	member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, ext.workspaceID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil {
+		if err := s.quotaService.CheckSeats(ctx, ext.workspaceID); err != nil {
+			return nil, err
+		}
+		if err := s.workspaceMemberRepo.AddMemberToWorkspace(ctx, ext.workspaceID, user.ID, models.Member); err != nil {
+			return nil, err
+		}
+		s.log.Info().Int("workspace_id", ext.workspaceID).Int("user_id", user.ID).Msg("Added SSO user to workspace.")
+	}
+
+	user.LastLoginAt = &now
+	if !user.IsVerified && ext.emailVerified && strings.EqualFold(user.Email, email) {
+		user.IsVerified = true
+	}
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to record last login time")
+	}
+
+	s.log.Info().Int("user_id", user.ID).Str("provider", ext.provider).Int("workspace_id", ext.workspaceID).Msg("User logged in with SSO.")
+	return user, nil
+}
+
+// createIdentity links the external identity to the account of userID.
+func (s *ssoService) createIdentity(ctx context.Context, userID int, email string, ext externalIdentity) (*models.UserIdentity, error) {
+	identity := &models.UserIdentity{
+		UserID:   userID,
+		Provider: ext.provider,
+		Issuer:   ext.issuer,
+		Subject:  ext.subject,
+		Email:    email,
+	}
+	if err := s.ssoRepo.CreateIdentity(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// createSSOUser creates an account for someone who has only ever signed in through SSO. It gets an
+// unusable random password; a password reset gives it a real one.
+func (s *ssoService) createSSOUser(ctx context.Context, email string, ext externalIdentity) (*models.User, error) {
+	local := email[:strings.Index(email, "@")]
+	name := ext.name
+	if name == "" {
+		name = local
+	}
+	locale := ext.locale
+	if locale == "" {
+		locale = "en-US"
+	}
+
+	username, err := s.availableUsername(ctx, ext.username, local)
+	if err != nil {
+		return nil, err
+	}
+
+	random, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := utils.HashPassword(random)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Name:       name,
+		Username:   username,
+		Email:      email,
+		Password:   hashedPassword,
+		Status:     models.Active,
+		Timezone:   ext.timezone,
+		Locale:     locale,
+		IsVerified: true,
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Provisioned user from SSO login.")
+	return user, nil
+}
+
+func (s *ssoService) availableUsername(ctx context.Context, candidates ...string) (string, error) {
+	base := ""
+	for _, c := range candidates {
+		if c = strings.TrimSpace(c); c != "" {
+			base = c
+			break
+		}
+	}
+	if base == "" {
+		base = "user"
+	}
+
+	for i := 0; i < 20; i++ {
+		username := base
+		if i > 0 {
+			username = fmt.Sprintf("%s%d", base, i+1)
+		}
+		_, err := s.userRepo.GetUserByUsername(ctx, username)
+		if err == sql.ErrNoRows {
+			return username, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	suffix, err := utils.GenerateOpaqueToken(4)
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + strings.ToLower(suffix), nil
+}
+
+func (s *ssoService) GetSAMLConfig(ctx context.Context, userID, workspaceID int) (*models.WorkspaceSAMLConfig, error) {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return nil, err
+	}
+
+	config, err := s.ssoRepo.GetSAMLConfig(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, NewNotFoundError("SAML is not configured for this workspace")
+	}
+	return config, nil
+}
+
+func (s *ssoService) ConfigureSAML(ctx context.Context, userID, workspaceID int, form models.SAMLConfigModel) (*models.WorkspaceSAMLConfig, error) {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return nil, err
+	}
+
+	if form.IdPEntityID == "" || form.IdPSSOURL == "" || form.IdPCertificate == "" {
+		return nil, NewValidationError("IdP entity ID, SSO URL and certificate are required")
+	}
+	if !strings.HasPrefix(form.IdPSSOURL, "https://") && !strings.HasPrefix(form.IdPSSOURL, "http://localhost") {
+		return nil, NewValidationError("IdP SSO URL must be an https URL")
+	}
+	if _, err := saml.ParseCertificates(form.IdPCertificate); err != nil {
+		return nil, NewValidationError("IdP certificate is invalid: " + err.Error())
+	}
+
+	enabled := true
+	if form.Enabled != nil {
+		enabled = *form.Enabled
+	}
+
+	config := &models.WorkspaceSAMLConfig{
+		WorkspaceID:       workspaceID,
+		IdPEntityID:       strings.TrimSpace(form.IdPEntityID),
+		IdPSSOURL:         strings.TrimSpace(form.IdPSSOURL),
+		IdPCertificate:    strings.TrimSpace(form.IdPCertificate),
+		AllowedDomains:    normalizeDomains(form.AllowedDomains),
+		Enabled:           enabled,
+		EmailAttribute:    form.EmailAttribute,
+		NameAttribute:     form.NameAttribute,
+		UsernameAttribute: form.UsernameAttribute,
+		TimezoneAttribute: form.TimezoneAttribute,
+		LocaleAttribute:   form.LocaleAttribute,
+		UpdatedAt:         time.Now(),
+	}
+	if err := s.ssoRepo.SaveSAMLConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Str("idp_entity_id", config.IdPEntityID).Msg("SAML configuration saved.")
+	return config, nil
+}
+
+func (s *ssoService) DeleteSAMLConfig(ctx context.Context, userID, workspaceID int) error {
+	if _, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceSSO); err != nil {
+		return err
+	}
+	if err := s.ssoRepo.DeleteSAMLConfig(ctx, workspaceID); err != nil {
+		return err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Msg("SAML configuration deleted.")
+	return nil
+}
+
+// SAMLMetadata returns the SP metadata an IdP administrator imports. It is available before SAML is
+// configured so the IdP side can be set up first.
+func (s *ssoService) SAMLMetadata(ctx context.Context, workspaceID int) ([]byte, error) {
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	return samlServiceProvider(workspaceID).Metadata()
+}
+
+func (s *ssoService) StartSAMLLogin(ctx context.Context, workspaceID int) (string, error) {
+	return s.startSAMLLogin(ctx, workspaceID, nil)
+}
+
+func (s *ssoService) StartSAMLLink(ctx context.Context, userID, workspaceID int) (string, error) {
+	return s.startSAMLLogin(ctx, workspaceID, &userID)
+}
+
+func (s *ssoService) startSAMLLogin(ctx context.Context, workspaceID int, linkUserID *int) (string, error) {
+	config, idp, err := s.samlIdentityProvider(ctx, workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	requestID, redirectURL, err := samlServiceProvider(workspaceID).AuthnRequestURL(idp, "")
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to build SAML AuthnRequest.")
+		return "", err
+	}
+
+	err = s.ssoRepo.CreateLoginState(ctx, &models.SSOLoginState{
+		State:       requestID,
+		WorkspaceID: config.WorkspaceID,
+		LinkUserID:  linkUserID,
+		ExpiresAt:   time.Now().Add(ssoLoginStateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Msg("SAML login started.")
+	return redirectURL, nil
+}
+
+// CompleteSAMLLogin validates a response posted to the ACS endpoint, provisions the user and
+// returns a one-time code for ExchangeLoginCode. Only responses to requests started with
+// StartSAMLLogin are accepted, which also stops a response from being replayed.
+func (s *ssoService) CompleteSAMLLogin(ctx context.Context, workspaceID int, samlResponse string) (string, error) {
+	config, idp, err := s.samlIdentityProvider(ctx, workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	assertion, err := samlServiceProvider(workspaceID).ParseResponse(idp, samlResponse, time.Now())
+	if err != nil {
+		s.log.Warn().Err(err).Int("workspace_id", workspaceID).Msg("SAML response rejected.")
+		return "", NewUnauthorizedError("Invalid SAML response")
+	}
+
+	loginState, err := s.ssoRepo.ConsumeLoginState(ctx, assertion.InResponseTo)
+	if err != nil {
+		return "", err
+	}
+	if assertion.InResponseTo == "" || loginState == nil || loginState.WorkspaceID != workspaceID || time.Now().After(loginState.ExpiresAt) {
+		s.log.Warn().Int("workspace_id", workspaceID).Msg("SAML response does not answer a pending request.")
+		return "", NewUnauthorizedError("SAML response does not match a pending login")
+	}
+
+	email := assertion.NameID
+	if config.EmailAttribute != "" {
+		email = assertion.Attribute(config.EmailAttribute)
+	}
+	user, err := s.provisionUser(ctx, externalIdentity{
+		provider:       identityProviderSAML,
+		issuer:         config.IdPEntityID,
+		subject:        assertion.NameID,
+		email:          email,
+		emailVerified:  true,
+		name:           samlAttribute(assertion, config.NameAttribute),
+		username:       samlAttribute(assertion, config.UsernameAttribute),
+		timezone:       samlAttribute(assertion, config.TimezoneAttribute),
+		locale:         samlAttribute(assertion, config.LocaleAttribute),
+		workspaceID:    workspaceID,
+		allowedDomains: config.AllowedDomains,
+		linkUserID:     loginState.LinkUserID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	code, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.ssoRepo.CreateLoginCode(ctx, &models.SSOLoginCode{
+		CodeHash:  utils.HashToken(code),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(ssoLoginCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *ssoService) ExchangeLoginCode(ctx context.Context, code string) (*models.User, error) {
+	loginCode, err := s.ssoRepo.ConsumeLoginCode(ctx, utils.HashToken(code))
+	if err != nil {
+		return nil, err
+	}
+	if loginCode == nil || time.Now().After(loginCode.ExpiresAt) {
+		s.log.Info().Msg("Unknown or expired SSO login code.")
+		return nil, NewUnauthorizedError("Invalid or expired login code")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, loginCode.UserID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", loginCode.UserID).Msg("Failed to fetch user for SSO login code.")
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *ssoService) samlIdentityProvider(ctx context.Context, workspaceID int) (*models.WorkspaceSAMLConfig, *saml.IdentityProvider, error) {
+	config, err := s.ssoRepo.GetSAMLConfig(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if config == nil || !config.Enabled {
+		return nil, nil, NewNotFoundError("SAML is not enabled for this workspace")
+	}
+	certs, err := saml.ParseCertificates(config.IdPCertificate)
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Stored SAML certificate is invalid.")
+		return nil, nil, err
+	}
+	return config, &saml.IdentityProvider{
+		EntityID:     config.IdPEntityID,
+		SSOURL:       config.IdPSSOURL,
+		Certificates: certs,
+	}, nil
+}
+
+func samlServiceProvider(workspaceID int) *saml.ServiceProvider {
+	return &saml.ServiceProvider{
+		EntityID: utils.APIURL(fmt.Sprintf("/api/sso/saml/%d/metadata", workspaceID)),
+		ACSURL:   utils.APIURL(fmt.Sprintf("/api/sso/saml/%d/acs", workspaceID)),
+	}
+}
+
+func samlAttribute(assertion *saml.Assertion, name string) string {
+	if name == "" {
+		return ""
+	}
+	return assertion.Attribute(name)
+}
+
+func normalizeDomains(domains []string) []string {
+	out := make([]string, 0, len(domains))
+	for _, d := range domains {
+		if d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@")); d != "" {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func oidcClientConfig(config *models.WorkspaceOIDCConfig) oidc.Config {
+	return oidc.Config{
+		Issuer:       config.Issuer,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  utils.AppURL("/sso/oidc/callback", nil),
+	}
+}
+
+func emailDomainAllowed(email string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, d := range allowed {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"github.com/rs/zerolog"
+)
+
+// StatusEvent is the WebSocket event type announcing a status change.
+const StatusEvent = "status"
+
+// StatusService manages custom statuses and Do Not Disturb, and tells workspace peers about
+// every change.
+type StatusService interface {
+	// GetStatus returns the status of userID as seen by viewerID, who must be the user or share a
+	// workspace with them.
+	GetStatus(ctx context.Context, viewerID, userID int) (*models.UserStatusInfo, error)
+	SetStatus(ctx context.Context, userID int, form models.SetStatusModel) (*models.UserStatusInfo, error)
+	ClearStatus(ctx context.Context, userID int) (*models.UserStatusInfo, error)
+	SetDoNotDisturb(ctx context.Context, userID int, form models.SetDoNotDisturbModel) (*models.UserStatusInfo, error)
+	ClearDoNotDisturb(ctx context.Context, userID int) (*models.UserStatusInfo, error)
+	SetDoNotDisturbSchedule(ctx context.Context, userID int, schedule models.DNDSchedule) (*models.UserStatusInfo, error)
+	ClearDoNotDisturbSchedule(ctx context.Context, userID int) (*models.UserStatusInfo, error)
+	// RunExpiryWorker clears statuses that ran out and announces scheduled Do Not Disturb windows
+	// opening and closing, every interval until ctx is done.
+	RunExpiryWorker(ctx context.Context, interval time.Duration)
+}
+
+type statusService struct {
+	userRepo            repositories.UserRepo
+	statusRepo          repositories.StatusRepo
+	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	eventService        EventService
+	log                 zerolog.Logger
+}
+
+func NewStatusService(userRepo repositories.UserRepo, statusRepo repositories.StatusRepo, wmr repositories.WorkspaceMemberRepo, es EventService, logger zerolog.Logger) StatusService {
+	return &statusService{
+		userRepo:            userRepo,
+		statusRepo:          statusRepo,
+		workspaceMemberRepo: wmr,
+		eventService:        es,
+		log:                 logger,
+	}
+}
+
+func (s *statusService) GetStatus(ctx context.Context, viewerID, userID int) (*models.UserStatusInfo, error) {
+	if viewerID != userID {
+		peerIDs, err := s.workspaceMemberRepo.GetWorkspacePeerIDs(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(peerIDs, userID) {
+			return nil, NewNotFoundError("User not found")
+		}
+	}
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return statusInfo(user, time.Now()), nil
+}
+
+func (s *statusService) SetStatus(ctx context.Context, userID int, form models.SetStatusModel) (*models.UserStatusInfo, error) {
+	form.Text = strings.TrimSpace(form.Text)
+	form.Emoji = strings.TrimSpace(form.Emoji)
+	switch {
+	case form.Status != nil && (*form.Status < models.Active || *form.Status > models.AFK):
+		return nil, NewValidationError("Status must be 0 (active), 1 (busy) or 2 (away)")
+	case utf8.RuneCountInString(form.Text) > 100:
+		return nil, NewValidationError("Status text must be at most 100 characters long")
+	case utf8.RuneCountInString(form.Emoji) > 32:
+		return nil, NewValidationError("Status emoji must be at most 32 characters long")
+	case form.ExpiresAt != nil && !form.ExpiresAt.After(time.Now()):
+		return nil, NewValidationError("Status expiry must be in the future")
+	}
+
+	return s.update(ctx, userID, func(user *models.User) {
+		if form.Status != nil {
+			user.Status = *form.Status
+		}
+		user.StatusText = form.Text
+		user.StatusEmoji = form.Emoji
+		user.StatusExpiresAt = form.ExpiresAt
+	})
+}
+
+func (s *statusService) ClearStatus(ctx context.Context, userID int) (*models.UserStatusInfo, error) {
+	return s.update(ctx, userID, clearCustomStatus)
+}
+
+func (s *statusService) SetDoNotDisturb(ctx context.Context, userID int, form models.SetDoNotDisturbModel) (*models.UserStatusInfo, error) {
+	if !form.Until.After(time.Now()) {
+		return nil, NewValidationError("Do Not Disturb must end in the future")
+	}
+	return s.update(ctx, userID, func(user *models.User) {
+		until := form.Until
+		user.DNDUntil = &until
+	})
+}
+
+func (s *statusService) ClearDoNotDisturb(ctx context.Context, userID int) (*models.UserStatusInfo, error) {
+	return s.update(ctx, userID, func(user *models.User) {
+		user.DNDUntil = nil
+	})
+}
+
+func (s *statusService) SetDoNotDisturbSchedule(ctx context.Context, userID int, schedule models.DNDSchedule) (*models.UserStatusInfo, error) {
+	if err := validateDNDSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, userID, func(user *models.User) {
+		user.DNDSchedule = &schedule
+	})
+}
+
+func (s *statusService) ClearDoNotDisturbSchedule(ctx context.Context, userID int) (*models.UserStatusInfo, error) {
+	return s.update(ctx, userID, func(user *models.User) {
+		user.DNDSchedule = nil
+	})
+}
+
+func (s *statusService) RunExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.expireStatuses(ctx, last, now)
+			last = now
+		}
+	}
+}
+
+// expireStatuses does one pass of the expiry worker for the period (last, now].
+func (s *statusService) expireStatuses(ctx context.Context, last, now time.Time) {
+	announced := make(map[int]bool)
+
+	expired, err := s.statusRepo.GetExpiredStatuses(ctx, now)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to get expired statuses")
+		return
+	}
+	for i := range expired {
+		user := &expired[i]
+		if user.StatusExpiresAt != nil && !user.StatusExpiresAt.After(now) {
+			clearCustomStatus(user)
+		}
+		if user.DNDUntil != nil && !user.DNDUntil.After(now) {
+			user.DNDUntil = nil
+		}
+		if err := s.statusRepo.UpdateStatus(ctx, user); err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to clear expired status")
+			continue
+		}
+		s.announce(ctx, user, now)
+		announced[user.ID] = true
+	}
+
+	scheduled, err := s.statusRepo.GetScheduledDoNotDisturb(ctx)
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to get scheduled Do Not Disturb windows")
+		return
+	}
+	for i := range scheduled {
+		user := &scheduled[i]
+		if announced[user.ID] {
+			continue
+		}
+		if scheduledDoNotDisturb(user, last) != scheduledDoNotDisturb(user, now) {
+			s.announce(ctx, user, now)
+		}
+	}
+}
+
+// update applies change to the user's status, saves it and announces the result.
+func (s *statusService) update(ctx context.Context, userID int, change func(user *models.User)) (*models.UserStatusInfo, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	change(user)
+	if err := s.statusRepo.UpdateStatus(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.announce(ctx, user, time.Now()), nil
+}
+
+func (s *statusService) announce(ctx context.Context, user *models.User, now time.Time) *models.UserStatusInfo {
+	info := statusInfo(user, now)
+	if err := s.eventService.PublishToWorkspacePeers(ctx, user.ID, StatusEvent, info); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to announce status change")
+	}
+	return info
+}
+
+func (s *statusService) getUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewNotFoundError("User not found")
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func clearCustomStatus(user *models.User) {
+	user.StatusText = ""
+	user.StatusEmoji = ""
+	user.StatusExpiresAt = nil
+}
+
+// statusInfo describes the user's status at now. Anything past its expiry is left out even if the
+// worker has not cleared it yet.
+func statusInfo(user *models.User, now time.Time) *models.UserStatusInfo {
+	info := &models.UserStatusInfo{
+		UserID:      user.ID,
+		Status:      user.Status,
+		DNDSchedule: user.DNDSchedule,
+	}
+	if user.StatusExpiresAt == nil || user.StatusExpiresAt.After(now) {
+		info.Text = user.StatusText
+		info.Emoji = user.StatusEmoji
+		info.ExpiresAt = user.StatusExpiresAt
+	}
+	if user.DNDUntil != nil && user.DNDUntil.After(now) {
+		info.DNDUntil = user.DNDUntil
+	}
+	info.DoNotDisturb = info.DNDUntil != nil || scheduledDoNotDisturb(user, now)
+	return info
+}
+
+// scheduledDoNotDisturb reports whether now falls in the user's Do Not Disturb schedule, read in
+// the user's timezone, or UTC when it is unset or unknown.
+func scheduledDoNotDisturb(user *models.User, now time.Time) bool {
+	schedule := user.DNDSchedule
+	if schedule == nil {
+		return false
+	}
+	start, err := parseClock(schedule.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(schedule.End)
+	if err != nil {
+		return false
+	}
+
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	today := slices.Contains(schedule.Days, local.Weekday())
+
+	if start < end {
+		return today && minute >= start && minute < end
+	}
+	// Overnight: the evening part belongs to today, the morning part to yesterday's window.
+	yesterday := slices.Contains(schedule.Days, (local.Weekday()+6)%7)
+	return (today && minute >= start) || (yesterday && minute < end)
+}
+
+func validateDNDSchedule(schedule models.DNDSchedule) error {
+	if len(schedule.Days) == 0 {
+		return NewValidationError("Schedule needs at least one day")
+	}
+	for _, day := range schedule.Days {
+		if day < time.Sunday || day > time.Saturday {
+			return NewValidationError("Schedule days must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	start, err := parseClock(schedule.Start)
+	if err != nil {
+		return NewValidationError("Schedule start must be a time like 22:00")
+	}
+	end, err := parseClock(schedule.End)
+	if err != nil {
+		return NewValidationError("Schedule end must be a time like 07:00")
+	}
+	if start == end {
+		return NewValidationError("Schedule start and end must differ")
+	}
+	return nil
+}
+
+// parseClock turns "HH:MM" into minutes after midnight.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"axis/internal/models"
+)
+
+func TestScheduledDoNotDisturb(t *testing.T) {
+	// Weeknights 22:00 to 07:00 in Berlin, which is UTC+2 in July.
+	user := &models.User{
+		Timezone: "Europe/Berlin",
+		DNDSchedule: &models.DNDSchedule{
+			Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Start: "22:00",
+			End:   "07:00",
+		},
+	}
+
+	tests := []struct {
+		utc    string
+		active bool
+	}{
+		{"2024-07-01T19:59:00Z", false}, // Monday 21:59
+		{"2024-07-01T20:00:00Z", true},  // Monday 22:00
+		{"2024-07-02T04:59:00Z", true},  // Tuesday 06:59, Monday's window
+		{"2024-07-02T05:00:00Z", false}, // Tuesday 07:00
+		{"2024-07-06T03:00:00Z", true},  // Saturday 05:00, Friday's window
+		{"2024-07-06T21:00:00Z", false}, // Saturday 23:00
+		{"2024-07-08T03:00:00Z", false}, // Monday 05:00, Sunday has no window
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.utc)
+		if got := scheduledDoNotDisturb(user, now); got != tt.active {
+			t.Errorf("scheduledDoNotDisturb at %s = %v, want %v", tt.utc, got, tt.active)
+		}
+	}
+}
+
+func TestStatusInfoHidesExpiredStatus(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	user := &models.User{
+		ID:              7,
+		StatusText:      "Lunch",
+		StatusEmoji:     ":sandwich:",
+		StatusExpiresAt: &past,
+		DNDUntil:        &future,
+	}
+
+	info := statusInfo(user, now)
+	if info.Text != "" || info.Emoji != "" || info.ExpiresAt != nil {
+		t.Errorf("expired status was shown: %+v", info)
+	}
+	if !info.DoNotDisturb || info.DNDUntil == nil {
+		t.Errorf("manual Do Not Disturb was not shown: %+v", info)
+	}
+
+	user.DNDUntil = &past
+	if statusInfo(user, now).DoNotDisturb {
+		t.Error("expired Do Not Disturb is still active")
+	}
+}
+
+func TestValidateDNDSchedule(t *testing.T) {
+	valid := models.DNDSchedule{Days: []time.Weekday{time.Saturday}, Start: "09:00", End: "17:30"}
+	if err := validateDNDSchedule(valid); err != nil {
+		t.Errorf("validateDNDSchedule(%+v) = %v", valid, err)
+	}
+	for _, schedule := range []models.DNDSchedule{
+		{Start: "09:00", End: "17:00"},
+		{Days: []time.Weekday{7}, Start: "09:00", End: "17:00"},
+		{Days: []time.Weekday{time.Monday}, Start: "9am", End: "17:00"},
+		{Days: []time.Weekday{time.Monday}, Start: "09:00", End: "09:00"},
+	} {
+		if _, ok := validateDNDSchedule(schedule).(*ValidationError); !ok {
+			t.Errorf("validateDNDSchedule(%+v) accepted an invalid schedule", schedule)
+		}
+	}
+}
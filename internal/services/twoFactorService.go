@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/utils"
+)
+
+const (
+	totpIssuer            = "Axis"
+	twoFactorChallengeTTL = 5 * time.Minute
+	recoveryCodeCount     = 10
+)
+
+type TwoFactorService interface {
+	BeginSetup(ctx context.Context, userID int) (*models.TOTPSetup, error)
+	Enable(ctx context.Context, userID int, code, ipAddress string) ([]string, error)
+	Disable(ctx context.Context, userID int, form models.DisableTwoFactorModel, ipAddress string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, form models.RegenerateRecoveryCodesModel, ipAddress string) ([]string, error)
+	// Reauthenticate confirms a sensitive action of a signed-in user by their password and, when 2FA
+	// is enabled, a TOTP or recovery code.
+	Reauthenticate(ctx context.Context, user *models.User, password, code, ipAddress string) error
+	CompleteLogin(ctx context.Context, form models.TwoFactorLoginModel, ipAddress string) (*models.User, error)
+}
+
+type twoFactorService struct {
+	userRepo            repositories.UserRepo
+	recoveryCodeRepo    repositories.RecoveryCodeRepo
+	loginAttemptService LoginAttemptService
+	log                 zerolog.Logger
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepo, recoveryCodeRepo repositories.RecoveryCodeRepo, las LoginAttemptService, logger zerolog.Logger) TwoFactorService {
+	return &twoFactorService{
+		userRepo:            userRepo,
+		recoveryCodeRepo:    recoveryCodeRepo,
+		loginAttemptService: las,
+		log:                 logger,
+	}
+}
+
+// BeginSetup stores a fresh, not yet active TOTP secret. 2FA only turns on once Enable sees a valid
+// code for it, so a user who abandons setup is never locked out.
+func (s *twoFactorService) BeginSetup(ctx context.Context, userID int) (*models.TOTPSetup, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, &ConflictError{Message: "Two-factor authentication is already enabled"}
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to generate TOTP secret.")
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastUsedStep = 0
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to store pending TOTP secret.")
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("TOTP setup started.")
+	return &models.TOTPSetup{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *twoFactorService) Enable(ctx context.Context, userID int, code, ipAddress string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, &ConflictError{Message: "Two-factor authentication is already enabled"}
+	}
+	if user.TOTPSecret == "" {
+		return nil, NewValidationError("Two-factor setup has not been started")
+	}
+
+	err = s.throttled(ctx, user, ipAddress, func() error {
+		if !s.checkTOTP(user, code) {
+			s.log.Info().Int("user_id", userID).Msg("Invalid TOTP code while enabling 2FA.")
+			return NewValidationError("Invalid authentication code")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user.TwoFactorEnabled = true
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to enable 2FA.")
+		return nil, err
+	}
+
+	codes, err := s.issueRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("Two-factor authentication enabled.")
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userID int, form models.DisableTwoFactorModel, ipAddress string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return &ConflictError{Message: "Two-factor authentication is not enabled"}
+	}
+
+	if err := s.Reauthenticate(ctx, user, form.Password, form.Code, ipAddress); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastUsedStep = 0
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to disable 2FA.")
+		return err
+	}
+	if err := s.recoveryCodeRepo.DeleteCodesForUser(ctx, userID); err != nil {
+		return err
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("Two-factor authentication disabled.")
+	return nil
+}
+
+func (s *twoFactorService) Reauthenticate(ctx context.Context, user *models.User, password, code, ipAddress string) error {
+	err := s.throttled(ctx, user, ipAddress, func() error {
+		if !utils.CheckPassword(user.Password, password) {
+			s.log.Warn().Int("user_id", user.ID).Msg("Invalid password for a sensitive action.")
+			return NewUnauthorizedError("Password is incorrect")
+		}
+		if !user.TwoFactorEnabled {
+			return nil
+		}
+		ok, err := s.checkSecondFactor(ctx, user, code, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			s.log.Warn().Int("user_id", user.ID).Msg("Invalid authentication code for a sensitive action.")
+			return NewUnauthorizedError("Invalid authentication code")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if user.TwoFactorEnabled {
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record used TOTP step.")
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID int, form models.RegenerateRecoveryCodesModel, ipAddress string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, &ConflictError{Message: "Two-factor authentication is not enabled"}
+	}
+	err = s.throttled(ctx, user, ipAddress, func() error {
+		if !utils.CheckPassword(user.Password, form.Password) {
+			s.log.Warn().Int("user_id", userID).Msg("Invalid password while regenerating recovery codes.")
+			return NewUnauthorizedError("Password is incorrect")
+		}
+		if !s.checkTOTP(user, form.Code) {
+			s.log.Warn().Int("user_id", userID).Msg("Invalid TOTP code while regenerating recovery codes.")
+			return NewUnauthorizedError("Invalid authentication code")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to record used TOTP step.")
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// CompleteLogin finishes a login started by UserService.Login for an account with 2FA. Either a
+// TOTP code or an unused recovery code is accepted.
+func (s *twoFactorService) CompleteLogin(ctx context.Context, form models.TwoFactorLoginModel, ipAddress string) (*models.User, error) {
+	claims, err := utils.ParseActionToken(form.ChallengeToken, utils.PurposeTwoFactorLogin)
+	if err != nil {
+		s.log.Info().Err(err).Msg("Invalid two-factor login challenge.")
+		return nil, NewUnauthorizedError("Invalid or expired login challenge")
+	}
+
+	user, err := s.getUser(ctx, claims.UserID)
+	if err != nil {
+		if _, ok := err.(*NotFoundError); ok {
+			return nil, NewUnauthorizedError("Invalid or expired login challenge")
+		}
+		return nil, err
+	}
+	if !user.TwoFactorEnabled || user.Email != claims.Email {
+		return nil, NewUnauthorizedError("Invalid or expired login challenge")
+	}
+
+	err = s.throttled(ctx, user, ipAddress, func() error {
+		ok, err := s.checkSecondFactor(ctx, user, form.Code, form.RecoveryCode)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			s.log.Warn().Int("user_id", user.ID).Msg("Two-factor login with invalid code.")
+			return NewUnauthorizedError("Invalid authentication code")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record two-factor login.")
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Two-factor login completed.")
+	return user, nil
+}
+
+// throttled runs check, which proves the user's identity, behind the login lockout. Codes are
+// short, so wrong ones count towards the same lockout as wrong passwords, whether they are entered
+// to sign in or to confirm a change to the account. check returns a ValidationError or an
+// UnauthorizedError for a wrong password or code.
+func (s *twoFactorService) throttled(ctx context.Context, user *models.User, ipAddress string, check func() error) error {
+	if err := s.loginAttemptService.Check(ctx, user.Email, ipAddress); err != nil {
+		return err
+	}
+	err := check()
+	switch err.(type) {
+	case nil:
+		if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+			s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear failed login attempts.")
+		}
+	case *ValidationError, *UnauthorizedError:
+		if err := s.loginAttemptService.RecordFailure(ctx, user.Email, ipAddress, user); err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record failed two-factor attempt.")
+		}
+	}
+	return err
+}
+
+func (s *twoFactorService) getUser(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to fetch user for 2FA.")
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkTOTP validates code and advances the user's last used step in memory so the same code
+// cannot be replayed. Callers persist the user.
+func (s *twoFactorService) checkTOTP(user *models.User, code string) bool {
+	if code == "" || user.TOTPSecret == "" {
+		return false
+	}
+	step, ok := utils.ValidateTOTPCode(user.TOTPSecret, code, time.Now())
+	if !ok || step <= user.TOTPLastUsedStep {
+		return false
+	}
+	user.TOTPLastUsedStep = step
+	return true
+}
+
+// checkSecondFactor accepts a TOTP code first and falls back to consuming a recovery code. A
+// recovery code is always single use; the TOTP step is persisted by the caller.
+func (s *twoFactorService) checkSecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) (bool, error) {
+	if s.checkTOTP(user, code) {
+		return true, nil
+	}
+	normalized := normalizeRecoveryCode(recoveryCode)
+	if normalized == "" {
+		return false, nil
+	}
+	consumed, err := s.recoveryCodeRepo.ConsumeCode(ctx, user.ID, utils.HashToken(normalized))
+	if err != nil {
+		return false, err
+	}
+	if consumed {
+		s.log.Info().Int("user_id", user.ID).Msg("Recovery code used.")
+	}
+	return consumed, nil
+}
+
+func (s *twoFactorService) issueRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]*models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to generate recovery code.")
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, &models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceCodesForUser(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// newTwoFactorChallenge issues the short-lived token exchanged for a session once the second
+// factor is verified.
+func newTwoFactorChallenge(user *models.User) (*models.TwoFactorChallenge, error) {
+	token, err := utils.GenerateActionToken(utils.PurposeTwoFactorLogin, user.ID, user.Email, twoFactorChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TwoFactorChallenge{
+		ChallengeToken: token,
+		ExpiresAt:      time.Now().Add(twoFactorChallengeTTL),
+	}, nil
+}
+
+// generateRecoveryCode returns a code such as "k3m9x-2qv7d" that is easy to read off paper.
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -3,37 +3,79 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"github.com/rs/zerolog"
 	"golang.org/x/crypto/bcrypt"
 
+	"axis/internal/mailer"
 	"axis/internal/models"
 	"axis/internal/repositories"
+	"axis/internal/utils"
+)
+
+const (
+	verificationTokenTTL       = 24 * time.Hour
+	verificationResendInterval = time.Minute
+	passwordResetTokenTTL      = time.Hour
+	loginLinkTTL               = 15 * time.Minute
+	loginLinkMinInterval       = time.Minute
+	loginLinksPerHour          = 5
 )
 
 type UserService interface {
 	Register(ctx context.Context, form models.RegisterModel) (*models.User, error)
-	Login(ctx context.Context, creds models.LoginModel) (*models.User, error)
+	Login(ctx context.Context, creds models.LoginModel, ipAddress string) (*models.User, *models.TwoFactorChallenge, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	UpdateUser(ctx context.Context, userID int, newUser models.UpdateUser) (*models.User, error)
-	DeleteUser(ctx context.Context, id int) error
+	ResendVerificationEmail(ctx context.Context, userID int) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ChangePassword(ctx context.Context, userID, currentSessionID int, form models.ChangePasswordModel) error
+	SendLoginLink(ctx context.Context, email string) error
+	LoginWithLink(ctx context.Context, token string) (*models.User, *models.TwoFactorChallenge, error)
+	PasswordPolicy() *PasswordPolicy
 }
 
 type userService struct {
-	userRepo repositories.UserRepo
-	log      zerolog.Logger
+	userRepo            repositories.UserRepo
+	sessionRepo         repositories.SessionRepo
+	passwordResetRepo   repositories.PasswordResetRepo
+	loginLinkRepo       repositories.LoginLinkRepo
+	loginAttemptService LoginAttemptService
+	passwordPolicy      *PasswordPolicy
+	mailer              mailer.Mailer
+	log                 zerolog.Logger
 }
 
-func NewUserService(userRepo repositories.UserRepo, logger zerolog.Logger) UserService {
+func NewUserService(userRepo repositories.UserRepo, sessionRepo repositories.SessionRepo, passwordResetRepo repositories.PasswordResetRepo, loginLinkRepo repositories.LoginLinkRepo, las LoginAttemptService, policy *PasswordPolicy, m mailer.Mailer, logger zerolog.Logger) UserService {
 	return &userService{
-		userRepo: userRepo,
-		log:      logger,
+		userRepo:            userRepo,
+		sessionRepo:         sessionRepo,
+		passwordResetRepo:   passwordResetRepo,
+		loginLinkRepo:       loginLinkRepo,
+		loginAttemptService: las,
+		passwordPolicy:      policy,
+		mailer:              m,
+		log:                 logger,
 	}
 }
 
 func (s *userService) Register(ctx context.Context, form models.RegisterModel) (*models.User, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(form.Password), bcrypt.DefaultCost)
+	if err := s.passwordPolicy.Validate(form.Password, form.Name, form.Username, form.Email); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := hashPassword(form.Password)
 	if err != nil {
 		s.log.Error().Err(err).Msg("Can't hash the password")
 		return nil, err
@@ -43,7 +85,7 @@ func (s *userService) Register(ctx context.Context, form models.RegisterModel) (
 		Name:        form.Name,
 		Username:    form.Username,
 		Email:       form.Email,
-		Password:    string(hashedPassword),
+		Password:    hashedPassword,
 		Status:      models.Active,
 		Timezone:    form.Timezone,
 		Locale:      form.Locale,
@@ -57,26 +99,67 @@ func (s *userService) Register(ctx context.Context, form models.RegisterModel) (
 		return nil, err
 	}
 
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send verification email after registration")
+	}
+
 	return user, nil
 }
 
-func (s *userService) Login(ctx context.Context, creds models.LoginModel) (*models.User, error) {
+// Login checks the password. For accounts with 2FA it returns a challenge instead of recording the
+// login; the caller must then complete it through TwoFactorService.CompleteLogin.
+func (s *userService) Login(ctx context.Context, creds models.LoginModel, ipAddress string) (*models.User, *models.TwoFactorChallenge, error) {
+	if err := s.loginAttemptService.Check(ctx, creds.Email, ipAddress); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, creds.Email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			s.log.Info().Str("email", creds.Email).Msg("User not found during login.")
-			return nil, err
+			s.log.Info().Str("ip_address", ipAddress).Msg("Login for an unknown email.")
+			if err := s.loginAttemptService.RecordFailure(ctx, creds.Email, ipAddress, nil); err != nil {
+				s.log.Error().Err(err).Msg("Failed to record failed login attempt")
+			}
+			return nil, nil, NewUnauthorizedError("Invalid credentials")
 		}
-		s.log.Error().Err(err).Str("email", creds.Email).Msg("Failed to fetch user by email for login")
-		return nil, err
+		s.log.Error().Err(err).Msg("Failed to fetch user by email for login")
+		return nil, nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(creds.Password)); err != nil {
-		s.log.Error().Err(err).Str("email", creds.Email).Msg("Login with Invalid password.")
-		return nil, err
+	if !utils.CheckPassword(user.Password, creds.Password) {
+		s.log.Info().Int("user_id", user.ID).Str("ip_address", ipAddress).Msg("Login with invalid password.")
+		if err := s.loginAttemptService.RecordFailure(ctx, creds.Email, ipAddress, user); err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record failed login attempt")
+		}
+		return nil, nil, NewUnauthorizedError("Invalid credentials")
+	}
+	s.upgradePasswordHash(ctx, user, creds.Password)
+	if user.DeactivatedAt != nil {
+		s.log.Info().Int("user_id", user.ID).Msg("Login to a deactivated account.")
+		return nil, nil, &ForbiddenError{Message: "Account is deactivated"}
 	}
 
-	return user, nil
+	if user.TwoFactorEnabled {
+		challenge, err := newTwoFactorChallenge(user)
+		if err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to issue two-factor challenge")
+			return nil, nil, err
+		}
+		s.log.Info().Int("user_id", user.ID).Msg("Two-factor challenge issued")
+		return user, challenge, nil
+	}
+
+	if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear failed login attempts")
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to record last login time")
+	}
+
+	return user, nil, nil
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id int) (*models.User, error) {
@@ -96,10 +179,10 @@ func (s *userService) GetUserByEmail(ctx context.Context, email string) (*models
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			s.log.Info().Str("email", email).Msg("User not found by email.")
+			s.log.Info().Msg("User not found by email.")
 			return nil, err
 		}
-		s.log.Error().Err(err).Str("email", email).Msg("Failed to fetch user by email.")
+		s.log.Error().Err(err).Msg("Failed to fetch user by email.")
 		return nil, err
 	}
 	return user, nil
@@ -135,8 +218,12 @@ func (s *userService) UpdateUser(ctx context.Context, userID int, newUser models
 	if newUser.Username != nil {
 		user.Username = *newUser.Username
 	}
-	if newUser.Email != nil {
+	emailChanged := false
+	if newUser.Email != nil && *newUser.Email != user.Email {
 		user.Email = *newUser.Email
+		user.IsVerified = false
+		user.VerificationSentAt = nil
+		emailChanged = true
 	}
 	if newUser.Timezone != nil {
 		user.Timezone = *newUser.Timezone
@@ -144,6 +231,18 @@ func (s *userService) UpdateUser(ctx context.Context, userID int, newUser models
 	if newUser.Locale != nil {
 		user.Locale = *newUser.Locale
 	}
+	if newUser.Title != nil {
+		user.Title = strings.TrimSpace(*newUser.Title)
+	}
+	if newUser.Pronouns != nil {
+		user.Pronouns = strings.TrimSpace(*newUser.Pronouns)
+	}
+	if newUser.Phone != nil {
+		user.Phone = strings.TrimSpace(*newUser.Phone)
+	}
+	if err := validateProfile(user); err != nil {
+		return nil, err
+	}
 
 	err = s.userRepo.UpdateUser(ctx, user)
 	if err != nil {
@@ -151,18 +250,429 @@ func (s *userService) UpdateUser(ctx context.Context, userID int, newUser models
 		return nil, err
 	}
 
+	if emailChanged {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to send verification email after email change")
+		}
+	}
+
 	return user, nil
 }
 
-func (s *userService) DeleteUser(ctx context.Context, id int) error {
-	err := s.userRepo.DeleteUser(ctx, id)
+func (s *userService) ResendVerificationEmail(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.log.Info().Int("user_id", userID).Msg("User not found for verification resend.")
+			return NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to fetch user for verification resend.")
+		return err
+	}
+
+	if user.IsVerified {
+		return &ConflictError{Message: "Email address is already verified"}
+	}
+	if user.VerificationSentAt != nil {
+		if wait := verificationResendInterval - time.Since(*user.VerificationSentAt); wait > 0 {
+			s.log.Info().Int("user_id", userID).Dur("retry_after", wait).Msg("Verification email resend throttled.")
+			return NewTooManyRequestsError("Verification email was sent recently", wait)
+		}
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	claims, err := utils.ParseActionToken(token, utils.PurposeEmailVerification)
+	if err != nil {
+		s.log.Info().Err(err).Msg("Invalid email verification token.")
+		return nil, NewValidationError("Invalid or expired verification token")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.log.Info().Int("user_id", claims.UserID).Msg("User not found for email verification.")
+			return nil, NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", claims.UserID).Msg("Failed to fetch user for email verification.")
+		return nil, err
+	}
+
+	// The token is bound to the address it was sent to, so changing the email invalidates it.
+	if user.Email != claims.Email {
+		s.log.Info().Int("user_id", user.ID).Msg("Verification token was issued for a previous email address.")
+		return nil, NewValidationError("Invalid or expired verification token")
+	}
+	if user.IsVerified {
+		return user, nil
+	}
+
+	user.IsVerified = true
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to mark user as verified.")
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Email address verified.")
+	return user, nil
+}
+
+func (s *userService) sendVerificationEmail(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateActionToken(utils.PurposeEmailVerification, user.ID, user.Email, verificationTokenTTL)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to generate verification token.")
+		return err
+	}
+
+	link := utils.AppURL("/verify-email", url.Values{"token": {token}})
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Axis email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in 24 hours.\n",
+			user.Name, link),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send verification email.")
+		return err
+	}
+
+	now := time.Now()
+	user.VerificationSentAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record verification email time.")
+		return err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Verification email sent.")
+	return nil
+}
+
+// ForgotPassword emails a single-use reset link. Unknown addresses are silently ignored so the
+// endpoint cannot be used to discover which emails have accounts.
+func (s *userService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.log.Info().Msg("Password reset requested for unknown email.")
+			return nil
+		}
+		s.log.Error().Err(err).Msg("Failed to fetch user for password reset.")
+		return err
+	}
+
+	token, err := utils.GenerateOpaqueToken(32)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to generate password reset token.")
+		return err
+	}
+
+	// Only the newest link stays valid.
+	if err := s.passwordResetRepo.InvalidateTokensForUser(ctx, user.ID); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to invalidate previous password reset tokens.")
+		return err
+	}
+	err = s.passwordResetRepo.CreateToken(ctx, &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to store password reset token.")
+		return err
+	}
+
+	link := utils.AppURL("/reset-password", url.Values{"token": {token}})
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Axis password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your Axis account. If it was you, open the link below:\n\n%s\n\nThe link expires in 1 hour and can only be used once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, link),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send password reset email.")
+		return err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Password reset email sent.")
+	return nil
+}
+
+func (s *userService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	resetToken, err := s.passwordResetRepo.GetTokenByHash(ctx, utils.HashToken(token))
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to fetch password reset token.")
+		return err
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || time.Now().After(resetToken.ExpiresAt) {
+		s.log.Info().Msg("Invalid, used or expired password reset token.")
+		return NewValidationError("Invalid or expired reset token")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", resetToken.UserID).Msg("Failed to fetch user for password reset.")
+		return err
+	}
+	// Check the policy first so a rejected password does not use up the token.
+	if err := s.passwordPolicy.Validate(newPassword, user.Name, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	consumed, err := s.passwordResetRepo.ConsumeToken(ctx, resetToken.ID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", resetToken.UserID).Msg("Failed to consume password reset token.")
+		return err
+	}
+	if !consumed {
+		s.log.Info().Int("user_id", resetToken.UserID).Msg("Password reset token was used concurrently.")
+		return NewValidationError("Invalid or expired reset token")
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in.
+	if err := s.sessionRepo.RevokeSessionsForUser(ctx, user.ID, 0); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to revoke sessions after password reset.")
+		return err
+	}
+
+	// Proving control of the mailbox lifts a lockout caused by someone else guessing.
+	if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear failed login attempts after password reset.")
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Password reset successfully.")
+	return nil
+}
+
+func (s *userService) ChangePassword(ctx context.Context, userID, currentSessionID int, form models.ChangePasswordModel) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			s.log.Info().Int("user_id", id).Msg("User not found for deletion.")
-			return err
+			s.log.Info().Int("user_id", userID).Msg("User not found for password change.")
+			return NewNotFoundError("User not found")
 		}
-		s.log.Error().Err(err).Int("user_id", id).Msg("Failed to delete user.")
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to fetch user for password change.")
+		return err
+	}
+
+	if !utils.CheckPassword(user.Password, form.CurrentPassword) {
+		s.log.Warn().Int("user_id", userID).Msg("Password change with invalid current password.")
+		return NewUnauthorizedError("Current password is incorrect")
+	}
+	if err := s.passwordPolicy.Validate(form.NewPassword, user.Name, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, form.NewPassword); err != nil {
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeSessionsForUser(ctx, userID, currentSessionID); err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to revoke other sessions after password change.")
+		return err
+	}
+
+	s.log.Info().Int("user_id", userID).Msg("Password changed successfully.")
+	return nil
+}
+
+func (s *userService) setPassword(ctx context.Context, user *models.User, password string) error {
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Can't hash the password")
+		return err
+	}
+
+	user.Password = hashedPassword
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to store new password.")
 		return err
 	}
 	return nil
 }
+
+func (s *userService) PasswordPolicy() *PasswordPolicy {
+	return s.passwordPolicy
+}
+
+// upgradePasswordHash rehashes a just verified password when its stored hash uses an outdated
+// algorithm or parameters. The login goes ahead even if storing the new hash fails.
+func (s *userService) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	if !utils.PasswordNeedsRehash(user.Password) {
+		return
+	}
+	hashedPassword, err := hashPassword(password)
+	if err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to rehash password")
+		return
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to store rehashed password")
+		return
+	}
+	user.Password = hashedPassword
+	s.log.Info().Int("user_id", user.ID).Msg("Password hash upgraded.")
+}
+
+func hashPassword(password string) (string, error) {
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return "", NewValidationError("Password is too long")
+		}
+		return "", err
+	}
+	return hashedPassword, nil
+}
+
+// SendLoginLink emails a link that signs the user in without a password. Like ForgotPassword it
+// does not reveal whether the email belongs to an account. Requests beyond the per-address rate
+// limit send nothing and return a TooManyRequestsError, which public callers must not pass on for
+// the same reason.
+func (s *userService) SendLoginLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			s.log.Info().Msg("Login link requested for unknown email.")
+			return nil
+		}
+		s.log.Error().Err(err).Msg("Failed to fetch user for login link.")
+		return err
+	}
+
+	now := time.Now()
+	recent, err := s.loginLinkRepo.CountLinksSince(ctx, user.ID, now.Add(-loginLinkMinInterval))
+	if err != nil {
+		return err
+	}
+	hourly, err := s.loginLinkRepo.CountLinksSince(ctx, user.ID, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent > 0 || hourly >= loginLinksPerHour {
+		s.log.Warn().Int("user_id", user.ID).Int("links_last_hour", hourly).Msg("Login link request throttled.")
+		wait := loginLinkMinInterval
+		if hourly >= loginLinksPerHour {
+			wait = time.Hour
+		}
+		return NewTooManyRequestsError("A sign-in link was sent recently, try again later", wait)
+	}
+
+	token, tokenID, err := utils.GenerateSingleUseActionToken(utils.PurposeLoginLink, user.ID, user.Email, loginLinkTTL)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to generate login link token.")
+		return err
+	}
+	err = s.loginLinkRepo.CreateLink(ctx, &models.LoginLink{
+		TokenID:   tokenID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(loginLinkTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return err
+	}
+
+	link := utils.AppURL("/login/link", url.Values{"token": {token}})
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your Axis sign-in link",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below to sign in to Axis:\n\n%s\n\nThe link expires in 15 minutes and can only be used once. If you did not ask for it, you can ignore this email.\n",
+			user.Name, link),
+	})
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to send login link email.")
+		return err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("Login link sent.")
+	return nil
+}
+
+// LoginWithLink redeems a login link. The link stands in for the password only, so accounts with
+// 2FA still get a challenge. Opening it also proves the user controls the email address.
+func (s *userService) LoginWithLink(ctx context.Context, token string) (*models.User, *models.TwoFactorChallenge, error) {
+	claims, err := utils.ParseActionToken(token, utils.PurposeLoginLink)
+	if err != nil || claims.ID == "" {
+		s.log.Info().Err(err).Msg("Invalid login link token.")
+		return nil, nil, NewUnauthorizedError("Invalid or expired login link")
+	}
+
+	consumed, err := s.loginLinkRepo.ConsumeLink(ctx, claims.ID, claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !consumed {
+		s.log.Info().Int("user_id", claims.UserID).Msg("Login link was already used or has expired.")
+		return nil, nil, NewUnauthorizedError("Invalid or expired login link")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, NewUnauthorizedError("Invalid or expired login link")
+		}
+		s.log.Error().Err(err).Int("user_id", claims.UserID).Msg("Failed to fetch user for login link.")
+		return nil, nil, err
+	}
+	if user.Email != claims.Email {
+		s.log.Info().Int("user_id", user.ID).Msg("Login link was sent to a previous email address.")
+		return nil, nil, NewUnauthorizedError("Invalid or expired login link")
+	}
+	if user.DeactivatedAt != nil {
+		s.log.Info().Int("user_id", user.ID).Msg("Login link for a deactivated account.")
+		return nil, nil, &ForbiddenError{Message: "Account is deactivated"}
+	}
+
+	if err := s.loginAttemptService.RecordSuccess(ctx, user.Email); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to clear failed login attempts")
+	}
+
+	if !user.IsVerified {
+		user.IsVerified = true
+		s.log.Info().Int("user_id", user.ID).Msg("Email verified by login link.")
+	}
+	if user.TwoFactorEnabled {
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			return nil, nil, err
+		}
+		challenge, err := newTwoFactorChallenge(user)
+		if err != nil {
+			s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to issue two-factor challenge")
+			return nil, nil, err
+		}
+		return user, challenge, nil
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Error().Err(err).Int("user_id", user.ID).Msg("Failed to record login link sign-in.")
+		return nil, nil, err
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("User signed in with login link.")
+	return user, nil, nil
+}
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{2,30}$`)
+
+// validateProfile checks the free-form profile fields a user sets on themselves.
+func validateProfile(user *models.User) error {
+	if utf8.RuneCountInString(user.Title) > 100 {
+		return NewValidationError("Title must be at most 100 characters long")
+	}
+	if utf8.RuneCountInString(user.Pronouns) > 40 {
+		return NewValidationError("Pronouns must be at most 40 characters long")
+	}
+	if user.Phone != "" && !phonePattern.MatchString(user.Phone) {
+		return NewValidationError("Phone must be a phone number, such as +1 555 0100")
+	}
+	return nil
+}
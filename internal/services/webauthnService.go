@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"axis/internal/models"
+	"axis/internal/repositories"
+	"axis/internal/webauthn"
+)
+
+const (
+	webAuthnPurposeRegistration = "registration"
+	webAuthnPurposeLogin        = "login"
+)
+
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID int) (*webauthn.CreationOptions, error)
+	FinishRegistration(ctx context.Context, userID int, form models.WebAuthnRegistrationModel) (*models.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, email string) (*webauthn.RequestOptions, error)
+	FinishLogin(ctx context.Context, credential webauthn.AssertionCredential) (*models.User, error)
+	ListCredentials(ctx context.Context, userID int) ([]models.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, credentialID int) error
+}
+
+type webAuthnService struct {
+	webAuthnRepo repositories.WebAuthnRepo
+	userRepo     repositories.UserRepo
+	rp           *webauthn.RelyingParty
+	log          zerolog.Logger
+}
+
+func NewWebAuthnService(webAuthnRepo repositories.WebAuthnRepo, userRepo repositories.UserRepo, rp *webauthn.RelyingParty, logger zerolog.Logger) WebAuthnService {
+	return &webAuthnService{
+		webAuthnRepo: webAuthnRepo,
+		userRepo:     userRepo,
+		rp:           rp,
+		log:          logger,
+	}
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID int) (*webauthn.CreationOptions, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to fetch user for WebAuthn registration.")
+		return nil, err
+	}
+
+	existing, err := s.webAuthnRepo.GetCredentialsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.issueChallenge(ctx, webAuthnPurposeRegistration, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	options := s.rp.CreationOptions(challenge, userHandle(userID), user.Email, user.Name, credentialDescriptors(existing))
+	s.log.Info().Int("user_id", userID).Msg("WebAuthn registration started.")
+	return &options, nil
+}
+
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID int, form models.WebAuthnRegistrationModel) (*models.WebAuthnCredential, error) {
+	challenge, err := s.consumeChallenge(ctx, form.Credential.Response.ClientDataJSON, webAuthnPurposeRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || challenge.UserID != userID {
+		s.log.Info().Int("user_id", userID).Msg("Unknown or expired WebAuthn registration challenge.")
+		return nil, NewValidationError("Unknown or expired registration challenge")
+	}
+
+	verified, err := s.rp.VerifyRegistration(form.Credential, challenge.Challenge)
+	if err != nil {
+		s.log.Warn().Err(err).Int("user_id", userID).Msg("WebAuthn registration failed verification.")
+		return nil, NewValidationError("Registration could not be verified")
+	}
+
+	credentialID := webauthn.EncodeID(verified.ID)
+	existing, err := s.webAuthnRepo.GetCredentialByCredentialID(ctx, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, &ConflictError{Message: "This authenticator is already registered"}
+	}
+
+	name := form.Name
+	if name == "" {
+		name = "Passkey"
+	}
+	credential := &models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: credentialID,
+		PublicKey:    verified.PublicKey,
+		SignCount:    int64(verified.SignCount),
+		AAGUID:       verified.AAGUID,
+		Name:         name,
+		Transports:   form.Credential.Response.Transports,
+	}
+	if err := s.webAuthnRepo.CreateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	s.log.Info().Int("user_id", userID).Int("credential_id", credential.ID).Msg("WebAuthn credential registered.")
+	return credential, nil
+}
+
+// BeginLogin starts an authentication ceremony. With an email the browser is told which
+// credentials to offer; without one it falls back to discoverable credentials (passkeys). Unknown
+// emails get the same response as accounts without credentials.
+func (s *webAuthnService) BeginLogin(ctx context.Context, email string) (*webauthn.RequestOptions, error) {
+	var allow []webauthn.CredentialDescriptor
+	userID := 0
+	if email != "" {
+		user, err := s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil && err != sql.ErrNoRows {
+			s.log.Error().Err(err).Msg("Failed to fetch user for WebAuthn login.")
+			return nil, err
+		}
+		if user != nil {
+			userID = user.ID
+			credentials, err := s.webAuthnRepo.GetCredentialsForUser(ctx, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			allow = credentialDescriptors(credentials)
+		}
+	}
+
+	challenge, err := s.issueChallenge(ctx, webAuthnPurposeLogin, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	options := s.rp.RequestOptions(challenge, allow)
+	return &options, nil
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, assertion webauthn.AssertionCredential) (*models.User, error) {
+	challenge, err := s.consumeChallenge(ctx, assertion.Response.ClientDataJSON, webAuthnPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		s.log.Info().Msg("Unknown or expired WebAuthn login challenge.")
+		return nil, NewUnauthorizedError("Unknown or expired login challenge")
+	}
+
+	credential, err := s.webAuthnRepo.GetCredentialByCredentialID(ctx, assertion.ID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		s.log.Info().Msg("WebAuthn login with unknown credential.")
+		return nil, NewUnauthorizedError("Unknown credential")
+	}
+	if challenge.UserID != 0 && challenge.UserID != credential.UserID {
+		s.log.Warn().Int("user_id", credential.UserID).Msg("WebAuthn credential does not belong to the requested account.")
+		return nil, NewUnauthorizedError("Unknown credential")
+	}
+	if handle := assertion.Response.UserHandle; handle != "" && handle != webauthn.EncodeID(userHandle(credential.UserID)) {
+		s.log.Warn().Int("user_id", credential.UserID).Msg("WebAuthn user handle mismatch.")
+		return nil, NewUnauthorizedError("Unknown credential")
+	}
+
+	signCount, err := s.rp.VerifyAssertion(assertion, challenge.Challenge, credential.PublicKey, uint32(credential.SignCount))
+	if err != nil {
+		s.log.Warn().Err(err).Int("user_id", credential.UserID).Msg("WebAuthn assertion failed verification.")
+		return nil, NewUnauthorizedError("Authentication could not be verified")
+	}
+
+	now := time.Now()
+	credential.SignCount = int64(signCount)
+	credential.LastUsedAt = &now
+	if err := s.webAuthnRepo.UpdateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, credential.UserID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", credential.UserID).Msg("Failed to fetch user for WebAuthn login.")
+		return nil, err
+	}
+	user.LastLoginAt = &now
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		s.log.Warn().Err(err).Int("user_id", user.ID).Msg("Failed to record last login time")
+	}
+
+	s.log.Info().Int("user_id", user.ID).Msg("User logged in with WebAuthn.")
+	return user, nil
+}
+
+func (s *webAuthnService) ListCredentials(ctx context.Context, userID int) ([]models.WebAuthnCredential, error) {
+	return s.webAuthnRepo.GetCredentialsForUser(ctx, userID)
+}
+
+func (s *webAuthnService) DeleteCredential(ctx context.Context, userID, credentialID int) error {
+	deleted, err := s.webAuthnRepo.DeleteCredential(ctx, userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return NewNotFoundError("Credential not found")
+	}
+	s.log.Info().Int("user_id", userID).Int("credential_id", credentialID).Msg("WebAuthn credential deleted.")
+	return nil
+}
+
+func (s *webAuthnService) issueChallenge(ctx context.Context, purpose string, userID int) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		s.log.Error().Err(err).Msg("Failed to generate WebAuthn challenge.")
+		return "", err
+	}
+	err = s.webAuthnRepo.CreateChallenge(ctx, &models.WebAuthnChallenge{
+		Challenge: challenge,
+		Purpose:   purpose,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(webauthn.Timeout),
+	})
+	if err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// consumeChallenge finds the challenge the browser answered and removes it. It returns nil if the
+// challenge is unknown or expired.
+func (s *webAuthnService) consumeChallenge(ctx context.Context, clientDataJSON, purpose string) (*models.WebAuthnChallenge, error) {
+	value, err := webauthn.ClientDataChallenge(clientDataJSON)
+	if err != nil || value == "" {
+		return nil, nil
+	}
+	challenge, err := s.webAuthnRepo.ConsumeChallenge(ctx, value, purpose)
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, nil
+	}
+	return challenge, nil
+}
+
+func userHandle(userID int) []byte {
+	return []byte(strconv.Itoa(userID))
+}
+
+func credentialDescriptors(credentials []models.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, c := range credentials {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         c.CredentialID,
+			Transports: c.Transports,
+		})
+	}
+	return descriptors
+}
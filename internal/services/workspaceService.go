@@ -3,49 +3,77 @@ package services
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"axis/internal/models"
 	"axis/internal/repositories"
 	"github.com/rs/zerolog"
 )
 
+const (
+	defaultDirectoryLimit = 20
+	maxDirectoryLimit     = 100
+)
+
 type WorkspaceService interface {
 	CreateWorkspace(ctx context.Context, workspace *models.Workspace) (*models.Workspace, error)
 	GetWorkspaceByID(ctx context.Context, id int) (*models.Workspace, error)
 	GetWorkspaceByIDAuthorized(ctx context.Context, userID, workspaceID int) (*models.Workspace, error)
-	UpdateWorkspace(ctx context.Context, userID int, workspace *models.Workspace) (*models.Workspace, error)
+	UpdateWorkspace(ctx context.Context, userID, workspaceID int, update models.UpdateWorkspace) (*models.Workspace, error)
 	DeleteWorkspace(ctx context.Context, userID int, id int) error
 	GetWorkspacesForUser(ctx context.Context, userID int) ([]*models.Workspace, error)
+	// GetWorkspaceBySlug finds a workspace by its slug. Only its members and, for discoverable
+	// workspaces, everyone else can find it.
+	GetWorkspaceBySlug(ctx context.Context, userID int, slug string) (*models.WorkspaceSummary, error)
+	// SearchDirectory lists the discoverable workspaces whose name or slug contains query.
+	SearchDirectory(ctx context.Context, query string, limit, offset int) ([]models.WorkspaceSummary, error)
+	// TransferOwnership hands the workspace to another of its members. Only the owner can, and
+	// they stay on as an admin. The transfer is recorded in the audit log.
+	TransferOwnership(ctx context.Context, userID, workspaceID int, form models.TransferOwnershipModel) (*models.Workspace, error)
 }
 
 type workspaceService struct {
 	workspaceRepo       repositories.WorkspaceRepo
 	workspaceMemberRepo repositories.WorkspaceMemberRepo
+	userRepo            repositories.UserRepo
+	authorizer          Authorizer
+	auditService        AuditService
 	log                 zerolog.Logger
 }
 
-func NewWorkspaceService(wr repositories.WorkspaceRepo, wmr repositories.WorkspaceMemberRepo, logger zerolog.Logger) WorkspaceService {
+func NewWorkspaceService(wr repositories.WorkspaceRepo, wmr repositories.WorkspaceMemberRepo, ur repositories.UserRepo, authorizer Authorizer, as AuditService, logger zerolog.Logger) WorkspaceService {
 	return &workspaceService{
 		workspaceRepo:       wr,
 		workspaceMemberRepo: wmr,
+		userRepo:            ur,
+		authorizer:          authorizer,
+		auditService:        as,
 		log:                 logger,
 	}
 }
 
 func (s *workspaceService) CreateWorkspace(ctx context.Context, workspace *models.Workspace) (*models.Workspace, error) {
-	err := s.workspaceRepo.CreateWorkspace(ctx, workspace)
+	// Every workspace starts on the free plan; only operators move it to another.
+	workspace.Plan = models.PlanFree
+	slug, err := s.newSlug(ctx, workspace)
+	if err != nil {
+		return nil, err
+	}
+	workspace.Slug = slug
+	err = s.workspaceRepo.CreateWorkspace(ctx, workspace)
 	if err != nil {
 		s.log.Error().Err(err).Str("workspace_name", workspace.Name).Msg("Failed to create workspace")
 		return nil, err
 	}
 	s.log.Info().Str("workspace_name", workspace.Name).Int("workspace_id", workspace.ID).Msg("Workspace created successfully")
 
-	err = s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspace.ID, workspace.CreatorID, models.Admin)
+	err = s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspace.ID, workspace.CreatorID, models.Owner)
 	if err != nil {
 		s.log.Error().Err(err).Int("workspace_id", workspace.ID).Int("user_id", workspace.CreatorID).Msg("Failed to add creator as member to workspace")
 		return nil, err
 	}
-	s.log.Info().Int("workspace_id", workspace.ID).Int("user_id", workspace.CreatorID).Msg("Creator added as admin to workspace")
+	s.log.Info().Int("workspace_id", workspace.ID).Int("user_id", workspace.CreatorID).Msg("Creator added as owner to workspace")
 
 	return workspace, nil
 }
@@ -86,39 +114,26 @@ func (s *workspaceService) GetWorkspaceByIDAuthorized(ctx context.Context, userI
 	return workspace, nil
 }
 
-func (s *workspaceService) UpdateWorkspace(ctx context.Context, userID int, workspace *models.Workspace) (*models.Workspace, error) {
-	existingWorkspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspace.ID)
+func (s *workspaceService) UpdateWorkspace(ctx context.Context, userID, workspaceID int, update models.UpdateWorkspace) (*models.Workspace, error) {
+	existingWorkspace, err := s.authorizer.Authorize(ctx, userID, workspaceID, models.PermWorkspaceUpdate)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			s.log.Info().Int("workspace_id", workspace.ID).Msg("Workspace not found for update")
-			return nil, nil
-		}
-		s.log.Error().Err(err).Int("workspace_id", workspace.ID).Msg("Failed to get workspace for update")
 		return nil, err
 	}
 
-	if existingWorkspace.CreatorID != int(userID) {
-		isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, existingWorkspace.ID, int(userID))
-		if err != nil {
-			s.log.Error().Err(err).Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("Failed to check workspace membership for update")
-			return nil, err
-		}
-		if !isMember {
-			s.log.Warn().Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("User not authorized to update this workspace")
-			return nil, &ForbiddenError{Message: "User not authorized to update this workspace"}
-		}
-		member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, existingWorkspace.ID, int(userID))
-		if err != nil && err != sql.ErrNoRows {
-			s.log.Error().Err(err).Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("Failed to get workspace member role for update")
-			return nil, err
-		}
-		if member == nil || member.Role != models.Admin {
-			s.log.Warn().Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("User does not have admin role to update this workspace")
-			return nil, &ForbiddenError{Message: "User not authorized to update this workspace"}
+	if update.Slug != nil {
+		if slug := normalizeSlug(*update.Slug); slug != existingWorkspace.Slug {
+			if err := validateSlug(slug); err != nil {
+				return nil, err
+			}
+			if err := s.checkSlugFree(ctx, slug); err != nil {
+				return nil, err
+			}
+			existingWorkspace.Slug = slug
 		}
 	}
-
-	existingWorkspace.Name = workspace.Name 
+	if err := applyWorkspaceUpdate(existingWorkspace, update); err != nil {
+		return nil, err
+	}
 
 	err = s.workspaceRepo.UpdateWorkspace(ctx, existingWorkspace)
 	if err != nil {
@@ -129,39 +144,37 @@ func (s *workspaceService) UpdateWorkspace(ctx context.Context, userID int, work
 	return existingWorkspace, nil
 }
 
-func (s *workspaceService) DeleteWorkspace(ctx context.Context, userID int, id int) error {
-	existingWorkspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, id)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			s.log.Info().Int("workspace_id", id).Msg("Workspace not found for deletion")
-			return nil
+// applyWorkspaceUpdate copies the settings given in update onto workspace, leaving the others as
+// they are. The slug is left to the caller, which has to check that it is free.
+func applyWorkspaceUpdate(workspace *models.Workspace, update models.UpdateWorkspace) error {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" {
+			return NewValidationError("name must not be empty")
 		}
-		s.log.Error().Err(err).Int("workspace_id", id).Msg("Failed to get workspace for deletion")
-		return err
+		workspace.Name = name
+	}
+	if update.RequireVerifiedEmail != nil {
+		workspace.RequireVerifiedEmail = *update.RequireVerifiedEmail
+	}
+	if update.IsOpen != nil {
+		workspace.IsOpen = *update.IsOpen
+	}
+	if update.AllowJoinRequests != nil {
+		workspace.AllowJoinRequests = *update.AllowJoinRequests
 	}
+	if update.IsDiscoverable != nil {
+		workspace.IsDiscoverable = *update.IsDiscoverable
+	}
+	return nil
+}
 
-	if existingWorkspace.CreatorID != int(userID) {
-		isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, existingWorkspace.ID, int(userID))
-		if err != nil {
-			s.log.Error().Err(err).Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("Failed to check workspace membership for deletion")
-			return err
-		}
-		if !isMember {
-			s.log.Warn().Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("User not authorized to delete this workspace")
-			return &ForbiddenError{Message: "User not authorized to delete this workspace"}
-		}
-		member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, existingWorkspace.ID, int(userID))
-		if err != nil && err != sql.ErrNoRows {
-			s.log.Error().Err(err).Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("Failed to get workspace member role for deletion")
-			return err
-		}
-		if member == nil || member.Role != models.Admin {
-			s.log.Warn().Int("workspace_id", existingWorkspace.ID).Int("user_id", int(userID)).Msg("User does not have admin role to delete this workspace")
-			return &ForbiddenError{Message: "User not authorized to delete this workspace"}
-		}
+func (s *workspaceService) DeleteWorkspace(ctx context.Context, userID int, id int) error {
+	if _, err := s.authorizer.Authorize(ctx, userID, id, models.PermWorkspaceDelete); err != nil {
+		return err
 	}
 
-	err = s.workspaceRepo.DeleteWorkspace(ctx, id)
+	err := s.workspaceRepo.DeleteWorkspace(ctx, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			s.log.Info().Int("workspace_id", id).Msg("Workspace not found for deletion")
@@ -195,3 +208,147 @@ func (s *workspaceService) GetWorkspacesForUser(ctx context.Context, userID int)
 	s.log.Info().Int("user_id", int(userID)).Int("workspace_count", len(workspaces)).Msg("Retrieved workspaces for user successfully")
 	return workspaces, nil
 }
+
+func (s *workspaceService) TransferOwnership(ctx context.Context, userID, workspaceID int, form models.TransferOwnershipModel) (*models.Workspace, error) {
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	owner, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if owner == nil || !isOwner(workspace, owner) {
+		s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Ownership transfer by non-owner denied")
+		return nil, &ForbiddenError{Message: "Only the workspace's owner can transfer it"}
+	}
+	if strings.TrimSpace(form.ConfirmName) != workspace.Name {
+		return nil, NewValidationError("Confirm the transfer by entering the workspace's name")
+	}
+	if form.UserID == userID {
+		return nil, NewValidationError("You already own this workspace")
+	}
+	newOwner, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, form.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if newOwner == nil {
+		return nil, NewValidationError("The new owner must be a member of this workspace")
+	}
+	// An owner nobody can sign in as would leave the workspace without one.
+	newOwnerUser, err := s.userRepo.GetUserByID(ctx, form.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !liveAccount(newOwnerUser) {
+		return nil, NewValidationError("The new owner's account is deactivated or erased")
+	}
+
+	if err := s.workspaceRepo.TransferOwnership(ctx, workspaceID, userID, form.UserID); err != nil {
+		return nil, err
+	}
+	workspace.CreatorID = form.UserID
+	s.auditService.Record(ctx, &models.WorkspaceAuditEntry{
+		WorkspaceID:  workspaceID,
+		ActorID:      userID,
+		Action:       models.AuditOwnershipTransferred,
+		TargetUserID: &form.UserID,
+		Details:      map[string]any{"previous_owner_id": userID},
+	})
+	s.log.Info().Int("workspace_id", workspaceID).Int("from_user_id", userID).Int("to_user_id", form.UserID).Msg("Workspace ownership transferred")
+	return workspace, nil
+}
+
+func (s *workspaceService) GetWorkspaceBySlug(ctx context.Context, userID int, slug string) (*models.WorkspaceSummary, error) {
+	workspace, err := s.workspaceRepo.GetWorkspaceBySlug(ctx, normalizeSlug(slug))
+	if err != nil {
+		return nil, err
+	}
+	if workspace == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	// Workspaces that are not listed are not revealed to outsiders either.
+	if !workspace.IsDiscoverable {
+		isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, workspace.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isMember {
+			return nil, NewNotFoundError("Workspace not found")
+		}
+	}
+
+	summary, err := s.workspaceRepo.GetWorkspaceSummary(ctx, workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if summary == nil {
+		return nil, NewNotFoundError("Workspace not found")
+	}
+	return summary, nil
+}
+
+func (s *workspaceService) SearchDirectory(ctx context.Context, query string, limit, offset int) ([]models.WorkspaceSummary, error) {
+	if limit <= 0 {
+		limit = defaultDirectoryLimit
+	}
+	if limit > maxDirectoryLimit {
+		limit = maxDirectoryLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	summaries, err := s.workspaceRepo.SearchDirectory(ctx, strings.TrimSpace(query), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if summaries == nil {
+		summaries = []models.WorkspaceSummary{}
+	}
+	return summaries, nil
+}
+
+// newSlug returns the slug a new workspace gets: the one chosen for it, or a free one made from its
+// name.
+func (s *workspaceService) newSlug(ctx context.Context, workspace *models.Workspace) (string, error) {
+	if chosen := normalizeSlug(workspace.Slug); chosen != "" {
+		if err := validateSlug(chosen); err != nil {
+			return "", err
+		}
+		if err := s.checkSlugFree(ctx, chosen); err != nil {
+			return "", err
+		}
+		return chosen, nil
+	}
+
+	base := slugFromName(workspace.Name)
+	for n := 1; n <= maxSlugAttempts; n++ {
+		slug := base
+		if n > 1 {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		taken, err := s.workspaceRepo.GetWorkspaceBySlug(ctx, slug)
+		if err != nil {
+			return "", err
+		}
+		if taken == nil {
+			return slug, nil
+		}
+	}
+	return "", &ConflictError{Message: "No slug could be made from the name; choose one"}
+}
+
+func (s *workspaceService) checkSlugFree(ctx context.Context, slug string) error {
+	taken, err := s.workspaceRepo.GetWorkspaceBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if taken != nil {
+		return &ConflictError{Message: "This slug is taken"}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"axis/internal/models"
+)
+
+func TestApplyWorkspaceUpdate(t *testing.T) {
+	workspace := &models.Workspace{
+		Name:                 "Acme",
+		RequireVerifiedEmail: true,
+		IsOpen:               true,
+		AllowJoinRequests:    true,
+		IsDiscoverable:       true,
+	}
+
+	// A request that only closes the workspace keeps its other settings.
+	var update models.UpdateWorkspace
+	if err := json.Unmarshal([]byte(`{"is_open": false}`), &update); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyWorkspaceUpdate(workspace, update); err != nil {
+		t.Fatalf("applyWorkspaceUpdate = %v, want nil", err)
+	}
+	if workspace.IsOpen {
+		t.Error("IsOpen = true, want false")
+	}
+	if workspace.Name != "Acme" || !workspace.RequireVerifiedEmail || !workspace.AllowJoinRequests || !workspace.IsDiscoverable {
+		t.Errorf("settings left out of the update changed: %+v", workspace)
+	}
+
+	empty := " "
+	if err := applyWorkspaceUpdate(workspace, models.UpdateWorkspace{Name: &empty}); err == nil {
+		t.Error("applyWorkspaceUpdate with a blank name = nil, want ValidationError")
+	} else if _, ok := err.(*ValidationError); !ok {
+		t.Errorf("applyWorkspaceUpdate with a blank name = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"strings"
+)
+
+const (
+	minSlugLength = 3
+	maxSlugLength = 40
+	// maxSlugAttempts bounds the numbered variants tried when the slug made from a name is taken.
+	maxSlugAttempts = 100
+)
+
+// reservedSlugs cannot be chosen: they name the app's own pages, or could pass for the service
+// itself or its staff.
+var reservedSlugs = map[string]bool{
+	"about":       true,
+	"account":     true,
+	"admin":       true,
+	"api":         true,
+	"app":         true,
+	"assets":      true,
+	"axis":        true,
+	"directory":   true,
+	"help":        true,
+	"invitations": true,
+	"join":        true,
+	"login":       true,
+	"logout":      true,
+	"new":         true,
+	"register":    true,
+	"security":    true,
+	"settings":    true,
+	"signup":      true,
+	"sso":         true,
+	"static":      true,
+	"status":      true,
+	"support":     true,
+	"system":      true,
+	"workspaces":  true,
+	"www":         true,
+}
+
+// normalizeSlug puts a slug given by a user in the form slugs are stored in.
+func normalizeSlug(slug string) string {
+	return strings.ToLower(strings.TrimSpace(slug))
+}
+
+// validateSlug checks a normalized slug. Slugs are 3 to 40 lowercase letters, digits and single
+// hyphens, neither starting nor ending with a hyphen. They need a letter, so that they cannot be
+// mistaken for a workspace ID, and must not be reserved.
+func validateSlug(slug string) error {
+	if len(slug) < minSlugLength || len(slug) > maxSlugLength {
+		return NewValidationError("slug must be 3 to 40 characters")
+	}
+	hasLetter := false
+	for i, r := range slug {
+		switch {
+		case r >= 'a' && r <= 'z':
+			hasLetter = true
+		case r >= '0' && r <= '9':
+		case r == '-':
+			if i == 0 || i == len(slug)-1 || slug[i-1] == '-' {
+				return NewValidationError("slug must not start or end with a hyphen or have two in a row")
+			}
+		default:
+			return NewValidationError("slug may only contain lowercase letters, digits and hyphens")
+		}
+	}
+	if !hasLetter {
+		return NewValidationError("slug must contain a letter")
+	}
+	if reservedSlugs[slug] {
+		return NewValidationError("This slug is reserved")
+	}
+	return nil
+}
+
+// slugFromName makes a valid slug out of a workspace's name. It is short enough for a numbered
+// variant to be made of it when it is taken.
+func slugFromName(name string) string {
+	var b strings.Builder
+	gap := false
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			if gap && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			gap = false
+			b.WriteRune(r)
+		} else {
+			gap = true
+		}
+	}
+
+	slug := truncateSlug(b.String())
+	if validateSlug(slug) != nil {
+		slug = truncateSlug(strings.TrimSuffix("workspace-"+slug, "-"))
+	}
+	return slug
+}
+
+// truncateSlug leaves room in slug for the suffix of a numbered variant.
+func truncateSlug(slug string) string {
+	if limit := maxSlugLength - len("-100"); len(slug) > limit {
+		slug = strings.TrimRight(slug[:limit], "-")
+	}
+	return slug
+}
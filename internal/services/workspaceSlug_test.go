@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSlug(t *testing.T) {
+	tests := []struct {
+		slug  string
+		valid bool
+	}{
+		{"acme", true},
+		{"acme-corp-2", true},
+		{"a1b", true},
+		{"ab", false},
+		{strings.Repeat("a", 41), false},
+		{"Acme", false},
+		{"acme_corp", false},
+		{"acme corp", false},
+		{"-acme", false},
+		{"acme-", false},
+		{"acme--corp", false},
+		{"12345", false},
+		{"admin", false},
+		{"directory", false},
+	}
+	for _, tt := range tests {
+		err := validateSlug(tt.slug)
+		if tt.valid && err != nil {
+			t.Errorf("validateSlug(%q) = %v, want nil", tt.slug, err)
+		}
+		if !tt.valid {
+			if _, ok := err.(*ValidationError); !ok {
+				t.Errorf("validateSlug(%q) = %v, want ValidationError", tt.slug, err)
+			}
+		}
+	}
+}
+
+func TestSlugFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Acme Corp", "acme-corp"},
+		{"  R&D -- Team 7!  ", "r-d-team-7"},
+		{"Café", "caf"},
+		{"Admin", "workspace-admin"},
+		{"42", "workspace-42"},
+		{"", "workspace"},
+		{"!!!", "workspace"},
+		{strings.Repeat("ab ", 20), "ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab-ab"},
+	}
+	for _, tt := range tests {
+		got := slugFromName(tt.name)
+		if got != tt.want {
+			t.Errorf("slugFromName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if err := validateSlug(got); err != nil {
+			t.Errorf("slugFromName(%q) = %q, which is invalid: %v", tt.name, got, err)
+		}
+	}
+}
@@ -2,35 +2,102 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"axis/internal/mailer"
 	"axis/internal/models"
 	"axis/internal/repositories"
+	"axis/internal/utils"
 	"github.com/rs/zerolog"
 )
 
 type WorkspaceMemberService interface {
-	AddMemberToWorkspace(ctx context.Context, workspaceID, userID int, role models.UserRole) (*models.WorkspaceMember, error)
-	RemoveMemberFromWorkspace(ctx context.Context, workspaceID, userID int) error
+	AddMemberToWorkspace(ctx context.Context, actorID, workspaceID, userID int, role models.UserRole) (*models.WorkspaceMember, error)
+	// RemoveMemberFromWorkspace removes userID from the workspace. Members may always leave; removing
+	// someone else takes the member.remove permission. Neither the owner nor the last admin can be
+	// removed. Removals are recorded in the audit log.
+	RemoveMemberFromWorkspace(ctx context.Context, actorID, workspaceID, userID int) error
 	GetWorkspaceMembers(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error)
-	JoinWorkspace(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error)
+	// JoinWorkspace adds the user to the workspace. Unless the workspace is open, token must be a
+	// usable invitation to it; the member then gets the invitation's role.
+	JoinWorkspace(ctx context.Context, workspaceID, userID int, token string) (*models.WorkspaceMember, error)
+	// UnlockMember emails a locked-out member a sign-in link, which clears their lockout when they
+	// open it. Admins cannot clear it themselves: the lockout protects the account everywhere, not
+	// just in their workspace.
+	UnlockMember(ctx context.Context, adminID, workspaceID, userID int) error
+	// RequestToJoin asks the admins of a workspace that allows join requests to let the user in.
+	RequestToJoin(ctx context.Context, workspaceID, userID int, form models.CreateJoinRequestModel) (*models.WorkspaceJoinRequest, error)
+	GetJoinRequests(ctx context.Context, adminID, workspaceID int) ([]models.WorkspaceJoinRequest, error)
+	ApproveJoinRequest(ctx context.Context, adminID, workspaceID, requestID int, form models.ApproveJoinRequestModel) (*models.WorkspaceMember, error)
+	DenyJoinRequest(ctx context.Context, adminID, workspaceID, requestID int) error
 }
 
+const (
+	// JoinRequestEvent tells the admins of a workspace that someone asked to join it.
+	JoinRequestEvent = "join_request"
+	// JoinRequestDecidedEvent tells the user who asked to join that an admin approved or denied it.
+	JoinRequestDecidedEvent = "join_request_decided"
+
+	maxJoinRequestMessage = 500
+)
+
 type workspaceMemberService struct {
 	workspaceMemberRepo repositories.WorkspaceMemberRepo
 	workspaceRepo       repositories.WorkspaceRepo
+	userRepo            repositories.UserRepo
+	invitationRepo      repositories.InvitationRepo
+	joinRequestRepo     repositories.JoinRequestRepo
+	userService         UserService
+	quotaService        QuotaService
+	authorizer          Authorizer
+	auditService        AuditService
+	eventService        EventService
+	mailer              mailer.Mailer
 	log                 zerolog.Logger
 }
 
-func NewWorkspaceMemberService(wmr repositories.WorkspaceMemberRepo, wr repositories.WorkspaceRepo, logger zerolog.Logger) WorkspaceMemberService {
+func NewWorkspaceMemberService(wmr repositories.WorkspaceMemberRepo, wr repositories.WorkspaceRepo, ur repositories.UserRepo, ir repositories.InvitationRepo, jrr repositories.JoinRequestRepo, us UserService, qs QuotaService, authorizer Authorizer, as AuditService, es EventService, m mailer.Mailer, logger zerolog.Logger) WorkspaceMemberService {
 	return &workspaceMemberService{
 		workspaceMemberRepo: wmr,
 		workspaceRepo:       wr,
+		userRepo:            ur,
+		invitationRepo:      ir,
+		joinRequestRepo:     jrr,
+		userService:         us,
+		quotaService:        qs,
+		authorizer:          authorizer,
+		auditService:        as,
+		eventService:        es,
+		mailer:              m,
 		log:                 logger,
 	}
 }
 
-func (s *workspaceMemberService) AddMemberToWorkspace(ctx context.Context, workspaceID, userID int, role models.UserRole) (*models.WorkspaceMember, error) {
-	err := s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspaceID, userID, role)
+func (s *workspaceMemberService) AddMemberToWorkspace(ctx context.Context, actorID, workspaceID, userID int, role models.UserRole) (*models.WorkspaceMember, error) {
+	if !validAssignableRole(role) {
+		return nil, NewValidationError("role must be 0 (admin), 1 (member) or 3 (guest)")
+	}
+	if _, err := s.authorizer.Authorize(ctx, actorID, workspaceID, models.PermMemberInvite); err != nil {
+		return nil, err
+	}
+	if err := s.authorizer.AuthorizeGrant(ctx, actorID, workspaceID, role, nil); err != nil {
+		return nil, err
+	}
+	// Adding someone directly follows the rules for joining, apart from needing an invitation.
+	_, user, err := s.checkCanJoin(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeactivatedAt != nil {
+		return nil, NewValidationError("User's account is deactivated")
+	}
+	if err := s.quotaService.CheckSeats(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+	err = s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspaceID, userID, role)
 	if err != nil {
 		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Str("role", role.String()).Msg("Failed to add member to workspace")
 		return nil, err
@@ -44,12 +111,45 @@ func (s *workspaceMemberService) AddMemberToWorkspace(ctx context.Context, works
 	return workspaceMember, nil
 }
 
-func (s *workspaceMemberService) RemoveMemberFromWorkspace(ctx context.Context, workspaceID, userID int) error {
-	err := s.workspaceMemberRepo.RemoveMemberFromWorkspace(ctx, workspaceID, userID)
+func (s *workspaceMemberService) RemoveMemberFromWorkspace(ctx context.Context, actorID, workspaceID, userID int) error {
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if workspace == nil {
+		return NewNotFoundError("Workspace not found")
+	}
+	if actorID != userID {
+		if _, err := s.authorizer.Authorize(ctx, actorID, workspaceID, models.PermMemberRemove); err != nil {
+			return err
+		}
+	}
+	member, err := s.workspaceMemberRepo.GetWorkspaceMember(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return NewNotFoundError("User is not a member of this workspace")
+	}
+	if isOwner(workspace, member) {
+		return &ForbiddenError{Message: "The owner cannot be removed from the workspace"}
+	}
+	if err := checkAdminsRemain(ctx, s.authorizer, workspaceID, userID); err != nil {
+		return err
+	}
+
+	err = s.workspaceMemberRepo.RemoveMemberFromWorkspace(ctx, workspaceID, userID)
 	if err != nil {
 		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to remove member from workspace")
 		return err
 	}
+	s.auditService.Record(ctx, &models.WorkspaceAuditEntry{
+		WorkspaceID:  workspaceID,
+		ActorID:      actorID,
+		Action:       models.AuditMemberRemoved,
+		TargetUserID: &userID,
+		Details:      map[string]any{"role": member.Role.String()},
+	})
 	s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Member removed from workspace successfully")
 	return nil
 }
@@ -63,38 +163,299 @@ func (s *workspaceMemberService) GetWorkspaceMembers(ctx context.Context, worksp
 	return members, nil
 }
 
-func (s *workspaceMemberService) JoinWorkspace(ctx context.Context, workspaceID, userID int) (*models.WorkspaceMember, error) {
+func (s *workspaceMemberService) JoinWorkspace(ctx context.Context, workspaceID, userID int, token string) (*models.WorkspaceMember, error) {
+	workspace, user, err := s.checkCanJoin(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.quotaService.CheckSeats(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	workspaceMember := &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      userID,
+		Role:        models.Member,
+	}
+	if token != "" {
+		invitation, err := s.invitationRepo.GetInvitationByTokenHash(ctx, utils.HashToken(token))
+		if err != nil {
+			return nil, err
+		}
+		if invitation == nil || invitation.WorkspaceID != workspaceID || !invitation.Usable(time.Now()) {
+			s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Invalid or expired invitation used to join workspace")
+			return nil, &ForbiddenError{Message: "The invitation is invalid or has expired"}
+		}
+		if invitation.Email != "" && !strings.EqualFold(invitation.Email, user.Email) {
+			s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Int("invitation_id", invitation.ID).Msg("Invitation used by another account than the one invited")
+			return nil, &ForbiddenError{Message: "The invitation was sent to another email address"}
+		}
+
+		workspaceMember.Role = invitation.Role
+		redeemed, err := s.invitationRepo.RedeemInvitation(ctx, invitation.ID, workspaceMember)
+		if err != nil {
+			return nil, err
+		}
+		if !redeemed {
+			return nil, &ForbiddenError{Message: "The invitation is invalid or has expired"}
+		}
+		s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Int("invitation_id", invitation.ID).Msg("User joined workspace by invitation")
+		return workspaceMember, nil
+	}
+
+	if !workspace.IsOpen {
+		s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("User tried to join workspace without an invitation")
+		return nil, &ForbiddenError{Message: "An invitation is required to join this workspace"}
+	}
+	err = s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspaceID, userID, models.Member)
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to add user to workspace")
+		return nil, err
+	}
+
+	s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("User joined workspace successfully")
+	return workspaceMember, nil
+}
+
+// checkCanJoin loads the workspace and the user, and checks the user may become a member: they
+// are not one already and their email is verified if the workspace requires it.
+func (s *workspaceMemberService) checkCanJoin(ctx context.Context, workspaceID, userID int) (*models.Workspace, *models.User, error) {
 	workspace, err := s.workspaceRepo.GetWorkspaceByID(ctx, workspaceID)
 	if err != nil {
 		s.log.Error().Err(err).Int("workspace_id", workspaceID).Msg("Failed to get workspace by ID")
-		return nil, err
+		return nil, nil, err
 	}
 	if workspace == nil {
 		s.log.Warn().Int("workspace_id", workspaceID).Msg("Workspace not found for joining")
-		return nil, &NotFoundError{Message: "Workspace not found"}
+		return nil, nil, &NotFoundError{Message: "Workspace not found"}
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, NewNotFoundError("User not found")
+		}
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get user for joining workspace")
+		return nil, nil, err
+	}
+	if workspace.RequireVerifiedEmail {
+		if !user.IsVerified {
+			s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Unverified user tried to join workspace requiring verified email")
+			return nil, nil, &ForbiddenError{Message: "This workspace requires a verified email address"}
+		}
 	}
 
 	isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, workspaceID, userID)
 	if err != nil {
 		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to check if user is already a member")
-		return nil, err
+		return nil, nil, err
 	}
 	if isMember {
 		s.log.Warn().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("User is already a member of this workspace")
-		return nil, &ConflictError{Message: "User is already a member of this workspace"}
+		return nil, nil, &ConflictError{Message: "User is already a member of this workspace"}
+	}
+	return workspace, user, nil
+}
+
+func (s *workspaceMemberService) UnlockMember(ctx context.Context, adminID, workspaceID, userID int) error {
+	if _, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberUnlock); err != nil {
+		return err
 	}
 
-	err = s.workspaceMemberRepo.AddMemberToWorkspace(ctx, workspaceID, userID, models.Member)
+	isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, workspaceID, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to check workspace membership for unlock")
+		return err
+	}
+	if !isMember {
+		return NewNotFoundError("User is not a member of this workspace")
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		s.log.Error().Err(err).Int("user_id", userID).Msg("Failed to get user for unlock")
+		return err
+	}
+	s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Int("admin_id", adminID).Msg("Sending sign-in link to unlock member")
+	return s.userService.SendLoginLink(ctx, user.Email)
+}
+
+func (s *workspaceMemberService) RequestToJoin(ctx context.Context, workspaceID, userID int, form models.CreateJoinRequestModel) (*models.WorkspaceJoinRequest, error) {
+	workspace, user, err := s.checkCanJoin(ctx, workspaceID, userID)
 	if err != nil {
-		s.log.Error().Err(err).Int("workspace_id", workspaceID).Int("user_id", userID).Msg("Failed to add user to workspace")
 		return nil, err
 	}
+	if !workspace.AllowJoinRequests {
+		return nil, &ForbiddenError{Message: "This workspace does not accept join requests"}
+	}
+	message := strings.TrimSpace(form.Message)
+	if len(message) > maxJoinRequestMessage {
+		return nil, NewValidationError("message must be at most 500 characters")
+	}
 
-	workspaceMember := &models.WorkspaceMember{
+	pending, err := s.joinRequestRepo.GetPendingJoinRequest(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		return nil, &ConflictError{Message: "You already asked to join this workspace"}
+	}
+
+	request := &models.WorkspaceJoinRequest{
 		WorkspaceID: workspaceID,
 		UserID:      userID,
-		Role:        models.Member,
+		Message:     message,
+		Status:      models.JoinRequestPending,
 	}
-	s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Msg("User joined workspace successfully")
-	return workspaceMember, nil
+	if err := s.joinRequestRepo.CreateJoinRequest(ctx, request); err != nil {
+		return nil, err
+	}
+	request.User = user
+	s.log.Info().Int("workspace_id", workspaceID).Int("user_id", userID).Int("request_id", request.ID).Msg("User asked to join workspace")
+
+	s.notifyAdmins(ctx, workspace, request)
+	return request, nil
+}
+
+func (s *workspaceMemberService) GetJoinRequests(ctx context.Context, adminID, workspaceID int) ([]models.WorkspaceJoinRequest, error) {
+	if _, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberApprove); err != nil {
+		return nil, err
+	}
+	return s.joinRequestRepo.GetPendingJoinRequests(ctx, workspaceID)
+}
+
+func (s *workspaceMemberService) ApproveJoinRequest(ctx context.Context, adminID, workspaceID, requestID int, form models.ApproveJoinRequestModel) (*models.WorkspaceMember, error) {
+	role := models.Member
+	if form.Role != nil {
+		role = *form.Role
+	}
+	if !validAssignableRole(role) {
+		return nil, NewValidationError("role must be 0 (admin), 1 (member) or 3 (guest)")
+	}
+
+	workspace, request, err := s.pendingJoinRequest(ctx, adminID, workspaceID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizer.AuthorizeGrant(ctx, adminID, workspaceID, role, nil); err != nil {
+		return nil, err
+	}
+	isMember, err := s.workspaceMemberRepo.IsMemberOfWorkspace(ctx, workspaceID, request.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, &ConflictError{Message: "User is already a member of this workspace"}
+	}
+	if err := s.quotaService.CheckSeats(ctx, workspaceID); err != nil {
+		return nil, err
+	}
+
+	member := &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      request.UserID,
+		Role:        role,
+	}
+	request.Role = &role
+	if err := s.decide(ctx, adminID, workspace, request, models.JoinRequestApproved, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (s *workspaceMemberService) DenyJoinRequest(ctx context.Context, adminID, workspaceID, requestID int) error {
+	workspace, request, err := s.pendingJoinRequest(ctx, adminID, workspaceID, requestID)
+	if err != nil {
+		return err
+	}
+	return s.decide(ctx, adminID, workspace, request, models.JoinRequestDenied, nil)
+}
+
+func (s *workspaceMemberService) pendingJoinRequest(ctx context.Context, adminID, workspaceID, requestID int) (*models.Workspace, *models.WorkspaceJoinRequest, error) {
+	workspace, err := s.authorizer.Authorize(ctx, adminID, workspaceID, models.PermMemberApprove)
+	if err != nil {
+		return nil, nil, err
+	}
+	request, err := s.joinRequestRepo.GetJoinRequest(ctx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request == nil || request.WorkspaceID != workspaceID {
+		return nil, nil, NewNotFoundError("Join request not found")
+	}
+	if request.Status != models.JoinRequestPending {
+		return nil, nil, &ConflictError{Message: "The join request was already " + string(request.Status)}
+	}
+	return workspace, request, nil
+}
+
+func (s *workspaceMemberService) decide(ctx context.Context, adminID int, workspace *models.Workspace, request *models.WorkspaceJoinRequest, status models.JoinRequestStatus, member *models.WorkspaceMember) error {
+	now := time.Now()
+	request.Status = status
+	request.DecidedByID = &adminID
+	request.DecidedAt = &now
+
+	decided, err := s.joinRequestRepo.DecideJoinRequest(ctx, request, member)
+	if err != nil {
+		return err
+	}
+	if !decided {
+		return &ConflictError{Message: "The join request was already decided"}
+	}
+	s.log.Info().Int("workspace_id", workspace.ID).Int("request_id", request.ID).Int("admin_id", adminID).Str("status", string(status)).Msg("Join request decided")
+
+	s.eventService.PublishToUsers([]int{request.UserID}, JoinRequestDecidedEvent, request)
+	if request.User == nil {
+		return nil
+	}
+	var body string
+	if status == models.JoinRequestApproved {
+		body = fmt.Sprintf("Hi %s,\n\nYou are now a member of the %s workspace on Axis:\n\n%s\n",
+			request.User.Name, workspace.Name, utils.AppURL(fmt.Sprintf("/workspaces/%d", workspace.ID), nil))
+	} else {
+		body = fmt.Sprintf("Hi %s,\n\nAn admin of the %s workspace on Axis declined your request to join it.\n",
+			request.User.Name, workspace.Name)
+	}
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      request.User.Email,
+		Subject: fmt.Sprintf("Your request to join %s was %s", workspace.Name, status),
+		Body:    body,
+	})
+	if err != nil {
+		// The decision stands; the user also learns about it in the app.
+		s.log.Warn().Err(err).Int("request_id", request.ID).Msg("Failed to email join request decision")
+	}
+	return nil
+}
+
+// notifyAdmins lets everyone who can approve join requests know about a new one. Failing to reach
+// them does not undo the request, which stays in the queue.
+func (s *workspaceMemberService) notifyAdmins(ctx context.Context, workspace *models.Workspace, request *models.WorkspaceJoinRequest) {
+	members, err := s.authorizer.MembersWith(ctx, workspace.ID, models.PermMemberApprove)
+	if err != nil {
+		s.log.Warn().Err(err).Int("workspace_id", workspace.ID).Msg("Failed to get admins to notify about join request")
+		return
+	}
+
+	link := utils.AppURL(fmt.Sprintf("/workspaces/%d/join-requests", workspace.ID), nil)
+	var adminIDs []int
+	for _, member := range members {
+		adminIDs = append(adminIDs, member.UserID)
+		if member.User == nil {
+			continue
+		}
+		body := fmt.Sprintf("Hi %s,\n\n%s asked to join the %s workspace on Axis.", member.User.Name, request.User.Name, workspace.Name)
+		if request.Message != "" {
+			body += fmt.Sprintf(" They wrote:\n\n%s", request.Message)
+		}
+		err := s.mailer.Send(ctx, mailer.Message{
+			To:      member.User.Email,
+			Subject: fmt.Sprintf("%s asked to join %s", request.User.Name, workspace.Name),
+			Body:    body + fmt.Sprintf("\n\nApprove or deny the request here:\n\n%s\n", link),
+		})
+		if err != nil {
+			s.log.Warn().Err(err).Int("workspace_id", workspace.ID).Int("admin_id", member.UserID).Msg("Failed to email admin about join request")
+		}
+	}
+	s.eventService.PublishToUsers(adminIDs, JoinRequestEvent, request)
 }
@@ -26,3 +26,23 @@ func GetUserIDFromContext(c *gin.Context) (int, error) {
 
 	return id, nil
 }
+
+func GetSessionIDFromContext(c *gin.Context) (int, error) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "session ID not found in context. Middleware not applied or token invalid.",
+		})
+		return 0, fmt.Errorf("session ID not found in context")
+	}
+
+	id, ok := sessionID.(int)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "session ID in context is of invalid type",
+		})
+		return 0, fmt.Errorf("session ID in context is of invalid type")
+	}
+
+	return id, nil
+}
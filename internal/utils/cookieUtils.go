@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionModeHeader set to "cookie" on a login request asks for the session to be kept in
+	// HttpOnly cookies instead of being returned in the response body.
+	SessionModeHeader = "X-Session-Mode"
+	SessionModeCookie = "cookie"
+	// CSRFHeader carries the CSRF token on state-changing requests authenticated by cookie.
+	CSRFHeader = "X-CSRF-Token"
+	// CSRFQueryParam carries the CSRF token on WebSocket upgrades, where browsers cannot set headers.
+	CSRFQueryParam = "csrf_token"
+
+	accessCookieName  = "axis_session"
+	refreshCookieName = "axis_refresh"
+	csrfCookieName    = "axis_csrf"
+)
+
+// SessionCookieConfig controls the attributes of session cookies.
+type SessionCookieConfig struct {
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+var currentSessionCookieConfig atomic.Pointer[SessionCookieConfig]
+
+// SetSessionCookieConfig replaces the cookie attributes used by the session cookie functions.
+func SetSessionCookieConfig(config *SessionCookieConfig) {
+	currentSessionCookieConfig.Store(config)
+}
+
+func sessionCookieConfig() *SessionCookieConfig {
+	if config := currentSessionCookieConfig.Load(); config != nil {
+		return config
+	}
+	return &SessionCookieConfig{Secure: true, SameSite: http.SameSiteLaxMode}
+}
+
+// LoadSessionCookieConfig reads the cookie attributes from the environment:
+//
+//	SESSION_COOKIE_SECURE    "false" to also send cookies over plain HTTP (default true)
+//	SESSION_COOKIE_SAMESITE  lax (default), strict or none
+//	SESSION_COOKIE_DOMAIN    domain to share cookies with subdomains (default: host only)
+func LoadSessionCookieConfig() (*SessionCookieConfig, error) {
+	config := &SessionCookieConfig{
+		Secure: os.Getenv("SESSION_COOKIE_SECURE") != "false",
+		Domain: os.Getenv("SESSION_COOKIE_DOMAIN"),
+	}
+	switch strings.ToLower(envOrDefault("SESSION_COOKIE_SAMESITE", "lax")) {
+	case "lax":
+		config.SameSite = http.SameSiteLaxMode
+	case "strict":
+		config.SameSite = http.SameSiteStrictMode
+	case "none":
+		if !config.Secure {
+			return nil, fmt.Errorf("SESSION_COOKIE_SAMESITE=none requires secure cookies")
+		}
+		config.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("SESSION_COOKIE_SAMESITE must be lax, strict or none")
+	}
+	return config, nil
+}
+
+// cookieName adds the __Host- prefix where the browser allows it. Such cookies cannot be set by
+// sibling subdomains, which keeps the double-submitted CSRF cookie from being planted.
+func (c *SessionCookieConfig) cookieName(name string) string {
+	switch {
+	case c.Secure && c.Domain == "":
+		return "__Host-" + name
+	case c.Secure:
+		return "__Secure-" + name
+	}
+	return name
+}
+
+func (c *SessionCookieConfig) cookie(name, value string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.cookieName(name),
+		Value:    value,
+		Path:     "/",
+		Domain:   c.Domain,
+		MaxAge:   int(maxAge.Seconds()),
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+// WantsCookieSession reports whether the client asked for a cookie session.
+func WantsCookieSession(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(SessionModeHeader), SessionModeCookie)
+}
+
+// SetSessionCookies stores a session's tokens in HttpOnly cookies together with a fresh CSRF token,
+// which is returned. The CSRF cookie is readable by scripts so the client can echo it back.
+func SetSessionCookies(c *gin.Context, accessToken, refreshToken string, refreshTTL time.Duration) (string, error) {
+	csrfToken, err := GenerateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	config := sessionCookieConfig()
+	// The access cookie outlives its token so an expired token is reported as such rather than as
+	// a missing session; the client then refreshes.
+	http.SetCookie(c.Writer, config.cookie(accessCookieName, accessToken, refreshTTL, true))
+	http.SetCookie(c.Writer, config.cookie(refreshCookieName, refreshToken, refreshTTL, true))
+	http.SetCookie(c.Writer, config.cookie(csrfCookieName, csrfToken, refreshTTL, false))
+	return csrfToken, nil
+}
+
+// ClearSessionCookies removes the session cookies from the browser.
+func ClearSessionCookies(c *gin.Context) {
+	config := sessionCookieConfig()
+	for _, name := range []string{accessCookieName, refreshCookieName, csrfCookieName} {
+		cookie := config.cookie(name, "", 0, name != csrfCookieName)
+		cookie.MaxAge = -1
+		http.SetCookie(c.Writer, cookie)
+	}
+}
+
+// SessionCookieAccessToken returns the access token from the session cookie, if any.
+func SessionCookieAccessToken(c *gin.Context) string {
+	value, _ := c.Cookie(sessionCookieConfig().cookieName(accessCookieName))
+	return value
+}
+
+// SessionCookieRefreshToken returns the refresh token from the session cookie, if any.
+func SessionCookieRefreshToken(c *gin.Context) string {
+	value, _ := c.Cookie(sessionCookieConfig().cookieName(refreshCookieName))
+	return value
+}
+
+// ValidCSRF checks the double-submitted CSRF token of a request authenticated by cookie. Safe
+// methods need none, except WebSocket upgrades, which pass the token as a query parameter.
+func ValidCSRF(c *gin.Context) bool {
+	var submitted string
+	switch {
+	case strings.EqualFold(c.GetHeader("Upgrade"), "websocket"):
+		submitted = c.Query(CSRFQueryParam)
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions:
+		return true
+	default:
+		submitted = c.GetHeader(CSRFHeader)
+	}
+
+	expected, err := c.Cookie(sessionCookieConfig().cookieName(csrfCookieName))
+	if err != nil || expected == "" || submitted == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) == 1
+}
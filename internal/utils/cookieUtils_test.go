@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetSessionCookieConfig(&SessionCookieConfig{Secure: true, SameSite: http.SameSiteLaxMode})
+	defer SetSessionCookieConfig(nil)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/login", nil)
+	csrfToken, err := SetSessionCookies(c, "access", "refresh", time.Hour)
+	if err != nil {
+		t.Fatalf("SetSessionCookies returned error: %v", err)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 3 {
+		t.Fatalf("got %d cookies, want 3", len(cookies))
+	}
+	for _, cookie := range cookies {
+		if !strings.HasPrefix(cookie.Name, "__Host-") || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+			t.Errorf("cookie %s has weak attributes: %+v", cookie.Name, cookie)
+		}
+		if cookie.HttpOnly == (cookie.Name == "__Host-axis_csrf") {
+			t.Errorf("cookie %s: HttpOnly = %v", cookie.Name, cookie.HttpOnly)
+		}
+	}
+
+	request := func(method, target, csrfHeader string) *gin.Context {
+		r := httptest.NewRequest(method, target, nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		if csrfHeader != "" {
+			r.Header.Set(CSRFHeader, csrfHeader)
+		}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = r
+		return c
+	}
+
+	if token := SessionCookieAccessToken(request(http.MethodGet, "/api/users", "")); token != "access" {
+		t.Errorf("SessionCookieAccessToken = %q, want access", token)
+	}
+	if !ValidCSRF(request(http.MethodGet, "/api/users", "")) {
+		t.Error("GET without CSRF token was rejected")
+	}
+	if ValidCSRF(request(http.MethodPost, "/api/logout", "")) {
+		t.Error("POST without CSRF token was accepted")
+	}
+	if ValidCSRF(request(http.MethodPost, "/api/logout", "forged")) {
+		t.Error("POST with wrong CSRF token was accepted")
+	}
+	if !ValidCSRF(request(http.MethodPost, "/api/logout", csrfToken)) {
+		t.Error("POST with the CSRF token was rejected")
+	}
+
+	upgrade := request(http.MethodGet, "/ws/meeting/1/chat", "")
+	upgrade.Request.Header.Set("Upgrade", "websocket")
+	if ValidCSRF(upgrade) {
+		t.Error("WebSocket upgrade without CSRF token was accepted")
+	}
+	upgrade = request(http.MethodGet, "/ws/meeting/1/chat?csrf_token="+csrfToken, "")
+	upgrade.Request.Header.Set("Upgrade", "websocket")
+	if !ValidCSRF(upgrade) {
+		t.Error("WebSocket upgrade with CSRF token was rejected")
+	}
+}
@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+)
+
+// AvatarSizes are the square sizes, in pixels, avatars are stored and served in.
+var AvatarSizes = []int{32, 64, 128, 256}
+
+// MaxAvatarDimension bounds the width and height of an uploaded image. The header is checked
+// before decoding, so a small file cannot expand into a huge bitmap.
+const MaxAvatarDimension = 4096
+
+var ErrUnsupportedImage = errors.New("unsupported image")
+
+// AvatarSize returns the standard size to serve for a requested one: the smallest that is at
+// least as large, or the largest there is.
+func AvatarSize(requested int) int {
+	for _, size := range AvatarSizes {
+		if size >= requested {
+			return size
+		}
+	}
+	return AvatarSizes[len(AvatarSizes)-1]
+}
+
+// DecodeAvatar decodes a PNG, JPEG or GIF image, rejecting anything larger than
+// MaxAvatarDimension on either side.
+func DecodeAvatar(data []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width > MaxAvatarDimension || config.Height > MaxAvatarDimension {
+		return nil, fmt.Errorf("%w: images must be at most %dx%d pixels", ErrUnsupportedImage, MaxAvatarDimension, MaxAvatarDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	return img, nil
+}
+
+// ResizeSquare crops the centre square out of src and scales it to size x size. Each target pixel
+// averages the source pixels it covers, which keeps downscaled photos free of aliasing.
+func ResizeSquare(src image.Image, size int) *image.NRGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	// Work on straight alpha so transparent edges don't darken.
+	rgba := image.NewNRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(rgba, rgba.Bounds(), src, image.Pt(x0, y0), draw.Src)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0, sy1 := dy*side/size, (dy+1)*side/size
+		if sy1 == sy0 {
+			sy1 = sy0 + 1
+		}
+		for dx := 0; dx < size; dx++ {
+			sx0, sx1 := dx*side/size, (dx+1)*side/size
+			if sx1 == sx0 {
+				sx1 = sx0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				i := rgba.PixOffset(sx0, sy)
+				for sx := sx0; sx < sx1; sx++ {
+					pa := uint64(rgba.Pix[i+3])
+					r += uint64(rgba.Pix[i]) * pa
+					g += uint64(rgba.Pix[i+1]) * pa
+					b += uint64(rgba.Pix[i+2]) * pa
+					a += pa
+					n++
+					i += 4
+				}
+			}
+			o := dst.PixOffset(dx, dy)
+			if a > 0 {
+				dst.Pix[o] = uint8(r / a)
+				dst.Pix[o+1] = uint8(g / a)
+				dst.Pix[o+2] = uint8(b / a)
+				dst.Pix[o+3] = uint8(a / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Identicon draws the default avatar for seed: a horizontally symmetric 5x5 pattern in a colour
+// derived from the seed, on a light background. The same seed always gives the same picture.
+func Identicon(seed string, size int) *image.NRGBA {
+	sum := sha256.Sum256([]byte(seed))
+	fg := hslColor(float64(sum[0])/255*360, 0.55, 0.5)
+	bg := color.NRGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+
+	// Only the left three columns are chosen; the right two mirror them.
+	var cells [5][3]bool
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 3; col++ {
+			cells[row][col] = sum[1+row*3+col]&1 == 1
+		}
+	}
+
+	// The pattern sits in a 6x6 grid, leaving half a cell of margin on every side. Columns are
+	// measured from the nearer edge so the picture stays symmetric when size isn't a multiple
+	// of the grid.
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		v := y * 12 / size
+		for x := 0; x < size; x++ {
+			u := min(x, size-1-x) * 12 / size
+			c := bg
+			if v >= 1 && v <= 10 && u >= 1 && cells[(v-1)/2][(u-1)/2] {
+				c = fg
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// EncodePNG writes img as PNG.
+func EncodePNG(w io.Writer, img image.Image) error {
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	return encoder.Encode(w, img)
+}
+
+func hslColor(h, s, l float64) color.NRGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g = c, x
+	case hp < 2:
+		r, g = x, c
+	case hp < 3:
+		g, b = c, x
+	case hp < 4:
+		g, b = x, c
+	case hp < 5:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	return color.NRGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 0xff}
+}
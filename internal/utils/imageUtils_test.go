@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestResizeSquare(t *testing.T) {
+	// A 300x200 image: red on the left half, blue on the right. The centre crop is 200x200
+	// and keeps both halves.
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 150 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			src.SetNRGBA(x, y, c)
+		}
+	}
+
+	dst := ResizeSquare(src, 32)
+	if got := dst.Bounds(); got.Dx() != 32 || got.Dy() != 32 {
+		t.Fatalf("bounds = %v, want 32x32", got)
+	}
+	if got := dst.NRGBAAt(2, 16); got != (color.NRGBA{R: 255, A: 255}) {
+		t.Errorf("left pixel = %v, want red", got)
+	}
+	if got := dst.NRGBAAt(29, 16); got != (color.NRGBA{B: 255, A: 255}) {
+		t.Errorf("right pixel = %v, want blue", got)
+	}
+}
+
+func TestDecodeAvatar(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 10, 10))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeAvatar(buf.Bytes()); err != nil {
+		t.Errorf("DecodeAvatar(png) returned error: %v", err)
+	}
+
+	if _, err := DecodeAvatar([]byte("not an image")); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("DecodeAvatar(text) = %v, want ErrUnsupportedImage", err)
+	}
+
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, MaxAvatarDimension+1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeAvatar(buf.Bytes()); !errors.Is(err, ErrUnsupportedImage) {
+		t.Errorf("DecodeAvatar(oversized) = %v, want ErrUnsupportedImage", err)
+	}
+}
+
+func TestIdenticon(t *testing.T) {
+	a := Identicon("42", 64)
+	if !bytes.Equal(a.Pix, Identicon("42", 64).Pix) {
+		t.Error("identicon is not deterministic")
+	}
+	if bytes.Equal(a.Pix, Identicon("43", 64).Pix) {
+		t.Error("different seeds gave the same identicon")
+	}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 32; x++ {
+			if a.NRGBAAt(x, y) != a.NRGBAAt(63-x, y) {
+				t.Fatalf("identicon is not symmetric at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestAvatarSize(t *testing.T) {
+	for requested, want := range map[int]int{1: 32, 32: 32, 33: 64, 200: 256, 1000: 256} {
+		if got := AvatarSize(requested); got != want {
+			t.Errorf("AvatarSize(%d) = %d, want %d", requested, got, want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	defaultJWTIssuer   = "axis"
+	defaultJWTAudience = "axis"
+	minRSAKeyBits      = 2048
+)
+
+// JWTConfig holds the keys tokens are signed and verified with. One private key signs new tokens;
+// every key in the set, including retired ones that only have a public key left, verifies them.
+// Keys are identified by the kid header.
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// Ephemeral is set when no key directory is configured and a key was generated at startup.
+	// Tokens signed with it do not survive a restart and other instances cannot verify them.
+	Ephemeral bool
+
+	signingKey *jwtKey
+	keys       map[string]*jwtKey
+}
+
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JWTPublicKey is a verification key as published in the JWKS document.
+type JWTPublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+var currentJWTConfig atomic.Pointer[JWTConfig]
+
+// SetJWTConfig installs the keys used by the token functions in this package.
+func SetJWTConfig(config *JWTConfig) {
+	currentJWTConfig.Store(config)
+}
+
+// CurrentJWTConfig returns the installed keys, or nil before SetJWTConfig is called.
+func CurrentJWTConfig() *JWTConfig {
+	return currentJWTConfig.Load()
+}
+
+func jwtConfig() (*JWTConfig, error) {
+	config := currentJWTConfig.Load()
+	if config == nil {
+		return nil, errors.New("JWT signing keys are not configured")
+	}
+	return config, nil
+}
+
+// LoadJWTConfig reads the key set from the environment:
+//
+//	JWT_KEYS_DIR        directory of PEM files, one key per file, named <kid>.pem
+//	JWT_SIGNING_KEY_ID  kid of the private key that signs new tokens (default: the last private key by name)
+//	JWT_ISSUER          iss claim (default "axis")
+//	JWT_AUDIENCE        aud claim of access tokens (default "axis")
+//
+// Private keys may be RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8). Public keys (PKIX) are
+// accepted for retired keys whose tokens should still verify. Without JWT_KEYS_DIR an ephemeral
+// Ed25519 key is generated.
+func LoadJWTConfig() (*JWTConfig, error) {
+	config := &JWTConfig{
+		Issuer:   envOrDefault("JWT_ISSUER", defaultJWTIssuer),
+		Audience: envOrDefault("JWT_AUDIENCE", defaultJWTAudience),
+		keys:     make(map[string]*jwtKey),
+	}
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key, err := newJWTKey("ephemeral", private)
+		if err != nil {
+			return nil, err
+		}
+		config.keys[key.id] = key
+		config.signingKey = key
+		config.Ephemeral = true
+		return config, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var lastPrivate *jwtKey
+	for _, path := range paths {
+		key, err := readJWTKeyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		config.keys[key.id] = key
+		if key.private != nil {
+			lastPrivate = key
+		}
+	}
+
+	if id := os.Getenv("JWT_SIGNING_KEY_ID"); id != "" {
+		key, ok := config.keys[id]
+		if !ok || key.private == nil {
+			return nil, fmt.Errorf("JWT_SIGNING_KEY_ID %q does not name a private key in %s", id, dir)
+		}
+		config.signingKey = key
+	} else {
+		config.signingKey = lastPrivate
+	}
+	if config.signingKey == nil {
+		return nil, fmt.Errorf("no private key found in %s", dir)
+	}
+	return config, nil
+}
+
+// NewJWTConfig builds a key set from keys in memory, by kid. signingKeyID names the private key
+// that signs new tokens; the other keys only verify.
+func NewJWTConfig(issuer, audience string, keys map[string]any, signingKeyID string) (*JWTConfig, error) {
+	config := &JWTConfig{Issuer: issuer, Audience: audience, keys: make(map[string]*jwtKey)}
+	for id, k := range keys {
+		key, err := newJWTKey(id, k)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		config.keys[id] = key
+	}
+	key, ok := config.keys[signingKeyID]
+	if !ok || key.private == nil {
+		return nil, fmt.Errorf("signing key %q is not a private key in the set", signingKeyID)
+	}
+	config.signingKey = key
+	return config, nil
+}
+
+// PublicKeys lists every verification key, signing key first.
+func (c *JWTConfig) PublicKeys() []JWTPublicKey {
+	keys := []JWTPublicKey{{ID: c.signingKey.id, Algorithm: c.signingKey.method.Alg(), Key: c.signingKey.public}}
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		if id != c.signingKey.id {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		k := c.keys[id]
+		keys = append(keys, JWTPublicKey{ID: k.id, Algorithm: k.method.Alg(), Key: k.public})
+	}
+	return keys
+}
+
+func (c *JWTConfig) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(c.signingKey.method, claims)
+	token.Header["kid"] = c.signingKey.id
+	return token.SignedString(c.signingKey.private)
+}
+
+// parse verifies a token against the key named by its kid header. The algorithm must be the one
+// that belongs to that key, so a token cannot pick a weaker or symmetric algorithm itself.
+func (c *JWTConfig) parse(tokenStr string, claims jwt.Claims, audience string) error {
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := c.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("algorithm %s does not match key %q", token.Method.Alg(), kid)
+		}
+		return key.public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(c.Issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return errors.New("invalid token")
+	}
+	return nil
+}
+
+func newJWTKey(id string, k any) (*jwtKey, error) {
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{id: id, method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSAKeyBits)
+		}
+		return &jwtKey{id: id, method: jwt.SigningMethodRS256, public: k}, nil
+	case ed25519.PrivateKey:
+		return &jwtKey{id: id, method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &jwtKey{id: id, method: jwt.SigningMethodEdDSA, public: k}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %T", k)
+}
+
+func readJWTKeyFile(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newJWTKey(strings.TrimSuffix(filepath.Base(path), ".pem"), key)
+}
+
+func envOrDefault(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
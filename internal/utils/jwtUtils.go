@@ -1,43 +1,125 @@
 package utils
 
 import (
-	"os"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+const AccessTokenTTL = 15 * time.Minute
+
+// actionTokenAudience keeps action tokens and access tokens from being accepted in place of each other.
+const actionTokenAudience = "axis-action"
 
 type Claims struct {
-	UserID      int `json:"user_id"`
+	UserID    int `json:"user_id"`
+	SessionID int `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID int) (string, error) {
+func GenerateAccessToken(userID, sessionID int) (string, error) {
+	config, err := jwtConfig()
+	if err != nil {
+		return "", err
+	}
+
 	claims := Claims{
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt: jwt.NewNumericDate(time.Now()),
-
+			Issuer:    config.Issuer,
+			Subject:   fmt.Sprint(userID),
+			Audience:  jwt.ClaimStrings{config.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString(jwtSecret)
+	return config.sign(claims)
 }
 
 func ParseToken(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func (token *jwt.Token) (any, error) {
-		return jwtSecret, nil
-	})
+	config, err := jwtConfig()
+	if err != nil {
+		return nil, err
+	}
 
-	if err != nil || !token.Valid {
+	claims := &Claims{}
+	if err := config.parse(tokenStr, claims, config.Audience); err != nil {
 		return nil, err
 	}
+	return claims, nil
+}
+
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposeTwoFactorLogin    = "two_factor_login"
+	PurposeLoginLink         = "login_link"
+)
+
+// ActionClaims back signed, expiring tokens for single purposes such as email verification links
+// and the second step of a two-factor login.
+type ActionClaims struct {
+	Purpose string `json:"purpose"`
+	UserID  int    `json:"user_id"`
+	Email   string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+func GenerateActionToken(purpose string, userID int, email string, ttl time.Duration) (string, error) {
+	return generateActionToken(purpose, userID, email, ttl, "")
+}
+
+// GenerateSingleUseActionToken is GenerateActionToken with a random token ID (the jti claim). The
+// caller records the ID and crosses it off when the token is redeemed, so it only works once.
+func GenerateSingleUseActionToken(purpose string, userID int, email string, ttl time.Duration) (string, string, error) {
+	id, err := GenerateOpaqueToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := generateActionToken(purpose, userID, email, ttl, id)
+	if err != nil {
+		return "", "", err
+	}
+	return token, id, nil
+}
+
+func generateActionToken(purpose string, userID int, email string, ttl time.Duration, id string) (string, error) {
+	config, err := jwtConfig()
+	if err != nil {
+		return "", err
+	}
+
+	claims := ActionClaims{
+		Purpose: purpose,
+		UserID:  userID,
+		Email:   email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Issuer:    config.Issuer,
+			Audience:  jwt.ClaimStrings{actionTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
 
-	return token.Claims.(*Claims), nil
+	return config.sign(claims)
 }
 
+// ParseActionToken validates an action token and checks it was issued for purpose.
+func ParseActionToken(tokenStr, purpose string) (*ActionClaims, error) {
+	config, err := jwtConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &ActionClaims{}
+	if err := config.parse(tokenStr, claims, actionTokenAudience); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, fmt.Errorf("action token was issued for %q, not %q", claims.Purpose, purpose)
+	}
+	return claims, nil
+}
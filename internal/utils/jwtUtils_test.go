@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testJWTConfig(t *testing.T) (*JWTConfig, *rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := NewJWTConfig("axis", "axis", map[string]any{
+		"2024-01": &rsaKey.PublicKey,
+		"2024-06": edKey,
+	}, "2024-06")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config, rsaKey, edKey
+}
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	config, _, _ := testJWTConfig(t)
+	SetJWTConfig(config)
+
+	token, err := GenerateAccessToken(7, 42)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken returned error: %v", err)
+	}
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "2024-06" || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("token header = %v, want kid 2024-06 and alg EdDSA", parsed.Header)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken returned error: %v", err)
+	}
+	if claims.UserID != 7 || claims.SessionID != 42 {
+		t.Errorf("claims = %+v, want user 7 session 42", claims)
+	}
+}
+
+func TestParseTokenAcceptsRetiredKey(t *testing.T) {
+	config, rsaKey, _ := testJWTConfig(t)
+	SetJWTConfig(config)
+
+	token := signTestToken(t, jwt.SigningMethodRS256, "2024-01", rsaKey, testClaims("axis", "axis"))
+	if _, err := ParseToken(token); err != nil {
+		t.Errorf("token signed with retired key was rejected: %v", err)
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	config, rsaKey, edKey := testJWTConfig(t)
+	SetJWTConfig(config)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"unknown kid", signTestToken(t, jwt.SigningMethodEdDSA, "2023-01", otherKey, testClaims("axis", "axis"))},
+		{"wrong key for kid", signTestToken(t, jwt.SigningMethodEdDSA, "2024-06", otherKey, testClaims("axis", "axis"))},
+		{"algorithm not matching key", signTestToken(t, jwt.SigningMethodPS256, "2024-01", rsaKey, testClaims("axis", "axis"))},
+		{"symmetric algorithm", signTestToken(t, jwt.SigningMethodHS256, "2024-06", []byte(edKey.Public().(ed25519.PublicKey)), testClaims("axis", "axis"))},
+		{"wrong issuer", signTestToken(t, jwt.SigningMethodEdDSA, "2024-06", edKey, testClaims("someone-else", "axis"))},
+		{"wrong audience", signTestToken(t, jwt.SigningMethodEdDSA, "2024-06", edKey, testClaims("axis", "billing"))},
+		{"action token", signTestToken(t, jwt.SigningMethodEdDSA, "2024-06", edKey, testClaims("axis", actionTokenAudience))},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseToken(tt.token); err == nil {
+			t.Errorf("%s: token was accepted", tt.name)
+		}
+	}
+}
+
+func TestActionTokenIsNotAnAccessToken(t *testing.T) {
+	config, _, _ := testJWTConfig(t)
+	SetJWTConfig(config)
+
+	token, err := GenerateActionToken(PurposeEmailVerification, 7, "user@example.com", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateActionToken returned error: %v", err)
+	}
+	if _, err := ParseToken(token); err == nil {
+		t.Error("action token was accepted as an access token")
+	}
+	if _, err := ParseActionToken(token, PurposeTwoFactorLogin); err == nil {
+		t.Error("action token was accepted for another purpose")
+	}
+	if claims, err := ParseActionToken(token, PurposeEmailVerification); err != nil || claims.UserID != 7 {
+		t.Errorf("ParseActionToken = %+v, %v", claims, err)
+	}
+}
+
+func TestNewJWTConfigRejectsShortRSAKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewJWTConfig("axis", "axis", map[string]any{"weak": key}, "weak")
+	if err == nil || !strings.Contains(err.Error(), "2048") {
+		t.Errorf("expected short RSA key to be rejected, got %v", err)
+	}
+}
+
+func testClaims(issuer, audience string) Claims {
+	return Claims{
+		UserID:    7,
+		SessionID: 42,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+}
+
+func signTestToken(t *testing.T, method jwt.SigningMethod, kid string, key any, claims Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing %s token: %v", method.Alg(), err)
+	}
+	return s
+}
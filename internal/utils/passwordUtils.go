@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// PasswordHashConfig selects how new password hashes are made. Hashes made with another algorithm
+// or weaker parameters still verify; PasswordNeedsRehash reports them so they can be upgraded the
+// next time the plain password is at hand.
+type PasswordHashConfig struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2id parameters: memory in KiB, number of passes and degree of parallelism.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+}
+
+// DefaultPasswordHashConfig follows the argon2id recommendation of RFC 9106 for memory
+// constrained environments.
+var DefaultPasswordHashConfig = PasswordHashConfig{
+	Algorithm:         PasswordHashArgon2id,
+	BcryptCost:        12,
+	Argon2Memory:      64 * 1024,
+	Argon2Iterations:  3,
+	Argon2Parallelism: 2,
+}
+
+var currentPasswordHashConfig atomic.Pointer[PasswordHashConfig]
+
+// SetPasswordHashConfig replaces the parameters new hashes are made with.
+func SetPasswordHashConfig(config *PasswordHashConfig) {
+	currentPasswordHashConfig.Store(config)
+}
+
+func passwordHashConfig() *PasswordHashConfig {
+	if config := currentPasswordHashConfig.Load(); config != nil {
+		return config
+	}
+	return &DefaultPasswordHashConfig
+}
+
+// LoadPasswordHashConfig reads the hash parameters from the environment:
+//
+//	PASSWORD_HASH_ALGORITHM     argon2id (default) or bcrypt
+//	PASSWORD_BCRYPT_COST        bcrypt cost (default 12)
+//	PASSWORD_ARGON2_MEMORY      argon2id memory in KiB (default 65536)
+//	PASSWORD_ARGON2_ITERATIONS  argon2id passes (default 3)
+//	PASSWORD_ARGON2_PARALLELISM argon2id lanes (default 2)
+func LoadPasswordHashConfig() (*PasswordHashConfig, error) {
+	config := DefaultPasswordHashConfig
+	config.Algorithm = envOrDefault("PASSWORD_HASH_ALGORITHM", config.Algorithm)
+	if config.Algorithm != PasswordHashArgon2id && config.Algorithm != PasswordHashBcrypt {
+		return nil, fmt.Errorf("PASSWORD_HASH_ALGORITHM %q is not argon2id or bcrypt", config.Algorithm)
+	}
+
+	var err error
+	if config.BcryptCost, err = envInt("PASSWORD_BCRYPT_COST", config.BcryptCost, bcrypt.MinCost, bcrypt.MaxCost); err != nil {
+		return nil, err
+	}
+	memory, err := envInt("PASSWORD_ARGON2_MEMORY", int(config.Argon2Memory), 8*1024, 4*1024*1024)
+	if err != nil {
+		return nil, err
+	}
+	iterations, err := envInt("PASSWORD_ARGON2_ITERATIONS", int(config.Argon2Iterations), 1, 100)
+	if err != nil {
+		return nil, err
+	}
+	parallelism, err := envInt("PASSWORD_ARGON2_PARALLELISM", int(config.Argon2Parallelism), 1, 255)
+	if err != nil {
+		return nil, err
+	}
+	config.Argon2Memory = uint32(memory)
+	config.Argon2Iterations = uint32(iterations)
+	config.Argon2Parallelism = uint8(parallelism)
+	return &config, nil
+}
+
+// HashPassword hashes password with the configured algorithm. bcrypt.ErrPasswordTooLong is
+// returned when bcrypt is configured and the password exceeds its 72 byte limit.
+func HashPassword(password string) (string, error) {
+	config := passwordHashConfig()
+	if config.Algorithm == PasswordHashBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), config.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, config.Argon2Iterations, config.Argon2Memory, config.Argon2Parallelism, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches hash, which may be a bcrypt or argon2id hash.
+func CheckPassword(hash, password string) bool {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Argon2Iterations, params.Argon2Memory, params.Argon2Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// PasswordNeedsRehash reports whether hash was made with another algorithm or weaker parameters
+// than the configured ones.
+func PasswordNeedsRehash(hash string) bool {
+	config := passwordHashConfig()
+	if config.Algorithm == PasswordHashBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost < config.BcryptCost
+	}
+
+	params, _, key, err := decodeArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return params.Argon2Memory < config.Argon2Memory ||
+		params.Argon2Iterations < config.Argon2Iterations ||
+		params.Argon2Parallelism != config.Argon2Parallelism ||
+		len(key) < argon2KeyLength
+}
+
+// decodeArgon2Hash parses the PHC string format written by HashPassword.
+func decodeArgon2Hash(hash string) (*PasswordHashConfig, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return nil, nil, nil, errors.New("not an argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.New("unsupported argon2 version")
+	}
+	params := &PasswordHashConfig{Algorithm: PasswordHashArgon2id}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Argon2Memory, &params.Argon2Iterations, &params.Argon2Parallelism); err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+	if params.Argon2Iterations == 0 || params.Argon2Parallelism == 0 {
+		return nil, nil, nil, errors.New("invalid argon2 parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errors.New("invalid argon2 hash")
+	}
+	return params, salt, key, nil
+}
+
+func envInt(name string, fallback, min, max int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be a number between %d and %d", name, min, max)
+	}
+	return n, nil
+}
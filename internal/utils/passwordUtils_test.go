@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordArgon2id(t *testing.T) {
+	SetPasswordHashConfig(&PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+	defer SetPasswordHashConfig(nil)
+
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("HashPassword returned error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=8192,t=1,p=1$") {
+		t.Errorf("hash = %q, want argon2id PHC string", hash)
+	}
+	if !CheckPassword(hash, "correct horse battery staple") {
+		t.Error("CheckPassword rejected the right password")
+	}
+	if CheckPassword(hash, "correct horse battery") {
+		t.Error("CheckPassword accepted a wrong password")
+	}
+	if PasswordNeedsRehash(hash) {
+		t.Error("fresh hash reported as outdated")
+	}
+
+	SetPasswordHashConfig(&PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2Memory: 16 * 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+	if !PasswordNeedsRehash(hash) {
+		t.Error("hash with less memory than configured not reported as outdated")
+	}
+}
+
+func TestPasswordNeedsRehashForBcrypt(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("hunter2hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetPasswordHashConfig(&PasswordHashConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	defer SetPasswordHashConfig(nil)
+	if !CheckPassword(string(legacy), "hunter2hunter2") {
+		t.Error("CheckPassword rejected a bcrypt hash")
+	}
+	if !PasswordNeedsRehash(string(legacy)) {
+		t.Error("bcrypt hash below the configured cost not reported as outdated")
+	}
+
+	SetPasswordHashConfig(&PasswordHashConfig{Algorithm: PasswordHashArgon2id, Argon2Memory: 8 * 1024, Argon2Iterations: 1, Argon2Parallelism: 1})
+	if !PasswordNeedsRehash(string(legacy)) {
+		t.Error("bcrypt hash not reported as outdated when argon2id is configured")
+	}
+}
+
+func TestCheckPasswordRejectsMalformedHashes(t *testing.T) {
+	for _, hash := range []string{"", "plain", "$argon2id$v=19$m=8192,t=0,p=1$c2FsdA$a2V5", "$argon2id$v=16$m=8192,t=1,p=1$c2FsdA$a2V5"} {
+		if CheckPassword(hash, "plain") {
+			t.Errorf("CheckPassword accepted malformed hash %q", hash)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// Test vectors from RFC 6238 appendix B (SHA-1), truncated to six digits.
+func TestGenerateTOTPCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := GenerateTOTPCode(secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateTOTPCode(%d) returned error: %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateTOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTPCodeAllowsOneStepOfDrift(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret returned error: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	previous, _ := GenerateTOTPCode(secret, TOTPStep(now)-1)
+	if step, ok := ValidateTOTPCode(secret, previous, now); !ok || step != TOTPStep(now)-1 {
+		t.Errorf("expected code from previous step to validate, got step=%d ok=%v", step, ok)
+	}
+
+	stale, _ := GenerateTOTPCode(secret, TOTPStep(now)-2)
+	if _, ok := ValidateTOTPCode(secret, stale, now); ok {
+		t.Error("expected code from two steps ago to be rejected")
+	}
+
+	if _, ok := ValidateTOTPCode(secret, "12345", now); ok {
+		t.Error("expected code with wrong length to be rejected")
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+var (
+	appBaseURL = os.Getenv("APP_BASE_URL")
+	apiBaseURL = os.Getenv("API_BASE_URL")
+)
+
+// AppURL builds a link into the web client, e.g. for links sent by email.
+func AppURL(path string, query url.Values) string {
+	base := strings.TrimRight(appBaseURL, "/")
+	if base == "" {
+		base = "http://localhost:5173"
+	}
+	u := base + "/" + strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// APIURL builds an absolute URL to this API, for endpoints that third parties such as identity
+// providers call directly.
+func APIURL(path string) string {
+	base := strings.TrimRight(apiBaseURL, "/")
+	if base == "" {
+		base = "http://localhost:8080"
+	}
+	return base + "/" + strings.TrimLeft(path, "/")
+}
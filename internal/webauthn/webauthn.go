@@ -0,0 +1,410 @@
+// Package webauthn implements the relying party side of the WebAuthn registration and
+// authentication ceremonies (https://www.w3.org/TR/webauthn-2/) for passkey sign-in.
+//
+// Only "none" attestation is requested. Attestation statements that authenticators send anyway are
+// not verified, so credentials are trusted for authentication but say nothing about the make of
+// the authenticator.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Timeout is how long the browser is told to wait for the user, and how long challenges stay valid.
+const Timeout = 5 * time.Minute
+
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+var (
+	rpID      = os.Getenv("WEBAUTHN_RP_ID")
+	rpName    = os.Getenv("WEBAUTHN_RP_NAME")
+	rpOrigins = os.Getenv("WEBAUTHN_ORIGINS")
+)
+
+// RelyingParty holds the identity browsers bind credentials to.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// NewRelyingParty reads WEBAUTHN_RP_ID, WEBAUTHN_RP_NAME and the comma separated
+// WEBAUTHN_ORIGINS, defaulting to the local development client.
+func NewRelyingParty() *RelyingParty {
+	rp := &RelyingParty{ID: rpID, Name: rpName}
+	if rp.ID == "" {
+		rp.ID = "localhost"
+	}
+	if rp.Name == "" {
+		rp.Name = "Axis"
+	}
+	for _, origin := range strings.Split(rpOrigins, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			rp.Origins = append(rp.Origins, origin)
+		}
+	}
+	if len(rp.Origins) == 0 {
+		rp.Origins = []string{"http://localhost:5173"}
+	}
+	return rp
+}
+
+// NewChallenge returns a random base64url challenge.
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is passed to navigator.credentials.create() as the publicKey member.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is passed to navigator.credentials.get() as the publicKey member.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationCredential is the JSON form of the PublicKeyCredential returned by create(), with
+// binary fields base64url encoded.
+type RegistrationCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionCredential is the JSON form of the PublicKeyCredential returned by get().
+type AssertionCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is what a successful registration yields and what must be stored for later logins.
+type Credential struct {
+	ID           []byte
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       []byte
+	UserVerified bool
+}
+
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	aaguid    []byte
+	credID    []byte
+	publicKey []byte
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.ID, Name: rp.Name},
+		User: UserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(userHandle),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor) RequestOptions {
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          Timeout.Milliseconds(),
+		RPID:             rp.ID,
+		AllowCredentials: allow,
+		UserVerification: "preferred",
+	}
+}
+
+// ClientDataChallenge extracts the challenge a response was made for, so the caller can look up
+// the matching ceremony before verifying it.
+func ClientDataChallenge(clientDataJSON string) (string, error) {
+	raw, err := decodeBase64URL(clientDataJSON)
+	if err != nil {
+		return "", fmt.Errorf("invalid clientDataJSON encoding: %w", err)
+	}
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return "", fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	return cd.Challenge, nil
+}
+
+// VerifyRegistration checks a create() response against the challenge that was issued for it.
+func (rp *RelyingParty) VerifyRegistration(cred RegistrationCredential, challenge string) (*Credential, error) {
+	if cred.Type != "public-key" {
+		return nil, errors.New("credential type must be public-key")
+	}
+	if _, err := rp.verifyClientData(cred.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawObject, err := decodeBase64URL(cred.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestationObject encoding: %w", err)
+	}
+	v, _, err := decodeCBOR(rawObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestationObject: %w", err)
+	}
+	object, ok := v.(map[any]any)
+	if !ok {
+		return nil, errors.New("attestationObject is not a map")
+	}
+	rawAuthData, ok := object["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestationObject has no authData")
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 || authData.credID == nil {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+	if _, err := parseCOSEKey(authData.publicKey); err != nil {
+		return nil, err
+	}
+	if rawID, err := decodeBase64URL(cred.RawID); err == nil && len(rawID) > 0 && !bytes.Equal(rawID, authData.credID) {
+		return nil, errors.New("rawId does not match attested credential")
+	}
+
+	return &Credential{
+		ID:           authData.credID,
+		PublicKey:    authData.publicKey,
+		SignCount:    authData.signCount,
+		AAGUID:       authData.aaguid,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAssertion checks a get() response against the issued challenge and the stored public key.
+// It returns the authenticator's new signature counter. A counter that fails to increase signals a
+// cloned authenticator and is rejected; authenticators that do not implement counters always
+// report zero.
+func (rp *RelyingParty) VerifyAssertion(cred AssertionCredential, challenge string, storedPublicKey []byte, storedSignCount uint32) (uint32, error) {
+	if cred.Type != "public-key" {
+		return 0, errors.New("credential type must be public-key")
+	}
+	clientDataRaw, err := rp.verifyClientData(cred.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return 0, err
+	}
+
+	rawAuthData, err := decodeBase64URL(cred.Response.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("invalid authenticatorData encoding: %w", err)
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.checkAuthenticatorData(authData); err != nil {
+		return 0, err
+	}
+
+	sig, err := decodeBase64URL(cred.Response.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	key, err := parseCOSEKey(storedPublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataRaw)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := key.verify(signed, sig); err != nil {
+		return 0, err
+	}
+
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return 0, errors.New("signature counter did not increase; the authenticator may be cloned")
+	}
+	return authData.signCount, nil
+}
+
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := decodeBase64URL(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON encoding: %w", err)
+	}
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("invalid clientDataJSON: %w", err)
+	}
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("unexpected ceremony type %q", cd.Type)
+	}
+	if challenge == "" || cd.Challenge != challenge {
+		return nil, errors.New("challenge mismatch")
+	}
+	if cd.CrossOrigin {
+		return nil, errors.New("cross-origin ceremonies are not allowed")
+	}
+	if !rp.allowedOrigin(cd.Origin) {
+		return nil, fmt.Errorf("origin %q is not allowed", cd.Origin)
+	}
+	return raw, nil
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(authData *authenticatorData) error {
+	expected := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.rpIDHash, expected[:]) {
+		return errors.New("credential was created for a different relying party")
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return errors.New("user presence was not confirmed")
+	}
+	return nil
+}
+
+func (rp *RelyingParty) allowedOrigin(origin string) bool {
+	for _, o := range rp.Origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data is too short")
+	}
+	ad.aaguid = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, errors.New("invalid credential ID length")
+	}
+	ad.credID = rest[:idLen]
+	rest = rest[idLen:]
+
+	_, n, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential public key: %w", err)
+	}
+	ad.publicKey = rest[:n]
+	return ad, nil
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// EncodeID base64url encodes a credential ID or user handle the way browsers do.
+func EncodeID(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
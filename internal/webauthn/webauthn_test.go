@@ -0,0 +1,210 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+// softAuthenticator is a minimal software passkey: one P-256 credential with a signature counter.
+type softAuthenticator struct {
+	t         *testing.T
+	rpID      string
+	origin    string
+	key       *ecdsa.PrivateKey
+	credID    []byte
+	signCount uint32
+}
+
+func newSoftAuthenticator(t *testing.T, rpID, origin string) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	credID := make([]byte, 16)
+	rand.Read(credID)
+	return &softAuthenticator{t: t, rpID: rpID, origin: origin, key: key, credID: credID}
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	raw, _ := json.Marshal(map[string]any{"type": ceremony, "challenge": challenge, "origin": a.origin})
+	return raw
+}
+
+func (a *softAuthenticator) authData(flags byte, attested bool) []byte {
+	rpHash := sha256.Sum256([]byte(a.rpID))
+	out := append([]byte{}, rpHash[:]...)
+	out = append(out, flags)
+	out = binary.BigEndian.AppendUint32(out, a.signCount)
+	if attested {
+		out = append(out, make([]byte, 16)...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(a.credID)))
+		out = append(out, a.credID...)
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		a.key.X.FillBytes(x)
+		a.key.Y.FillBytes(y)
+		out = append(out, encodeCBOR(map[any]any{
+			int64(1): int64(2), int64(3): int64(AlgES256), int64(-1): int64(1), int64(-2): x, int64(-3): y,
+		})...)
+	}
+	return out
+}
+
+func (a *softAuthenticator) create(challenge string) RegistrationCredential {
+	var cred RegistrationCredential
+	cred.ID = EncodeID(a.credID)
+	cred.RawID = cred.ID
+	cred.Type = "public-key"
+	cred.Response.ClientDataJSON = EncodeID(a.clientData("webauthn.create", challenge))
+	cred.Response.AttestationObject = EncodeID(encodeCBOR(map[any]any{
+		"fmt":      "none",
+		"attStmt":  map[any]any{},
+		"authData": a.authData(flagUserPresent|flagUserVerified|flagAttestedData, true),
+	}))
+	return cred
+}
+
+func (a *softAuthenticator) get(challenge string) AssertionCredential {
+	a.signCount++
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	authData := a.authData(flagUserPresent|flagUserVerified, false)
+	hash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatalf("failed to sign assertion: %v", err)
+	}
+
+	var cred AssertionCredential
+	cred.ID = EncodeID(a.credID)
+	cred.RawID = cred.ID
+	cred.Type = "public-key"
+	cred.Response.ClientDataJSON = EncodeID(clientDataJSON)
+	cred.Response.AuthenticatorData = EncodeID(authData)
+	cred.Response.Signature = EncodeID(sig)
+	return cred
+}
+
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n < 1<<8:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+	}
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case map[any]any:
+		keys := make([]any, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return string(encodeCBOR(keys[i])) < string(encodeCBOR(keys[j])) })
+		out := head(5, uint64(len(v)))
+		for _, k := range keys {
+			out = append(out, encodeCBOR(k)...)
+			out = append(out, encodeCBOR(v[k])...)
+		}
+		return out
+	}
+	panic("unsupported CBOR value")
+}
+
+func testRelyingParty() *RelyingParty {
+	return &RelyingParty{ID: "axis.example", Name: "Axis", Origins: []string{"https://axis.example"}}
+}
+
+func TestRegistrationAndAssertion(t *testing.T) {
+	rp := testRelyingParty()
+	auth := newSoftAuthenticator(t, rp.ID, "https://axis.example")
+
+	challenge, _ := NewChallenge()
+	cred, err := rp.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration returned error: %v", err)
+	}
+	if EncodeID(cred.ID) != EncodeID(auth.credID) || !cred.UserVerified {
+		t.Fatalf("unexpected credential: %+v", cred)
+	}
+
+	for i := 0; i < 2; i++ {
+		challenge, _ = NewChallenge()
+		assertion := auth.get(challenge)
+		got, err := ClientDataChallenge(assertion.Response.ClientDataJSON)
+		if err != nil || got != challenge {
+			t.Fatalf("ClientDataChallenge = %q, %v; want %q", got, err, challenge)
+		}
+		count, err := rp.VerifyAssertion(assertion, challenge, cred.PublicKey, cred.SignCount)
+		if err != nil {
+			t.Fatalf("VerifyAssertion returned error: %v", err)
+		}
+		if count != auth.signCount {
+			t.Fatalf("sign count = %d, want %d", count, auth.signCount)
+		}
+		cred.SignCount = count
+	}
+}
+
+func TestAssertionRejectsReplayedCounter(t *testing.T) {
+	rp := testRelyingParty()
+	auth := newSoftAuthenticator(t, rp.ID, "https://axis.example")
+	challenge, _ := NewChallenge()
+	cred, err := rp.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration returned error: %v", err)
+	}
+
+	challenge, _ = NewChallenge()
+	if _, err := rp.VerifyAssertion(auth.get(challenge), challenge, cred.PublicKey, 5); err == nil {
+		t.Fatal("expected assertion with a non-increasing counter to be rejected")
+	}
+}
+
+func TestCeremonyRejectsWrongChallengeOriginAndRP(t *testing.T) {
+	rp := testRelyingParty()
+	challenge, _ := NewChallenge()
+
+	auth := newSoftAuthenticator(t, rp.ID, "https://axis.example")
+	if _, err := rp.VerifyRegistration(auth.create(challenge), "other"); err == nil {
+		t.Error("expected registration with a different challenge to be rejected")
+	}
+
+	phishing := newSoftAuthenticator(t, rp.ID, "https://axis.example.evil")
+	if _, err := rp.VerifyRegistration(phishing.create(challenge), challenge); err == nil {
+		t.Error("expected registration from an unknown origin to be rejected")
+	}
+
+	otherRP := newSoftAuthenticator(t, "evil.example", "https://axis.example")
+	if _, err := rp.VerifyRegistration(otherRP.create(challenge), challenge); err == nil {
+		t.Error("expected registration for another relying party to be rejected")
+	}
+
+	cred, err := rp.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration returned error: %v", err)
+	}
+	assertion := auth.get(challenge)
+	assertion.Response.Signature = base64.RawURLEncoding.EncodeToString([]byte("not a signature"))
+	if _, err := rp.VerifyAssertion(assertion, challenge, cred.PublicKey, 0); err == nil {
+		t.Error("expected assertion with a bad signature to be rejected")
+	}
+}